@@ -110,6 +110,35 @@ def normalize_timestamps(
     return parsed
 
 
+def csv_read_options(
+    delimiter: str = ",",
+    encoding: Optional[str] = None,
+    quotechar: str = '"',
+    escape: str = "doubled",
+    no_header: bool = False,
+    decimal: str = ".",
+) -> dict:
+    """Translate a saved CSV dialect into pandas.read_csv keyword arguments."""
+    options = {
+        "sep": "\t" if delimiter == "\\t" else delimiter,
+        "encoding": encoding or "utf-8",
+        "quotechar": quotechar or '"',
+        "decimal": decimal or ".",
+    }
+    if escape == "backslash":
+        options["escapechar"] = "\\"
+        options["doublequote"] = False
+    if no_header:
+        options["header"] = None
+    return options
+
+
+def name_headerless_columns(df: pd.DataFrame) -> pd.DataFrame:
+    """Name the columns of a file without a header row column_1, column_2, ..."""
+    df.columns = [f"column_{i + 1}" for i in range(len(df.columns))]
+    return df
+
+
 def load_csv_dataframe(
     file_path: str,
     case_col: str,
@@ -122,8 +151,14 @@ def load_csv_dataframe(
     timestamp_timezone: Optional[str] = None,
     delimiter: str = ",",
     encoding: Optional[str] = None,
+    quotechar: str = '"',
+    escape: str = "doubled",
+    no_header: bool = False,
+    decimal: str = ".",
 ) -> pd.DataFrame:
-    df = pd.read_csv(file_path, sep=delimiter, encoding=encoding or "utf-8")
+    df = pd.read_csv(file_path, **csv_read_options(delimiter, encoding, quotechar, escape, no_header, decimal))
+    if no_header:
+        df = name_headerless_columns(df)
     return normalize_dataframe_columns(
         df,
         case_col,
@@ -204,13 +239,20 @@ def load_zip_csv_dataframe(
     timestamp_timezone: Optional[str] = None,
     delimiter: str = ",",
     encoding: Optional[str] = None,
+    quotechar: str = '"',
+    escape: str = "doubled",
+    no_header: bool = False,
+    decimal: str = ".",
     zip_member: Optional[str] = None,
 ) -> pd.DataFrame:
+    options = csv_read_options(delimiter, encoding, quotechar, escape, no_header, decimal)
     if zip_member:
         path = f"zip://{file_path}::{zip_member}"
-        df = pd.read_csv(path, sep=delimiter, encoding=encoding or "utf-8")
+        df = pd.read_csv(path, **options)
     else:
-        df = pd.read_csv(file_path, sep=delimiter, encoding=encoding or "utf-8", compression="zip")
+        df = pd.read_csv(file_path, compression="zip", **options)
+    if no_header:
+        df = name_headerless_columns(df)
     return normalize_dataframe_columns(
         df,
         case_col,
@@ -275,6 +317,10 @@ def load_event_log(
     timestamp_timezone: Optional[str] = None,
     delimiter: str = ",",
     encoding: Optional[str] = None,
+    quotechar: str = '"',
+    escape: str = "doubled",
+    no_header: bool = False,
+    decimal: str = ".",
     sheet: Optional[str] = None,
     json_lines: bool = False,
     zip_member: Optional[str] = None,
@@ -300,6 +346,10 @@ def load_event_log(
             timestamp_timezone=timestamp_timezone,
             delimiter=delimiter,
             encoding=encoding,
+            quotechar=quotechar,
+            escape=escape,
+            no_header=no_header,
+            decimal=decimal,
         )
     elif format_key == "parquet":
         df = pd.read_parquet(file_path)
@@ -353,6 +403,10 @@ def load_event_log(
             timestamp_timezone=timestamp_timezone,
             delimiter=delimiter,
             encoding=encoding,
+            quotechar=quotechar,
+            escape=escape,
+            no_header=no_header,
+            decimal=decimal,
             zip_member=zip_member,
         )
     else:
@@ -31,6 +31,10 @@ def parse_arguments() -> argparse.Namespace:
     parser.add_argument("--resource", help="Resource column name (CSV only).")
     parser.add_argument("--delimiter", default=",", help="CSV delimiter (CSV only).")
     parser.add_argument("--encoding", default="utf-8", help="CSV encoding (CSV only).")
+    parser.add_argument("--quotechar", default='"', help="CSV quote character (CSV only).")
+    parser.add_argument("--escape", choices=["doubled", "backslash"], default="doubled", help="CSV quote escaping (CSV only).")
+    parser.add_argument("--no-header", action="store_true", help="CSV has no header row; columns are named column_1.. (CSV only).")
+    parser.add_argument("--decimal", default=".", help="Decimal separator of numeric fields (CSV only).")
     parser.add_argument("--sheet", help="Excel sheet name (XLSX only).")
     parser.add_argument("--json-lines", action="store_true", help="Parse JSON lines format (JSON only).")
     parser.add_argument("--zip-member", help="CSV member name inside zip archive (ZIP-CSV only).")
@@ -62,6 +66,10 @@ def main() -> None:
             timestamp_timezone=args.timestamp_timezone,
             delimiter=args.delimiter,
             encoding=args.encoding,
+            quotechar=args.quotechar,
+            escape=args.escape,
+            no_header=args.no_header,
+            decimal=args.decimal,
             sheet=args.sheet,
             json_lines=args.json_lines,
             zip_member=args.zip_member,
@@ -0,0 +1,540 @@
+// Package api extracts records from paginated JSON HTTP APIs, such as
+// ServiceNow or Jira REST endpoints and Dynamics OData feeds, into a flat CSV.
+// Requests are GET only; rate limits and transient errors are retried with
+// backoff.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// Pagination types.
+const (
+	PageNone     = "none"
+	PageNextLink = "next_link"
+	PageOffset   = "offset"
+	PageCursor   = "cursor"
+)
+
+// Auth types.
+const (
+	AuthNone   = "none"
+	AuthBearer = "bearer"
+	AuthOAuth2 = "oauth2"
+)
+
+// DefaultNextLink is the OData v4 next-page link.
+const DefaultNextLink = "$['@odata.nextLink']"
+
+const (
+	// RequestTimeout bounds each HTTP request.
+	RequestTimeout = time.Minute
+	// maxRetries is how often a rate-limited or unavailable request is
+	// retried before the extract fails.
+	maxRetries = 5
+	maxBackoff = time.Minute
+)
+
+// PaginationTypes returns the supported pagination types.
+func PaginationTypes() []string {
+	return []string{PageNone, PageNextLink, PageOffset, PageCursor}
+}
+
+// AuthTypes returns the supported auth types.
+func AuthTypes() []string {
+	return []string{AuthNone, AuthBearer, AuthOAuth2}
+}
+
+// Result summarises an extract.
+type Result struct {
+	Pages   int
+	Rows    int64
+	Columns []string
+	// Retries counts requests repeated after a rate limit or a transient
+	// server error.
+	Retries int
+}
+
+// Client reads one configured API.
+type Client struct {
+	cfg     config.APIConfig
+	secret  string
+	http    *http.Client
+	records Path
+	project *Projection
+	next    Path
+	cursor  Path
+
+	token       string
+	tokenExpiry time.Time
+	retries     int
+}
+
+// New validates cfg and returns a client. The secret is the bearer token or
+// the OAuth2 client secret.
+func New(cfg config.APIConfig, secret string) (*Client, error) {
+	c, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Auth.Type {
+	case AuthBearer:
+		if secret == "" {
+			return nil, errors.New("bearer auth requires a token in the credential env var")
+		}
+	case AuthOAuth2:
+		if secret == "" {
+			return nil, errors.New("oauth2 auth requires the client secret in the credential env var")
+		}
+	}
+	c.secret = secret
+	return c, nil
+}
+
+// Validate checks the URL, JSONPaths, pagination, and auth settings.
+func Validate(cfg config.APIConfig) error {
+	_, err := newClient(cfg)
+	return err
+}
+
+func newClient(cfg config.APIConfig) (*Client, error) {
+	parsed, err := url.Parse(cfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("api connector requires an http(s) URL: %q", cfg.URL)
+	}
+	c := &Client{cfg: cfg, http: &http.Client{Timeout: RequestTimeout, CheckRedirect: sameOriginRedirect}}
+	switch cfg.Auth.Type {
+	case "", AuthNone, AuthBearer:
+	case AuthOAuth2:
+		if cfg.Auth.TokenURL == "" || cfg.Auth.ClientID == "" {
+			return nil, errors.New("oauth2 auth requires a token URL and client ID")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported api auth type: %s", cfg.Auth.Type)
+	}
+	if c.records, err = ParsePath(cfg.Records); err != nil {
+		return nil, err
+	}
+	if c.project, err = NewProjection(cfg.Fields); err != nil {
+		return nil, err
+	}
+	pagination := cfg.Pagination
+	switch pagination.Type {
+	case "", PageNone, PageOffset:
+	case PageNextLink:
+		if pagination.NextLink == "" {
+			pagination.NextLink = DefaultNextLink
+		}
+		if c.next, err = ParsePath(pagination.NextLink); err != nil {
+			return nil, err
+		}
+	case PageCursor:
+		if pagination.CursorPath == "" {
+			return nil, errors.New("cursor pagination requires the cursor's JSONPath in the response")
+		}
+		if c.cursor, err = ParsePath(pagination.CursorPath); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported api pagination type: %s", pagination.Type)
+	}
+	if pagination.OffsetParam == "" {
+		pagination.OffsetParam = "offset"
+	}
+	if pagination.LimitParam == "" {
+		pagination.LimitParam = "limit"
+	}
+	if pagination.CursorParam == "" {
+		pagination.CursorParam = "cursor"
+	}
+	c.cfg.Pagination = pagination
+	return c, nil
+}
+
+// Extract pages through the API and writes the projected records to
+// outputPath as CSV. Missing fields and JSON nulls are written empty; nested
+// objects and arrays are written as compact JSON.
+func (c *Client) Extract(ctx context.Context, outputPath string) (Result, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return Result{}, err
+	}
+	defer file.Close()
+	writer := c.project.NewWriter(file, filepath.Dir(outputPath), nil)
+	defer writer.Discard()
+	result := Result{}
+	pages, err := c.pages(ctx, func(records []any) error {
+		for _, record := range records {
+			if err := writer.Write(nil, record); err != nil {
+				return err
+			}
+			result.Rows++
+		}
+		return nil
+	})
+	result.Pages, result.Retries = pages, c.retries
+	if err != nil {
+		return result, err
+	}
+	if result.Rows == 0 && !c.project.Fixed() {
+		return result, errors.New("the API returned no records, so no columns could be projected")
+	}
+	if result.Columns, err = writer.Close(); err != nil {
+		return result, err
+	}
+	return result, file.Close()
+}
+
+// Probe fetches the first page and returns its record count and the columns
+// the projection would produce from it.
+func (c *Client) Probe(ctx context.Context) (int, []string, error) {
+	doc, err := c.get(ctx, c.firstURL())
+	if err != nil {
+		return 0, nil, err
+	}
+	records, err := c.pageRecords(doc)
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(records), c.project.Columns(records), nil
+}
+
+// pages calls fn with the records of each page and returns the page count.
+func (c *Client) pages(ctx context.Context, fn func(records []any) error) (int, error) {
+	pagination := c.cfg.Pagination
+	pageURL := c.firstURL()
+	offset, largest := 0, 0
+	for page := 1; ; page++ {
+		doc, err := c.get(ctx, pageURL)
+		if err != nil {
+			return page - 1, err
+		}
+		records, err := c.pageRecords(doc)
+		if err != nil {
+			return page - 1, err
+		}
+		if err := fn(records); err != nil {
+			return page, err
+		}
+		if c.cfg.MaxPages > 0 && page >= c.cfg.MaxPages {
+			return page, nil
+		}
+		next := ""
+		switch pagination.Type {
+		case PageNextLink:
+			if link, ok := c.next.Lookup(doc); ok {
+				next = resolveLink(pageURL, Flatten(link))
+				// The token and headers go with every page, so a link to
+				// another host would hand them to whoever serves it.
+				if next != "" && !sameOrigin(c.cfg.URL, next) {
+					return page, fmt.Errorf("next link %s is not on %s; refusing to send credentials to another host", next, origin(c.cfg.URL))
+				}
+			}
+		case PageOffset:
+			// Servers may cap the page below PageSize, so only a page
+			// shorter than the largest one served so far is the last.
+			offset += len(records)
+			if len(records) > 0 && len(records) >= largest {
+				next = c.offsetURL(offset)
+			}
+			largest = max(largest, len(records))
+		case PageCursor:
+			if cursor, ok := c.cursor.Lookup(doc); ok && Flatten(cursor) != "" {
+				next = withQuery(c.cfg.URL, map[string]string{pagination.CursorParam: Flatten(cursor)}, c.limitParam())
+			}
+		}
+		if next == "" || next == pageURL {
+			return page, nil
+		}
+		pageURL = next
+	}
+}
+
+func (c *Client) firstURL() string {
+	if c.cfg.Pagination.Type == PageOffset {
+		return c.offsetURL(0)
+	}
+	return withQuery(c.cfg.URL, nil, c.limitParam())
+}
+
+func (c *Client) offsetURL(offset int) string {
+	return withQuery(c.cfg.URL, map[string]string{c.cfg.Pagination.OffsetParam: strconv.Itoa(offset)}, c.limitParam())
+}
+
+// limitParam returns the page-size parameter, if a page size is set.
+func (c *Client) limitParam() map[string]string {
+	if c.cfg.Pagination.PageSize <= 0 || c.cfg.Pagination.Type == PageNextLink {
+		return nil
+	}
+	return map[string]string{c.cfg.Pagination.LimitParam: strconv.Itoa(c.cfg.Pagination.PageSize)}
+}
+
+func (c *Client) pageRecords(doc any) ([]any, error) {
+	value, ok := c.records.Lookup(doc)
+	if !ok {
+		return nil, fmt.Errorf("records path %s not found in the response", c.cfg.Records)
+	}
+	switch v := value.(type) {
+	case []any:
+		return v, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("records path %s is not an array", c.cfg.Records)
+	}
+}
+
+// get fetches and decodes one page. A 429 or 502-504 response is retried
+// after its Retry-After delay, or with exponential backoff, as is a dropped or
+// timed-out connection; an expired OAuth2 token is refreshed once.
+func (c *Client) get(ctx context.Context, pageURL string) (any, error) {
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		for name, value := range c.cfg.Headers {
+			req.Header.Set(name, value)
+		}
+		if err := c.authorize(ctx, req); err != nil {
+			return nil, err
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			if ctx.Err() == nil && transient(err) && attempt < maxRetries {
+				c.retries++
+				if err := sleep(ctx, retryDelay("", attempt)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && c.cfg.Auth.Type == AuthOAuth2 && !refreshed {
+			drain(resp)
+			c.token, refreshed = "", true
+			continue
+		}
+		if retryable(resp.StatusCode) && attempt < maxRetries {
+			delay := retryDelay(resp.Header.Get("Retry-After"), attempt)
+			drain(resp)
+			c.retries++
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("GET %s: %s%s", pageURL, resp.Status, bodySnippet(resp.Body))
+		}
+		decoder := json.NewDecoder(resp.Body)
+		decoder.UseNumber()
+		var doc any
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("GET %s: invalid JSON: %w", pageURL, err)
+		}
+		return doc, nil
+	}
+}
+
+func (c *Client) authorize(ctx context.Context, req *http.Request) error {
+	switch c.cfg.Auth.Type {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+c.secret)
+	case AuthOAuth2:
+		if c.token == "" || time.Now().After(c.tokenExpiry) {
+			if err := c.fetchToken(ctx); err != nil {
+				return err
+			}
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return nil
+}
+
+// fetchToken runs the OAuth2 client credentials grant, refreshing a little
+// before the token expires.
+func (c *Client) fetchToken(ctx context.Context) error {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.cfg.Auth.ClientID},
+		"client_secret": {c.secret},
+	}
+	if c.cfg.Auth.Scope != "" {
+		form.Set("scope", c.cfg.Auth.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.Auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("oauth2 token request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth2 token request: %s%s", resp.Status, bodySnippet(resp.Body))
+	}
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("oauth2 token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return errors.New("oauth2 token response has no access_token")
+	}
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Hour)
+	if seconds, err := token.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(seconds)*time.Second - 30*time.Second)
+	}
+	return nil
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transient reports whether a request failed in transport in a way worth
+// retrying: a timeout, a refused or reset connection, or a connection closed
+// before the response.
+func transient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// sameOriginRedirect refuses redirects to another scheme or host, which would
+// otherwise receive the configured headers and credentials.
+func sameOriginRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !sameOrigin(via[0].URL.String(), req.URL.String()) {
+		return fmt.Errorf("redirect to %s leaves %s; refusing to send credentials to another host", req.URL.Redacted(), origin(via[0].URL.String()))
+	}
+	return nil
+}
+
+// retryDelay honours Retry-After in seconds or as an HTTP date and otherwise
+// backs off exponentially from one second.
+func retryDelay(header string, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, maxBackoff)
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return min(max(time.Until(at), 0), maxBackoff)
+	}
+	return min(time.Second<<attempt, maxBackoff)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+func bodySnippet(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 200))
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return ""
+	}
+	return ": " + text
+}
+
+// withQuery sets query parameters on base.
+func withQuery(base string, params ...map[string]string) string {
+	parsed, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	query := parsed.Query()
+	for _, set := range params {
+		for name, value := range set {
+			query.Set(name, value)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// resolveLink resolves a possibly relative next link against the current
+// page.
+func resolveLink(current string, link string) string {
+	if link == "" {
+		return ""
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// sameOrigin reports whether two URLs share a scheme and host (with port).
+func sameOrigin(a string, b string) bool {
+	left, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	right, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(left.Scheme, right.Scheme) && strings.EqualFold(left.Host, right.Host)
+}
+
+// origin returns the scheme and host of a URL.
+func origin(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+func TestODataNextLinkWithOAuth2AndRateLimit(t *testing.T) {
+	tokens, throttled := 0, false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_secret") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tokens++
+		fmt.Fprintf(w, `{"access_token":"tok-%d","expires_in":3600}`, tokens)
+	})
+	mux.HandleFunc("/odata/incidents", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer tok-") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("$skiptoken") == "" {
+			fmt.Fprint(w, `{"value":[
+				{"id":1,"state":{"name":"New"},"opened":"2024-01-01T08:00:00Z","tags":["a"]},
+				{"id":2,"state":{"name":"Closed, resolved"},"opened":"2024-01-02T08:00:00Z","tags":null}
+			],"@odata.nextLink":"incidents?$skiptoken=2"}`)
+			return
+		}
+		if !throttled {
+			throttled = true
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"value":[{"id":3,"opened":"2024-01-03T08:00:00Z"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := New(config.APIConfig{
+		URL:     server.URL + "/odata/incidents",
+		Records: "$.value",
+		Fields: []config.APIField{
+			{Name: "case_id", Path: "$.id"},
+			{Name: "activity", Path: "$.state.name"},
+			{Name: "timestamp", Path: "opened"},
+			{Name: "tags", Path: "$.tags"},
+		},
+		Pagination: config.APIPagination{Type: PageNextLink},
+		Auth:       config.APIAuth{Type: AuthOAuth2, TokenURL: server.URL + "/token", ClientID: "pm-assist"},
+	}, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "source_extract.csv")
+	result, err := client.Extract(context.Background(), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Pages != 2 || result.Rows != 3 || result.Retries != 1 || tokens != 1 {
+		t.Fatalf("result = %+v, tokens = %d", result, tokens)
+	}
+	data, _ := os.ReadFile(out)
+	want := strings.Join([]string{
+		"case_id,activity,timestamp,tags",
+		`1,New,2024-01-01T08:00:00Z,"[""a""]"`,
+		`2,"Closed, resolved",2024-01-02T08:00:00Z,`,
+		"3,,2024-01-03T08:00:00Z,",
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Fatalf("extract =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestInferredColumnsCoverKeysOfLaterPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprint(w, `{"value":[{"id":1,"state":"New"}],"next":"?page=2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"value":[{"id":2,"state":"Closed","priority":"high"}]}`)
+	}))
+	defer server.Close()
+
+	client, err := New(config.APIConfig{
+		URL:        server.URL + "/incidents",
+		Records:    "$.value",
+		Pagination: config.APIPagination{Type: PageNextLink, NextLink: "$.next"},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "source_extract.csv")
+	result, err := client.Extract(context.Background(), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if strings.Join(result.Columns, ",") != "id,priority,state" || string(data) != "id,priority,state\n1,,New\n2,high,Closed\n" {
+		t.Fatalf("columns = %v, extract =\n%s", result.Columns, data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(out)); len(entries) != 1 {
+		t.Fatalf("spool file left behind: %v", entries)
+	}
+}
+
+func TestNextLinkToAnotherHostIsRefused(t *testing.T) {
+	leaked := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = leaked || r.Header.Get("Authorization") != ""
+		fmt.Fprint(w, `{"value":[]}`)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"value":[{"id":1}],"@odata.nextLink":%q}`, other.URL+"/steal?$skiptoken=2")
+	}))
+	defer server.Close()
+
+	client, err := New(config.APIConfig{
+		URL:        server.URL + "/odata/incidents",
+		Records:    "$.value",
+		Pagination: config.APIPagination{Type: PageNextLink},
+		Auth:       config.APIAuth{Type: AuthBearer},
+	}, "api-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Extract(context.Background(), filepath.Join(t.TempDir(), "source_extract.csv"))
+	if err == nil || !strings.Contains(err.Error(), "another host") {
+		t.Fatalf("expected a cross-host next link to be refused, got %v", err)
+	}
+	if leaked {
+		t.Fatal("bearer token was sent to another host")
+	}
+}
+
+func TestRedirectToAnotherHostIsRefused(t *testing.T) {
+	leaked := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = leaked || r.Header.Get("X-Api-Key") != "" || r.Header.Get("Authorization") != ""
+		fmt.Fprint(w, `{"value":[]}`)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/steal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client, err := New(config.APIConfig{
+		URL:     server.URL + "/odata/incidents",
+		Records: "$.value",
+		Headers: map[string]string{"X-Api-Key": "secret"},
+		Auth:    config.APIAuth{Type: AuthBearer},
+	}, "api-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Extract(context.Background(), filepath.Join(t.TempDir(), "source_extract.csv"))
+	if err == nil || !strings.Contains(err.Error(), "another host") {
+		t.Fatalf("expected a cross-host redirect to be refused, got %v", err)
+	}
+	if leaked {
+		t.Fatal("credentials were sent to another host")
+	}
+}
+
+func TestDroppedConnectionIsRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		fmt.Fprint(w, `{"value":[{"id":1}]}`)
+	}))
+	defer server.Close()
+
+	client, err := New(config.APIConfig{URL: server.URL + "/odata/incidents", Records: "$.value"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.Extract(context.Background(), filepath.Join(t.TempDir(), "source_extract.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 1 || result.Retries != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestOffsetAndCursorPagination(t *testing.T) {
+	events := []string{`{"key":"A","n":1}`, `{"key":"B","n":2}`, `{"key":"C","n":3}`}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		query := r.URL.Query()
+		start := 0
+		fmt.Sscan(query.Get("startAt")+query.Get("cursor"), &start)
+		end := min(start+2, len(events))
+		if r.URL.Path == "/cursor" {
+			next := ""
+			if end < len(events) {
+				next = fmt.Sprint(end)
+			}
+			fmt.Fprintf(w, `{"data":{"items":[%s]},"next":%q}`, strings.Join(events[start:end], ","), next)
+			return
+		}
+		fmt.Fprintf(w, `{"issues":[%s]}`, strings.Join(events[start:end], ","))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path       string
+		records    string
+		pagination config.APIPagination
+	}{
+		{"/offset", "$.issues", config.APIPagination{Type: PageOffset, OffsetParam: "startAt", LimitParam: "maxResults", PageSize: 2}},
+		// The server caps pages at 2, below the requested page size.
+		{"/offset", "$.issues", config.APIPagination{Type: PageOffset, OffsetParam: "startAt", LimitParam: "maxResults", PageSize: 5}},
+		{"/cursor", "$.data.items", config.APIPagination{Type: PageCursor, CursorPath: "$.next"}},
+	} {
+		client, err := New(config.APIConfig{
+			URL:        server.URL + tc.path,
+			Records:    tc.records,
+			Pagination: tc.pagination,
+			Auth:       config.APIAuth{Type: AuthBearer},
+		}, "api-token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := filepath.Join(t.TempDir(), "source_extract.csv")
+		result, err := client.Extract(context.Background(), out)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.path, err)
+		}
+		data, _ := os.ReadFile(out)
+		if result.Pages != 2 || string(data) != "key,n\nA,1\nB,2\nC,3\n" {
+			t.Fatalf("%s: %+v\n%s", tc.path, result, data)
+		}
+	}
+
+	if _, err := ParsePath("$.items[*].id"); err == nil {
+		t.Fatal("expected wildcard paths to be rejected")
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed JSONPath of child names and array indexes, such as
+// $.fields.status.name, $['@odata.nextLink'] or $.items[0].id. Wildcards,
+// filters, and recursive descent are not supported.
+type Path []pathStep
+
+type pathStep struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// ParsePath parses a JSONPath; the leading "$" is optional.
+func ParsePath(expr string) (Path, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	path := Path{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty name", expr)
+			}
+			path = append(path, pathStep{key: rest[:end], isKey: true})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed bracket", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, pathStep{key: inner[1 : len(inner)-1], isKey: true})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unsupported selector [%s]", expr, inner)
+			}
+			path = append(path, pathStep{index: index})
+		default:
+			if len(path) > 0 || strings.HasPrefix(strings.TrimSpace(expr), "$") {
+				return nil, fmt.Errorf("invalid JSONPath %q", expr)
+			}
+			// A bare name such as "id" is read as $.id.
+			rest = "." + rest
+		}
+	}
+	return path, nil
+}
+
+// Lookup returns the value at the path in a decoded JSON document.
+func (p Path) Lookup(doc any) (any, bool) {
+	current := doc
+	for _, step := range p {
+		if step.isKey {
+			object, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[step.key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		array, ok := current.([]any)
+		if !ok || step.index >= len(array) {
+			return nil, false
+		}
+		current = array[step.index]
+	}
+	return current, true
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// Projection flattens decoded JSON records into CSV rows. Without configured
+// fields it projects every top-level key seen in any record.
+type Projection struct {
+	fields []field
+}
+
+type field struct {
+	name string
+	path Path
+}
+
+// NewProjection parses the field paths.
+func NewProjection(fields []config.APIField) (*Projection, error) {
+	p := &Projection{}
+	for _, f := range fields {
+		path, err := ParsePath(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		p.fields = append(p.fields, field{name: f.Name, path: path})
+	}
+	return p, nil
+}
+
+// Fixed reports whether the columns were configured rather than inferred.
+func (p *Projection) Fixed() bool {
+	return len(p.fields) > 0
+}
+
+// Columns returns the configured column names, or the sorted union of the
+// records' top-level keys (a single "value" column for scalars).
+func (p *Projection) Columns(records []any) []string {
+	if p.Fixed() {
+		names := make([]string, len(p.fields))
+		for i, f := range p.fields {
+			names[i] = f.name
+		}
+		return names
+	}
+	keys := map[string]bool{}
+	for _, record := range records {
+		for name := range topLevel(record) {
+			keys[name] = true
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// row projects one record onto the configured fields. Missing fields and
+// JSON nulls are empty; nested objects and arrays are compact JSON.
+func (p *Projection) row(record any) []string {
+	row := make([]string, len(p.fields))
+	for i, f := range p.fields {
+		value, _ := f.path.Lookup(record)
+		row[i] = Flatten(value)
+	}
+	return row
+}
+
+// topLevel flattens each top-level key of a record, or the whole value of a
+// scalar under "value".
+func topLevel(record any) map[string]string {
+	object, ok := record.(map[string]any)
+	if !ok {
+		return map[string]string{"value": Flatten(record)}
+	}
+	values := make(map[string]string, len(object))
+	for name, value := range object {
+		values[name] = Flatten(value)
+	}
+	return values
+}
+
+func sortedKeys(keys map[string]bool) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Writer writes projected records as CSV, each row led by the caller's own
+// fields. With configured fields rows are written as they arrive. Without
+// them the header is the union of every record's keys, so rows are spooled
+// to a temporary file and written once the last record is in; a key that
+// first appears late in the extract is not dropped.
+type Writer struct {
+	project *Projection
+	prefix  []string
+	csv     *csv.Writer
+	header  bool
+	dir     string
+	keys    map[string]bool
+	spool   *os.File
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// spooled is one record waiting for the final header.
+type spooled struct {
+	Lead   []string          `json:"l"`
+	Values map[string]string `json:"v"`
+}
+
+// NewWriter returns a writer whose header starts with prefix. Spooled rows
+// are kept in a temporary file under dir.
+func (p *Projection) NewWriter(out io.Writer, dir string, prefix []string) *Writer {
+	return &Writer{project: p, prefix: prefix, csv: csv.NewWriter(out), dir: dir, keys: map[string]bool{}}
+}
+
+// Write adds one record, led by the values of the prefix columns.
+func (w *Writer) Write(lead []string, record any) error {
+	if w.project.Fixed() {
+		if err := w.writeHeader(w.project.Columns(nil)); err != nil {
+			return err
+		}
+		return w.csv.Write(append(append([]string{}, lead...), w.project.row(record)...))
+	}
+	values := topLevel(record)
+	for name := range values {
+		w.keys[name] = true
+	}
+	if w.spool == nil {
+		spool, err := os.CreateTemp(w.dir, ".pm-assist-records-*.jsonl")
+		if err != nil {
+			return err
+		}
+		w.spool, w.buffer = spool, bufio.NewWriter(spool)
+		w.encoder = json.NewEncoder(w.buffer)
+	}
+	return w.encoder.Encode(spooled{Lead: lead, Values: values})
+}
+
+// Close writes the header and any spooled rows, flushes, and returns the
+// columns, prefix included.
+func (w *Writer) Close() ([]string, error) {
+	columns := w.project.Columns(nil)
+	if !w.project.Fixed() {
+		columns = sortedKeys(w.keys)
+	}
+	if err := w.writeHeader(columns); err != nil {
+		return nil, err
+	}
+	if w.spool != nil {
+		defer os.Remove(w.spool.Name())
+		defer w.spool.Close()
+		if err := w.buffer.Flush(); err != nil {
+			return nil, err
+		}
+		if _, err := w.spool.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bufio.NewReader(w.spool))
+		for {
+			var row spooled
+			if err := decoder.Decode(&row); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("read spooled records: %w", err)
+			}
+			out := append([]string{}, row.Lead...)
+			for _, name := range columns {
+				out = append(out, row.Values[name])
+			}
+			if err := w.csv.Write(out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.csv.Flush()
+	return append(append([]string{}, w.prefix...), columns...), w.csv.Error()
+}
+
+// Discard removes the spool file of a write that was abandoned before Close.
+func (w *Writer) Discard() {
+	if w.spool != nil {
+		w.spool.Close()
+		os.Remove(w.spool.Name())
+	}
+}
+
+func (w *Writer) writeHeader(columns []string) error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.csv.Write(append(append([]string{}, w.prefix...), columns...))
+}
+
+// Flatten renders a decoded JSON value as a CSV field.
+func Flatten(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
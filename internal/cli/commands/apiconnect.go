@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/api"
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// apiFlags carries the connect flags for API connectors.
+type apiFlags struct {
+	URL         string
+	Records     string
+	Fields      string
+	Pagination  string
+	NextLink    string
+	PageSize    string
+	OffsetParam string
+	LimitParam  string
+	CursorParam string
+	CursorPath  string
+	MaxPages    string
+	Auth        string
+	TokenURL    string
+	ClientID    string
+	Scope       string
+	CredEnv     string
+}
+
+// resolveAPIConfig asks for the API endpoint, projection, pagination, and
+// auth, and returns them with the credential env var name.
+func resolveAPIConfig(flags apiFlags) (config.APIConfig, string, error) {
+	var cfg config.APIConfig
+	endpoint, err := resolveString(flags.URL, "API URL (first page, including filters)", "", true)
+	if err != nil {
+		return cfg, "", err
+	}
+	records, err := resolveString(flags.Records, "Records JSONPath (e.g., $.value for OData, $.result for ServiceNow; empty when the response is an array)", "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	fieldList, err := resolveString(flags.Fields, "Fields as column=JSONPath, comma-separated (empty projects every top-level key)", "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	fields, err := parseAPIFields(fieldList)
+	if err != nil {
+		return cfg, "", err
+	}
+
+	pagination := config.APIPagination{}
+	pagination.Type, err = resolveChoice(flags.Pagination, "Pagination", api.PaginationTypes(), api.PageNone, true)
+	if err != nil {
+		return cfg, "", err
+	}
+	switch pagination.Type {
+	case api.PageNextLink:
+		pagination.NextLink, err = resolveString(flags.NextLink, "Next link JSONPath", api.DefaultNextLink, true)
+	case api.PageOffset:
+		pagination.OffsetParam, err = resolveString(flags.OffsetParam, "Offset query parameter (e.g., $skip, startAt, sysparm_offset)", "offset", true)
+		if err == nil {
+			pagination.LimitParam, err = resolveString(flags.LimitParam, "Page size query parameter (e.g., $top, maxResults, sysparm_limit)", "limit", true)
+		}
+	case api.PageCursor:
+		pagination.CursorPath, err = resolveString(flags.CursorPath, "Next cursor JSONPath in the response", "", true)
+		if err == nil {
+			pagination.CursorParam, err = resolveString(flags.CursorParam, "Cursor query parameter", "cursor", true)
+		}
+	}
+	if err != nil {
+		return cfg, "", err
+	}
+	if pagination.Type == api.PageOffset || pagination.Type == api.PageCursor {
+		sizeText, err := resolveString(flags.PageSize, "Page size", "100", true)
+		if err != nil {
+			return cfg, "", err
+		}
+		if pagination.PageSize, err = strconv.Atoi(sizeText); err != nil || pagination.PageSize <= 0 {
+			return cfg, "", fmt.Errorf("invalid page size: %s", sizeText)
+		}
+	}
+	maxPages := 0
+	if flags.MaxPages != "" {
+		if maxPages, err = strconv.Atoi(flags.MaxPages); err != nil || maxPages < 0 {
+			return cfg, "", fmt.Errorf("invalid --max-pages: %s", flags.MaxPages)
+		}
+	}
+
+	auth := config.APIAuth{}
+	auth.Type, err = resolveChoice(flags.Auth, "Auth", api.AuthTypes(), api.AuthNone, true)
+	if err != nil {
+		return cfg, "", err
+	}
+	credEnv := ""
+	switch auth.Type {
+	case api.AuthBearer:
+		credEnv, err = resolveString(flags.CredEnv, "Credential env var holding the bearer token", "", true)
+	case api.AuthOAuth2:
+		auth.TokenURL, err = resolveString(flags.TokenURL, "OAuth2 token URL", "", true)
+		if err == nil {
+			auth.ClientID, err = resolveString(flags.ClientID, "OAuth2 client ID", "", true)
+		}
+		if err == nil {
+			auth.Scope, err = resolveString(flags.Scope, "OAuth2 scope (optional)", "", false)
+		}
+		if err == nil {
+			credEnv, err = resolveString(flags.CredEnv, "Credential env var holding the client secret", "", true)
+		}
+	}
+	if err != nil {
+		return cfg, "", err
+	}
+	if credEnv != "" {
+		fmt.Println("[INFO] Credentials are never stored in config. Set the env var before connecting.")
+		fmt.Printf("[INFO] Using credential env var: %s\n", credEnv)
+	}
+	cfg = config.APIConfig{
+		URL:        endpoint,
+		Records:    records,
+		Fields:     fields,
+		Pagination: pagination,
+		Auth:       auth,
+		MaxPages:   maxPages,
+	}
+	return cfg, credEnv, api.Validate(cfg)
+}
+
+// parseAPIFields reads "column=path" items; a bare name projects the
+// top-level key of that name.
+func parseAPIFields(list string) ([]config.APIField, error) {
+	fields := []config.APIField{}
+	for _, item := range splitCSV(list) {
+		name, path, found := strings.Cut(item, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !found {
+			path = name
+		}
+		if name == "" || path == "" {
+			return nil, fmt.Errorf("invalid field %q; use column=JSONPath", item)
+		}
+		if _, err := api.ParsePath(path); err != nil {
+			return nil, err
+		}
+		fields = append(fields, config.APIField{Name: name, Path: path})
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// connectorAPI returns the client for an API connector with the secret from
+// its credential env var.
+func connectorAPI(spec config.ConnectorSpec) (*api.Client, error) {
+	if spec.API == nil {
+		return nil, fmt.Errorf("api connector %s missing api config", spec.Name)
+	}
+	secret := ""
+	if spec.Options != nil && spec.Options.CredentialEnv != "" {
+		secret = os.Getenv(spec.Options.CredentialEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("credential env var %s is not set", spec.Options.CredentialEnv)
+		}
+	}
+	return api.New(*spec.API, secret)
+}
@@ -1,18 +1,22 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/pm-assist/pm-assist/internal/api"
 	"github.com/pm-assist/pm-assist/internal/app"
 	"github.com/pm-assist/pm-assist/internal/cli/prompt"
 	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
 	"github.com/pm-assist/pm-assist/internal/db"
 	"github.com/pm-assist/pm-assist/internal/policy"
 	"github.com/pm-assist/pm-assist/internal/preview"
+	"github.com/pm-assist/pm-assist/internal/sap"
 	"github.com/pm-assist/pm-assist/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -26,13 +30,19 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 		flagFormat      string
 		flagDelimiter   string
 		flagEncoding    string
+		flagQuote       string
+		flagEscape      string
+		flagHeader      string
+		flagDecimal     string
 		flagSheet       string
 		flagJSONLines   string
 		flagZipMember   string
+		flagAliases     string
 		flagPreview     string
 		flagCountRows   string
 		flagDriver      string
 		flagHost        string
+		flagDBFile      string
 		flagPort        string
 		flagDBName      string
 		flagSchema      string
@@ -41,6 +51,13 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 		flagCredEnv     string
 		flagTest        string
 		flagListCatalog string
+		flagCatSchema   string
+		flagCatTable    string
+		flagBuildQuery  string
+		flagProcess     string
+		flagObject      objectFlags
+		flagAPI         apiFlags
+		flagStream      streamFlags
 	)
 	cmd := &cobra.Command{
 		Use:   "connect",
@@ -68,7 +85,7 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 				projectPath = cwd
 			}
 
-			connectorType, err := resolveChoice(flagType, "Connector type", []string{"file", "database"}, "file", true)
+			connectorType, err := resolveChoice(flagType, "Connector type", []string{"file", "database", "object", "api", "stream"}, "file", true)
 			if err != nil {
 				return err
 			}
@@ -85,9 +102,6 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 			if !policies.AllowsConnector(connectorType) {
 				return fmt.Errorf("connector type blocked by policy: %s", connectorType)
 			}
-			if policies.OfflineOnly && connectorType == "database" {
-				return fmt.Errorf("database connectors are blocked in offline-only mode")
-			}
 			if connectorType == "file" {
 				defaultName := autoConnectorName(cfg.Connectors, "file-source")
 				name, err := resolveString(flagName, "Connector name", defaultName, true)
@@ -112,8 +126,6 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 				if err != nil {
 					return err
 				}
-				delimiter := ""
-				encoding := ""
 				sheet := ""
 				jsonLines := false
 				zipMember := ""
@@ -163,51 +175,88 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 						}
 					}
 				}
-				for _, path := range paths {
-					if _, err := os.Stat(path); err != nil {
-						return fmt.Errorf("path not accessible: %s. If using WSL, use /mnt/<drive>/... paths", path)
+				files, err := expandPaths(paths)
+				if err != nil {
+					return err
+				}
+				if len(files) != len(paths) {
+					fmt.Printf("[INFO] Paths match %d files; patterns are expanded again at ingest.\n", len(files))
+				}
+
+				var aliases map[string]string
+				if len(files) > 1 || flagAliases != "" {
+					aliasList, err := resolveString(flagAliases, "Column aliases for differing files (alias=canonical, comma-separated, optional)", "", false)
+					if err != nil {
+						return err
+					}
+					if aliases, err = parseAliases(aliasList); err != nil {
+						return err
 					}
 				}
 
+				dialect := csvdialect.Dialect{}
+				if format == "csv" && len(files) > 0 {
+					if sniffed, err := csvdialect.SniffFile(files[0]); err != nil {
+						fmt.Printf("[WARN] Dialect detection failed: %v\n", err)
+					} else {
+						dialect = sniffed
+						fmt.Printf("[INFO] Detected dialect: %s\n", dialect)
+					}
+				}
 				if format == "csv" || format == "zip-csv" {
-					defaultDelimiter := ","
-					if len(paths) > 0 && format == "csv" {
-						defaultDelimiter = detectDelimiter(paths[0])
+					dialect = dialect.Normalize()
+					dialect.Delimiter, err = resolveString(flagDelimiter, "CSV delimiter", dialect.Delimiter, true)
+					if err != nil {
+						return err
 					}
-					delimiter, err = resolveString(flagDelimiter, "CSV delimiter", defaultDelimiter, true)
+					dialect.Encoding, err = resolveString(flagEncoding, "CSV encoding", dialect.Encoding, true)
 					if err != nil {
 						return err
 					}
-					encoding, err = resolveString(flagEncoding, "CSV encoding", "utf-8", true)
+					header, err := resolveBool(flagHeader, "First row is a header?", !dialect.NoHeader)
 					if err != nil {
 						return err
 					}
+					dialect.NoHeader = !header
+					if flagQuote != "" {
+						dialect.Quote = flagQuote
+					}
+					if flagEscape != "" {
+						dialect.Escape, err = parseEscape(flagEscape)
+						if err != nil {
+							return err
+						}
+					}
+					if flagDecimal != "" {
+						dialect.Decimal = flagDecimal
+					}
+					dialect = dialect.Normalize()
+					if err := dialect.Validate(); err != nil {
+						return err
+					}
 				}
-				previewNow, err := resolveBool(flagPreview, "Preview CSV headers and sample rows?", true)
+				previewNow, err := resolveBool(flagPreview, "Preview headers and sample rows?", true)
 				if err != nil {
 					return err
 				}
-				if previewNow && (format == "csv" || format == "zip-csv") {
-					if strings.ToLower(encoding) != "utf-8" && encoding != "" {
-						fmt.Printf("[WARN] Preview skipped for encoding %s (only utf-8 supported).\n", encoding)
-					} else if len(paths) > 0 {
-						countRows, err := resolveBool(flagCountRows, "Count total rows? (may be slow)", false)
-						if err != nil {
-							return err
-						}
-						samplePath := paths[0]
-						if format == "zip-csv" {
-							fmt.Println("[WARN] CSV preview is skipped for zip archives.")
-							countRows = false
-						}
-						if samplePath != "" && format != "zip-csv" {
-							sample, err := preview.PreviewCSV(samplePath, delimiter, 5, countRows)
-							if err != nil {
-								fmt.Printf("[WARN] Preview failed: %v\n", err)
-							} else {
-								fmt.Println(preview.FormatSample(sample))
-							}
-						}
+				if previewNow && len(files) > 0 {
+					countRows, err := resolveBool(flagCountRows, "Count total rows? (may be slow)", false)
+					if err != nil {
+						return err
+					}
+					sample, err := preview.Preview(files[0], preview.Options{
+						Format:     format,
+						Dialect:    dialect,
+						Sheet:      sheet,
+						JSONLines:  jsonLines,
+						ZipMember:  zipMember,
+						SampleRows: 5,
+						CountAll:   countRows,
+					})
+					if err != nil {
+						fmt.Printf("[WARN] Preview failed: %v\n", err)
+					} else {
+						fmt.Println(preview.FormatSample(sample))
 					}
 				}
 
@@ -217,18 +266,26 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 					File: &config.FileConfig{
 						Paths:     paths,
 						Format:    format,
-						Delimiter: delimiter,
-						Encoding:  encoding,
+						Delimiter: dialect.Delimiter,
+						Quote:     dialect.Quote,
+						Escape:    dialect.Escape,
+						Encoding:  dialect.Encoding,
+						NoHeader:  dialect.NoHeader,
+						Decimal:   dialect.Decimal,
 						Sheet:     sheet,
 						JSONLines: jsonLines,
 						ZipMember: zipMember,
+						Aliases:   aliases,
 					},
 					Options: &config.ExtraConfig{ReadOnly: true},
 				})
 				summary := []string{
 					fmt.Sprintf("Connector: %s (file)", name),
 					fmt.Sprintf("Format: %s", format),
-					fmt.Sprintf("Paths: %d", len(paths)),
+					fmt.Sprintf("Paths: %d (%d files)", len(paths), len(files)),
+				}
+				if format == "csv" || format == "zip-csv" {
+					summary = append(summary, fmt.Sprintf("Dialect: %s", dialect))
 				}
 				confirm, err := confirmSummary("Confirm connector details", summary)
 				if err != nil {
@@ -248,6 +305,223 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 				return nil
 			}
 
+			if connectorType == "object" {
+				if policies.OfflineOnly {
+					return fmt.Errorf("object storage connectors are blocked in offline-only mode")
+				}
+				defaultName := autoConnectorName(cfg.Connectors, "object-source")
+				name, err := resolveString(flagName, "Connector name", defaultName, true)
+				if err != nil {
+					return err
+				}
+				if name == "?" {
+					return fmt.Errorf("connector name cannot be '?'")
+				}
+				locationFlags := flagObject
+				locationFlags.Format, locationFlags.Delimiter, locationFlags.Encoding, locationFlags.CredEnv = flagFormat, flagDelimiter, flagEncoding, flagCredEnv
+				objectConfig, credEnv, err := resolveObjectConfig(locationFlags)
+				if err != nil {
+					return err
+				}
+				if !policies.AllowsConnector(objectConfig.Provider) {
+					return fmt.Errorf("connector provider blocked by policy: %s", objectConfig.Provider)
+				}
+				spec := config.ConnectorSpec{
+					Name:    name,
+					Type:    "object",
+					Object:  &objectConfig,
+					Options: &config.ExtraConfig{ReadOnly: true, CredentialEnv: credEnv},
+				}
+				testNow, err := resolveBool(flagTest, "List matching objects now?", true)
+				if err != nil {
+					return err
+				}
+				if testNow {
+					store, err := connectorStore(spec)
+					if err != nil {
+						return err
+					}
+					matched, err := listConnectorObjects(store, objectConfig)
+					if err != nil {
+						return fmt.Errorf("list %s failed: %w", store.URI(objectConfig.Prefix), err)
+					}
+					for i, item := range matched {
+						if i >= 10 {
+							fmt.Println("[INFO] ...")
+							break
+						}
+						fmt.Printf("  - %s (%d bytes)\n", item.Key, item.Size)
+					}
+					if len(matched) == 0 {
+						fmt.Printf("[WARN] No objects under %s match the glob yet.\n", store.URI(objectConfig.Prefix))
+					} else {
+						fmt.Printf("[SUCCESS] %d objects match under %s.\n", len(matched), store.URI(objectConfig.Prefix))
+					}
+				}
+
+				cfg.Connectors = append(cfg.Connectors, spec)
+				summary := []string{
+					fmt.Sprintf("Connector: %s (object)", name),
+					fmt.Sprintf("Location: %s %s/%s%s", objectConfig.Provider, objectConfig.Bucket, objectConfig.Prefix, objectConfig.Glob),
+					fmt.Sprintf("Format: %s", objectConfig.Format),
+				}
+				confirm, err := confirmSummary("Confirm connector details", summary)
+				if err != nil {
+					return err
+				}
+				if !confirm {
+					fmt.Println("[INFO] Connector creation canceled.")
+					return nil
+				}
+				if err := cfg.Save(); err != nil {
+					return err
+				}
+				fmt.Println("[SUCCESS] Object storage connector saved.")
+				updated, _ := config.Load(cfg.Path)
+				ui.PrintSplash(updated, ui.SplashOptions{CompletedCommand: "connect", WorkingDir: projectPath})
+				success = true
+				return nil
+			}
+
+			if connectorType == "api" {
+				if policies.OfflineOnly {
+					return fmt.Errorf("api connectors are blocked in offline-only mode")
+				}
+				defaultName := autoConnectorName(cfg.Connectors, "api-source")
+				name, err := resolveString(flagName, "Connector name", defaultName, true)
+				if err != nil {
+					return err
+				}
+				if name == "?" {
+					return fmt.Errorf("connector name cannot be '?'")
+				}
+				requestFlags := flagAPI
+				requestFlags.CredEnv = flagCredEnv
+				apiConfig, credEnv, err := resolveAPIConfig(requestFlags)
+				if err != nil {
+					return err
+				}
+				spec := config.ConnectorSpec{
+					Name:    name,
+					Type:    "api",
+					API:     &apiConfig,
+					Options: &config.ExtraConfig{ReadOnly: true, CredentialEnv: credEnv},
+				}
+				testNow, err := resolveBool(flagTest, "Fetch the first page now?", true)
+				if err != nil {
+					return err
+				}
+				if testNow {
+					client, err := connectorAPI(spec)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := context.WithTimeout(context.Background(), api.RequestTimeout)
+					records, columns, err := client.Probe(ctx)
+					cancel()
+					if err != nil {
+						return fmt.Errorf("api test failed: %w", err)
+					}
+					fmt.Printf("[SUCCESS] First page returned %d records.\n", records)
+					if len(columns) > 0 {
+						fmt.Printf("[INFO] Columns: %s\n", strings.Join(columns, ", "))
+					}
+				}
+
+				cfg.Connectors = append(cfg.Connectors, spec)
+				summary := []string{
+					fmt.Sprintf("Connector: %s (api)", name),
+					fmt.Sprintf("URL: %s", apiConfig.URL),
+					fmt.Sprintf("Pagination/Auth: %s/%s", apiConfig.Pagination.Type, apiConfig.Auth.Type),
+				}
+				confirm, err := confirmSummary("Confirm connector details", summary)
+				if err != nil {
+					return err
+				}
+				if !confirm {
+					fmt.Println("[INFO] Connector creation canceled.")
+					return nil
+				}
+				if err := cfg.Save(); err != nil {
+					return err
+				}
+				fmt.Println("[SUCCESS] API connector saved.")
+				updated, _ := config.Load(cfg.Path)
+				ui.PrintSplash(updated, ui.SplashOptions{CompletedCommand: "connect", WorkingDir: projectPath})
+				success = true
+				return nil
+			}
+
+			if connectorType == "stream" {
+				if policies.OfflineOnly {
+					return fmt.Errorf("stream connectors are blocked in offline-only mode")
+				}
+				defaultName := autoConnectorName(cfg.Connectors, "stream-source")
+				name, err := resolveString(flagName, "Connector name", defaultName, true)
+				if err != nil {
+					return err
+				}
+				if name == "?" {
+					return fmt.Errorf("connector name cannot be '?'")
+				}
+				streamRequest := flagStream
+				streamRequest.Fields = flagAPI.Fields
+				streamRequest.User = flagUser
+				streamRequest.CredEnv = flagCredEnv
+				streamConfig, credEnv, err := resolveStreamConfig(streamRequest)
+				if err != nil {
+					return err
+				}
+				spec := config.ConnectorSpec{
+					Name:    name,
+					Type:    "stream",
+					Stream:  &streamConfig,
+					Options: &config.ExtraConfig{ReadOnly: true, CredentialEnv: credEnv},
+				}
+				testNow, err := resolveBool(flagTest, "Read partition offsets now?", true)
+				if err != nil {
+					return err
+				}
+				if testNow {
+					source, err := connectorStream(spec)
+					if err != nil {
+						return err
+					}
+					offsets, err := describeStream(source, streamConfig.Partitions)
+					source.Close()
+					if err != nil {
+						return fmt.Errorf("stream test failed: %w", err)
+					}
+					fmt.Printf("[SUCCESS] Topic %s has %d partitions available.\n", streamConfig.Topic, len(offsets))
+					for _, partition := range offsets {
+						fmt.Printf("  - partition %d: offsets %d to %d\n", partition.Partition, partition.Start, partition.End)
+					}
+				}
+
+				cfg.Connectors = append(cfg.Connectors, spec)
+				summary := []string{
+					fmt.Sprintf("Connector: %s (stream)", name),
+					fmt.Sprintf("Topic: %s", streamLocation(streamConfig)),
+					fmt.Sprintf("Format: %s", streamConfig.Format),
+				}
+				confirm, err := confirmSummary("Confirm connector details", summary)
+				if err != nil {
+					return err
+				}
+				if !confirm {
+					fmt.Println("[INFO] Connector creation canceled.")
+					return nil
+				}
+				if err := cfg.Save(); err != nil {
+					return err
+				}
+				fmt.Println("[SUCCESS] Stream connector saved.")
+				updated, _ := config.Load(cfg.Path)
+				ui.PrintSplash(updated, ui.SplashOptions{CompletedCommand: "connect", WorkingDir: projectPath})
+				success = true
+				return nil
+			}
+
 			defaultName := autoConnectorName(cfg.Connectors, "db-source")
 			name, err := resolveString(flagName, "Connector name", defaultName, true)
 			if err != nil {
@@ -256,177 +530,167 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 			if name == "?" {
 				return fmt.Errorf("connector name cannot be '?'")
 			}
-			driver, err := resolveChoice(flagDriver, "Database driver", []string{"postgres", "mysql", "mssql", "snowflake", "bigquery", "other"}, "postgres", true)
+			defaultDriver := "postgres"
+			if policies.OfflineOnly {
+				defaultDriver = "sqlite"
+			}
+			driver, err := resolveChoice(flagDriver, "Database driver", append(db.Drivers(), "other"), defaultDriver, true)
 			if err != nil {
 				return err
 			}
 			if !policies.AllowsConnector(driver) {
 				return fmt.Errorf("connector driver blocked by policy: %s", driver)
 			}
-			hostPrompt := "Host"
-			if driver == "bigquery" {
-				hostPrompt = "Project ID"
+			// Drivers outside the registry ("other") get the generic
+			// prompts and are saved without a connection test.
+			info := db.Info{Name: driver, Label: driver, DefaultPort: 5432}
+			if impl, err := db.Lookup(driver); err == nil {
+				info = impl.Info()
 			}
-			host, err := resolveString(flagHost, hostPrompt, "", true)
-			if err != nil {
-				return err
+			if info.Unavailable != "" {
+				return fmt.Errorf("%s connectors are not available: %s", info.Label, info.Unavailable)
 			}
-			port := 0
-			if driver != "bigquery" {
-				portText, err := resolveString(flagPort, "Port", "5432", true)
+			local := info.Local
+			if policies.OfflineOnly && !local {
+				return fmt.Errorf("database connectors are blocked in offline-only mode (file-backed drivers are allowed: %s)", strings.Join(db.LocalDrivers(), ", "))
+			}
+			dbConfig := config.DBConfig{Driver: driver}
+			credEnv := ""
+			if local {
+				dbFile, err := resolveString(flagDBFile, "Database file path", "", true)
 				if err != nil {
 					return err
 				}
-				value, err := strconv.Atoi(portText)
+				dbConfig.Path = dbFile
+				schema, err := resolveString(flagSchema, "Schema (optional)", "", false)
 				if err != nil {
-					return fmt.Errorf("invalid port: %s", portText)
+					return err
+				}
+				dbConfig.Schema = schema
+				fmt.Println("[INFO] File-backed databases are opened read-only and need no credentials.")
+			} else {
+				prompts := info.ConnectPrompts()
+				host, err := resolveString(flagHost, prompts.Host, "", true)
+				if err != nil {
+					return err
+				}
+				port := 0
+				if info.DefaultPort != 0 {
+					portText, err := resolveString(flagPort, "Port", strconv.Itoa(info.DefaultPort), true)
+					if err != nil {
+						return err
+					}
+					value, err := strconv.Atoi(portText)
+					if err != nil {
+						return fmt.Errorf("invalid port: %s", portText)
+					}
+					port = value
+				}
+				dbName, err := resolveString(flagDBName, prompts.DBName, "", !prompts.DBNameOptional)
+				if err != nil {
+					return err
+				}
+				schema, err := resolveString(flagSchema, "Schema (optional)", "", false)
+				if err != nil {
+					return err
+				}
+				user, err := resolveString(flagUser, prompts.User, "", false)
+				if err != nil {
+					return err
+				}
+				sslMode, err := resolveString(flagSSLMode, "SSL mode (optional)", "", false)
+				if err != nil {
+					return err
+				}
+				credEnvPrompt := "Credential env var name (e.g., DB_PASSWORD)"
+				if info.SecretOptional {
+					credEnvPrompt = fmt.Sprintf("Credential env var name (optional for %s)", info.Label)
+				}
+				credEnv, err = resolveString(flagCredEnv, credEnvPrompt, "", !info.SecretOptional)
+				if err != nil {
+					return err
 				}
-				port = value
-			}
-			dbNamePrompt := "Database name"
-			if driver == "bigquery" {
-				dbNamePrompt = "Dataset (optional)"
-			}
-			dbName, err := resolveString(flagDBName, dbNamePrompt, "", driver != "bigquery")
-			if err != nil {
-				return err
-			}
-			schema, err := resolveString(flagSchema, "Schema (optional)", "", false)
-			if err != nil {
-				return err
-			}
-			userPrompt := "Username (optional)"
-			if driver == "bigquery" {
-				userPrompt = "Service account credentials JSON path (optional)"
-			}
-			user, err := resolveString(flagUser, userPrompt, "", false)
-			if err != nil {
-				return err
-			}
-			sslMode, err := resolveString(flagSSLMode, "SSL mode (optional)", "", false)
-			if err != nil {
-				return err
-			}
-			credEnvPrompt := "Credential env var name (e.g., DB_PASSWORD)"
-			credEnvRequired := true
-			if driver == "bigquery" {
-				credEnvPrompt = "Credential env var name (optional for BigQuery)"
-				credEnvRequired = false
-			}
-			credEnv, err := resolveString(flagCredEnv, credEnvPrompt, "", credEnvRequired)
-			if err != nil {
-				return err
-			}
 
-			if credEnv != "" {
-				fmt.Println("[INFO] Credentials are never stored in config. Set the env var before connecting.")
-				fmt.Printf("[INFO] Using credential env var: %s\n", credEnv)
-			} else if driver != "bigquery" {
-				return fmt.Errorf("credential env var is required")
+				if credEnv != "" {
+					fmt.Println("[INFO] Credentials are never stored in config. Set the env var before connecting.")
+					fmt.Printf("[INFO] Using credential env var: %s\n", credEnv)
+				} else if !info.SecretOptional {
+					return fmt.Errorf("credential env var is required")
+				}
+				dbConfig.Host = host
+				dbConfig.Port = port
+				dbConfig.DBName = dbName
+				dbConfig.Schema = schema
+				dbConfig.User = user
+				dbConfig.SSLMode = sslMode
 			}
 
+			spec := config.ConnectorSpec{
+				Name:     name,
+				Type:     "database",
+				Database: &dbConfig,
+				Options:  &config.ExtraConfig{ReadOnly: true, CredentialEnv: credEnv},
+			}
 			testNow, err := resolveBool(flagTest, "Test read-only connection now?", true)
 			if err != nil {
 				return err
 			}
 			if testNow {
-				password := ""
-				if credEnv != "" {
-					password = os.Getenv(credEnv)
-					if password == "" && driver != "bigquery" {
-						return fmt.Errorf("credential env var %s is not set", credEnv)
-					}
-				}
-				listCatalog, err := resolveBool(flagListCatalog, "List schemas and tables after validation?", false)
-				if err != nil {
-					return err
-				}
-				switch driver {
-				case "postgres":
-					dsn := fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s", host, port, dbName, user, password, sslMode)
-					fmt.Println("[INFO] Testing Postgres read-only connection...")
-					if err := db.TestPostgresReadOnly(dsn); err != nil {
-						return fmt.Errorf("read-only test failed: %w", err)
-					}
-					fmt.Println("[SUCCESS] Read-only connection validated.")
-					if listCatalog {
-						if err := printCatalog(func() ([]string, error) { return db.ListSchemasPostgres(dsn) }, func(schema string) ([]string, error) { return db.ListTablesPostgres(dsn, schema) }); err != nil {
-							return err
-						}
-					}
-				case "mysql":
-					dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", user, password, host, port, dbName)
-					fmt.Println("[INFO] Testing MySQL read-only connection...")
-					if err := db.TestMySQLReadOnly(dsn); err != nil {
-						return fmt.Errorf("read-only test failed: %w", err)
-					}
-					fmt.Println("[SUCCESS] Read-only connection validated.")
-					if listCatalog {
-						if err := printCatalog(func() ([]string, error) { return db.ListSchemasMySQL(dsn) }, func(schema string) ([]string, error) { return db.ListTablesMySQL(dsn, schema) }); err != nil {
-							return err
-						}
-					}
-				case "mssql":
-					dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", user, password, host, port, dbName)
-					fmt.Println("[INFO] Testing SQL Server connection...")
-					if err := db.TestMSSQLReadOnly(dsn); err != nil {
-						return fmt.Errorf("read-only test failed: %w", err)
-					}
-					fmt.Println("[SUCCESS] Connection validated (read-only enforcement not guaranteed).")
-					if listCatalog {
-						if err := printCatalog(func() ([]string, error) { return db.ListSchemasMSSQL(dsn) }, func(schema string) ([]string, error) { return db.ListTablesMSSQL(dsn, schema) }); err != nil {
-							return err
-						}
+				if _, err := db.Lookup(driver); err != nil {
+					fmt.Printf("[WARN] %s validation is not supported yet; skipping connection test.\n", driver)
+				} else {
+					impl, dsn, err := connectorDriver(spec)
+					if err != nil {
+						return err
 					}
-				case "snowflake":
-					dsn, err := db.SnowflakeDSN(host, user, password, dbName, schema)
+					listCatalog, err := resolveBool(flagListCatalog, "List schemas and tables after validation?", false)
 					if err != nil {
 						return err
 					}
-					fmt.Println("[INFO] Testing Snowflake connection...")
-					if err := db.TestSnowflakeReadOnly(dsn); err != nil {
+					fmt.Printf("[INFO] Testing %s read-only connection...\n", info.Label)
+					ctx, cancel := checkContext()
+					err = impl.TestReadOnly(ctx, dsn)
+					cancel()
+					if err != nil {
 						return fmt.Errorf("read-only test failed: %w", err)
 					}
-					fmt.Println("[SUCCESS] Snowflake connection validated.")
+					if info.ReadOnlyTx {
+						fmt.Println("[SUCCESS] Read-only connection validated.")
+					} else {
+						fmt.Printf("[SUCCESS] %s connection validated (read-only enforced by query guard and account permissions).\n", info.Label)
+					}
 					if listCatalog {
-						if err := printCatalog(func() ([]string, error) { return db.ListSchemasSnowflake(dsn) }, func(schema string) ([]string, error) { return db.ListTablesSnowflake(dsn, schema) }); err != nil {
+						tmpl, err := printCatalog(impl, dsn, catalogOptions{Schema: flagCatSchema, Table: flagCatTable, BuildQuery: flagBuildQuery})
+						if err != nil {
 							return err
 						}
+						if tmpl != nil {
+							spec.Queries = append(spec.Queries, *tmpl)
+							fmt.Printf("[INFO] Query template %s will be saved with the connector.\n", tmpl.Name)
+						}
 					}
-				case "bigquery":
-					fmt.Println("[INFO] Testing BigQuery connection...")
-					credPath := user
-					if credPath == "" {
-						credPath = password
-					}
-					if err := db.TestBigQueryReadOnly(host, credPath); err != nil {
-						return fmt.Errorf("read-only test failed: %w", err)
+				}
+			}
+
+			if impl, err := db.Lookup(driver); err == nil {
+				tmpl, err := resolveProcessTemplate(impl, flagProcess, dbConfig.Schema)
+				if err != nil {
+					return err
+				}
+				if tmpl != nil {
+					if _, exists := spec.Query(tmpl.Name); exists {
+						return fmt.Errorf("query template already exists for connector %s: %s", name, tmpl.Name)
 					}
-					fmt.Println("[SUCCESS] BigQuery connection validated.")
-					if listCatalog {
-						if err := printCatalog(func() ([]string, error) { return db.ListSchemasBigQuery(host, credPath) }, func(schema string) ([]string, error) { return db.ListTablesBigQuery(host, schema, credPath) }); err != nil {
-							return err
-						}
+					spec.Queries = append(spec.Queries, *tmpl)
+					params := make([]string, len(tmpl.Params))
+					for i, param := range tmpl.Params {
+						params[i] = param.Name
 					}
-				default:
-					fmt.Printf("[WARN] %s validation is not supported yet; skipping connection test.\n", driver)
+					fmt.Printf("[INFO] Process template %s will be saved as query %s (parameters: %s).\n", tmpl.Process, tmpl.Name, strings.Join(params, ", "))
 				}
 			}
 
-			cfg.Connectors = append(cfg.Connectors, config.ConnectorSpec{
-				Name: name,
-				Type: "database",
-				Database: &config.DBConfig{
-					Driver:  driver,
-					Host:    host,
-					Port:    port,
-					DBName:  dbName,
-					Schema:  schema,
-					User:    user,
-					SSLMode: sslMode,
-				},
-				Options: &config.ExtraConfig{ReadOnly: true, CredentialEnv: credEnv},
-			})
+			cfg.Connectors = append(cfg.Connectors, spec)
 			summary := []string{
 				fmt.Sprintf("Connector: %s (%s)", name, connectorType),
 				fmt.Sprintf("Format/Driver: %s", driver),
@@ -450,27 +714,67 @@ func NewConnectCmd(global *app.GlobalFlags) *cobra.Command {
 		},
 		Example: "  pm-assist connect",
 	}
-	cmd.Flags().StringVar(&flagType, "type", "", "Connector type (file|database)")
+	cmd.Flags().StringVar(&flagType, "type", "", "Connector type (file|database|object|api|stream)")
 	cmd.Flags().StringVar(&flagName, "name", "", "Connector name")
 	cmd.Flags().StringVar(&flagPaths, "paths", "", "File paths (comma-separated)")
 	cmd.Flags().StringVar(&flagFormat, "format", "", "File format (csv|parquet|xlsx|json|zip-csv|xes)")
 	cmd.Flags().StringVar(&flagDelimiter, "delimiter", "", "CSV delimiter")
-	cmd.Flags().StringVar(&flagEncoding, "encoding", "", "CSV encoding")
+	cmd.Flags().StringVar(&flagEncoding, "encoding", "", "CSV encoding (e.g., utf-8, windows-1252, utf-16)")
+	cmd.Flags().StringVar(&flagQuote, "quote", "", "CSV quote character")
+	cmd.Flags().StringVar(&flagEscape, "escape", "", "CSV quote escaping (doubled|backslash)")
+	cmd.Flags().StringVar(&flagHeader, "header", "", "CSV first row is a header (true|false)")
+	cmd.Flags().StringVar(&flagDecimal, "decimal", "", "Decimal separator of numeric fields (. or ,)")
 	cmd.Flags().StringVar(&flagSheet, "sheet", "", "Excel sheet name")
 	cmd.Flags().StringVar(&flagJSONLines, "json-lines", "", "JSON lines format (true|false)")
 	cmd.Flags().StringVar(&flagZipMember, "zip-member", "", "Zip member name")
-	cmd.Flags().StringVar(&flagPreview, "preview", "", "Preview CSV headers and sample rows (true|false)")
+	cmd.Flags().StringVar(&flagAliases, "aliases", "", "Column aliases for combining files (alias=canonical,...)")
+	cmd.Flags().StringVar(&flagPreview, "preview", "", "Preview headers and sample rows (true|false)")
 	cmd.Flags().StringVar(&flagCountRows, "count-rows", "", "Count total rows when previewing (true|false)")
-	cmd.Flags().StringVar(&flagDriver, "driver", "", "Database driver (postgres|mysql|mssql|snowflake|bigquery|other)")
+	cmd.Flags().StringVar(&flagDriver, "driver", "", "Database driver ("+strings.Join(append(db.Drivers(), "other"), "|")+")")
 	cmd.Flags().StringVar(&flagHost, "host", "", "Database host")
 	cmd.Flags().StringVar(&flagPort, "port", "", "Database port")
-	cmd.Flags().StringVar(&flagDBName, "database", "", "Database name")
+	cmd.Flags().StringVar(&flagDBFile, "db-file", "", "Database file path for file-backed drivers ("+strings.Join(db.LocalDrivers(), "|")+")")
+	cmd.Flags().StringVar(&flagDBName, "database", "", "Database name (Oracle service name, HANA tenant database)")
 	cmd.Flags().StringVar(&flagSchema, "schema", "", "Database schema")
-	cmd.Flags().StringVar(&flagUser, "user", "", "Database user")
+	cmd.Flags().StringVar(&flagUser, "user", "", "Database user, or SASL user for stream connectors")
 	cmd.Flags().StringVar(&flagSSLMode, "ssl-mode", "", "Database SSL mode")
 	cmd.Flags().StringVar(&flagCredEnv, "credential-env", "", "Credential env var name")
-	cmd.Flags().StringVar(&flagTest, "test", "", "Test read-only connection (true|false)")
+	cmd.Flags().StringVar(&flagTest, "test", "", "Test read-only connection, or list matching objects (true|false)")
 	cmd.Flags().StringVar(&flagListCatalog, "list-catalog", "", "List schemas and tables after validation (true|false)")
+	cmd.Flags().StringVar(&flagCatSchema, "catalog-schema", "", "Schema to list tables from when listing the catalog")
+	cmd.Flags().StringVar(&flagCatTable, "catalog-table", "", "Table to describe (columns, row estimate, samples) when listing the catalog")
+	cmd.Flags().StringVar(&flagBuildQuery, "build-query", "", "Build and save an extraction query from the described table (true|false)")
+	cmd.Flags().StringVar(&flagObject.Provider, "provider", "", "Object storage provider (s3|azure)")
+	cmd.Flags().StringVar(&flagObject.Bucket, "bucket", "", "S3 bucket or Azure container")
+	cmd.Flags().StringVar(&flagObject.Prefix, "prefix", "", "Object key prefix")
+	cmd.Flags().StringVar(&flagObject.Glob, "glob", "", "Glob filtering object keys below the prefix (e.g., *.csv)")
+	cmd.Flags().StringVar(&flagObject.Endpoint, "endpoint", "", "Object storage endpoint URL (MinIO, Azurite, or another S3-compatible service)")
+	cmd.Flags().StringVar(&flagObject.Region, "region", "", "S3 region")
+	cmd.Flags().StringVar(&flagObject.Account, "account", "", "S3 access key ID or Azure storage account name")
+	cmd.Flags().StringVar(&flagAPI.URL, "url", "", "API URL of the first page")
+	cmd.Flags().StringVar(&flagAPI.Records, "records", "", "JSONPath of the record array in each API page (e.g., $.value)")
+	cmd.Flags().StringVar(&flagAPI.Fields, "fields", "", "API or stream fields as column=JSONPath (comma-separated)")
+	cmd.Flags().StringVar(&flagAPI.Pagination, "pagination", "", "API pagination (none|next_link|offset|cursor)")
+	cmd.Flags().StringVar(&flagAPI.NextLink, "next-link", "", "JSONPath of the next page link (default $['@odata.nextLink'])")
+	cmd.Flags().StringVar(&flagAPI.PageSize, "page-size", "", "Records per API page for offset and cursor pagination")
+	cmd.Flags().StringVar(&flagAPI.OffsetParam, "offset-param", "", "Offset query parameter (e.g., $skip)")
+	cmd.Flags().StringVar(&flagAPI.LimitParam, "limit-param", "", "Page size query parameter (e.g., $top)")
+	cmd.Flags().StringVar(&flagAPI.CursorParam, "cursor-param", "", "Cursor query parameter")
+	cmd.Flags().StringVar(&flagAPI.CursorPath, "cursor-path", "", "JSONPath of the next cursor in the response")
+	cmd.Flags().StringVar(&flagAPI.MaxPages, "max-pages", "", "Stop the API extract after this many pages")
+	cmd.Flags().StringVar(&flagAPI.Auth, "auth", "", "API auth (none|bearer|oauth2)")
+	cmd.Flags().StringVar(&flagAPI.TokenURL, "token-url", "", "OAuth2 token URL")
+	cmd.Flags().StringVar(&flagAPI.ClientID, "client-id", "", "OAuth2 client ID")
+	cmd.Flags().StringVar(&flagAPI.Scope, "scope", "", "OAuth2 scope")
+	cmd.Flags().StringVar(&flagStream.Brokers, "brokers", "", "Kafka brokers as host:port (comma-separated)")
+	cmd.Flags().StringVar(&flagStream.Topic, "topic", "", "Kafka topic")
+	cmd.Flags().StringVar(&flagStream.Partitions, "partitions", "", "Topic partitions to read (comma-separated; default all)")
+	cmd.Flags().StringVar(&flagStream.Format, "value-format", "", "Stream value format (json|avro)")
+	cmd.Flags().StringVar(&flagStream.SchemaRegistry, "schema-registry", "", "Schema registry URL for Avro values")
+	cmd.Flags().StringVar(&flagStream.SASL, "sasl", "", "SASL mechanism (none|plain|scram-sha-256|scram-sha-512)")
+	cmd.Flags().StringVar(&flagStream.TLS, "tls", "", "Connect to the brokers over TLS (true|false)")
+	addStreamRangeFlags(cmd, &flagStream.Range)
+	cmd.Flags().StringVar(&flagProcess, "process-template", "", "Built-in process extraction template to save with the connector (none|"+strings.Join(sap.Names(), "|")+")")
 	return cmd
 }
 
@@ -486,14 +790,25 @@ func splitCSV(input string) []string {
 	return out
 }
 
-func printCatalog(listSchemas func() ([]string, error), listTables func(schema string) ([]string, error)) error {
-	schemas, err := listSchemas()
+// catalogOptions carries the catalog drill-down flags.
+type catalogOptions struct {
+	Schema     string
+	Table      string
+	BuildQuery string
+}
+
+// printCatalog lists schemas and tables, optionally describes one table, and
+// returns a query template when the user builds an extraction query from it.
+func printCatalog(driver db.Driver, dsn string, opts catalogOptions) (*config.QueryTemplate, error) {
+	ctx, cancel := checkContext()
+	schemas, err := driver.ListSchemas(ctx, dsn)
+	cancel()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(schemas) == 0 {
 		fmt.Println("[INFO] No schemas found.")
-		return nil
+		return nil, nil
 	}
 	fmt.Println("[INFO] Schemas:")
 	for i, schema := range schemas {
@@ -503,13 +818,15 @@ func printCatalog(listSchemas func() ([]string, error), listTables func(schema s
 		}
 		fmt.Printf("  - %s\n", schema)
 	}
-	schema, err := prompt.AskString("Schema to list tables", schemas[0], true)
+	schema, err := resolveString(opts.Schema, "Schema to list tables", schemas[0], true)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tables, err := listTables(schema)
+	ctx, cancel = checkContext()
+	tables, err := driver.ListTables(ctx, dsn, schema)
+	cancel()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	fmt.Printf("[INFO] Tables in %s:\n", schema)
 	for i, table := range tables {
@@ -519,7 +836,125 @@ func printCatalog(listSchemas func() ([]string, error), listTables func(schema s
 		}
 		fmt.Printf("  - %s\n", table)
 	}
-	return nil
+	table, err := resolveString(opts.Table, "Table to inspect (optional)", "", false)
+	if err != nil || table == "" {
+		return nil, err
+	}
+
+	ctx, cancel = checkContext()
+	info, err := driver.DescribeTable(ctx, dsn, schema, table, db.DefaultSampleRows)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	printTableInfo(info)
+	suggestion := db.SuggestMapping(info.Columns)
+	printSuggestion(suggestion)
+
+	build, err := resolveBool(opts.BuildQuery, "Build an extraction query from this table?", false)
+	if err != nil || !build {
+		return nil, err
+	}
+	return buildCatalogQuery(driver, info, suggestion)
+}
+
+func printTableInfo(info db.TableInfo) {
+	if info.RowEstimate >= 0 {
+		fmt.Printf("[INFO] %s.%s: ~%d rows (catalog estimate)\n", info.Schema, info.Table, info.RowEstimate)
+	} else {
+		fmt.Printf("[INFO] %s.%s: row estimate unavailable\n", info.Schema, info.Table)
+	}
+	fmt.Println("[INFO] Columns:")
+	for _, column := range info.Columns {
+		nullable := "not null"
+		if column.Nullable {
+			nullable = "nullable"
+		}
+		line := fmt.Sprintf("  - %s (%s, %s)", column.Name, column.Type, nullable)
+		if len(column.Samples) > 0 {
+			line += " e.g. " + strings.Join(column.Samples, " | ")
+		}
+		fmt.Println(line)
+	}
+}
+
+func printSuggestion(suggestion db.MappingSuggestion) {
+	fmt.Println("[INFO] Mapping suggestions:")
+	roles := []struct {
+		label      string
+		candidates []db.Candidate
+	}{
+		{"case id", suggestion.CaseID},
+		{"activity", suggestion.Activity},
+		{"timestamp", suggestion.Timestamp},
+		{"resource", suggestion.Resource},
+	}
+	for _, role := range roles {
+		if len(role.candidates) == 0 {
+			fmt.Printf("  - %s: no candidate\n", role.label)
+			continue
+		}
+		top := role.candidates[0]
+		line := fmt.Sprintf("  - %s: %s (%s)", role.label, top.Column, top.Reason)
+		if len(role.candidates) > 1 {
+			others := []string{}
+			for i, candidate := range role.candidates[1:] {
+				if i >= 2 {
+					break
+				}
+				others = append(others, candidate.Column)
+			}
+			line += "; also " + strings.Join(others, ", ")
+		}
+		fmt.Println(line)
+	}
+}
+
+// buildCatalogQuery asks for the event log columns, defaulting to the
+// suggestions, and returns a SELECT over them as a named query template.
+func buildCatalogQuery(driver db.Driver, info db.TableInfo, suggestion db.MappingSuggestion) (*config.QueryTemplate, error) {
+	names := make([]string, len(info.Columns))
+	for i, column := range info.Columns {
+		names[i] = column.Name
+	}
+	caseCol, err := prompt.AskChoice("Case ID column", names, db.Best(suggestion.CaseID), true)
+	if err != nil {
+		return nil, err
+	}
+	activityCol, err := prompt.AskChoice("Activity column", names, db.Best(suggestion.Activity), true)
+	if err != nil {
+		return nil, err
+	}
+	timestampCol, err := prompt.AskChoice("Timestamp column", names, db.Best(suggestion.Timestamp), true)
+	if err != nil {
+		return nil, err
+	}
+	resourceDefault := db.Best(suggestion.Resource)
+	if resourceDefault == "" {
+		resourceDefault = "none"
+	}
+	resourceCol, err := prompt.AskChoice("Resource column", append([]string{"none"}, names...), resourceDefault, false)
+	if err != nil {
+		return nil, err
+	}
+	if caseCol == "" || activityCol == "" || timestampCol == "" {
+		return nil, fmt.Errorf("case, activity, and timestamp columns are required to build a query")
+	}
+	columns := []string{caseCol, activityCol, timestampCol}
+	if resourceCol != "" && resourceCol != "none" {
+		columns = append(columns, resourceCol)
+	}
+	query := db.SelectQuery(driver, info.Schema, info.Table, columns)
+	fmt.Printf("[INFO] Query: %s\n", query)
+	name, err := prompt.AskString("Query template name", strings.ToLower(info.Table), true)
+	if err != nil {
+		return nil, err
+	}
+	return &config.QueryTemplate{
+		Name:        name,
+		Description: fmt.Sprintf("Built from catalog %s.%s", info.Schema, info.Table),
+		SQL:         query,
+	}, nil
 }
 
 func autoConnectorName(connectors []config.ConnectorSpec, prefix string) string {
@@ -546,11 +981,6 @@ func resolvePathList(pathList string) ([]string, error) {
 				normalized = raw
 			}
 		}
-		matches, err := filepath.Glob(normalized)
-		if err == nil && len(matches) > 0 {
-			out = append(out, matches...)
-			continue
-		}
 		out = append(out, normalized)
 	}
 	return out, nil
@@ -597,3 +1027,21 @@ func selectPaths(paths []string) ([]string, error) {
 	}
 	return out, nil
 }
+
+// resolveProcessTemplate offers the built-in process templates and returns
+// the chosen one as a query template for the driver, or nil for none. Source
+// tables are read from schema when it is set.
+func resolveProcessTemplate(driver db.Driver, flagProcess string, schema string) (*config.QueryTemplate, error) {
+	choices := append([]string{"none"}, sap.Names()...)
+	choice, err := resolveChoice(flagProcess, "Process template", choices, "none", true)
+	if err != nil || choice == "none" {
+		return nil, err
+	}
+	tmpl, _ := sap.Lookup(choice)
+	fmt.Printf("[INFO] %s: %s\n", tmpl.Label, tmpl.Description)
+	query, err := tmpl.Query(driver, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &query, nil
+}
@@ -2,7 +2,6 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/pm-assist/pm-assist/internal/app"
 	"github.com/pm-assist/pm-assist/internal/config"
@@ -46,7 +45,7 @@ func newConnectorsListCmd(global *app.GlobalFlags) *cobra.Command {
 			for _, connector := range cfg.Connectors {
 				status := "unknown"
 				if connector.Type == "file" && connector.File != nil && len(connector.File.Paths) > 0 {
-					if _, err := os.Stat(connector.File.Paths[0]); err == nil {
+					if _, err := expandPaths(connector.File.Paths); err == nil {
 						status = "ok"
 					} else {
 						status = "missing"
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/db"
+)
+
+// connectorDriver resolves the driver for a database connector and builds its
+// DSN with the secret from the connector's credential env var.
+func connectorDriver(spec config.ConnectorSpec) (db.Driver, string, error) {
+	if spec.Database == nil {
+		return nil, "", fmt.Errorf("database connector %s missing database config", spec.Name)
+	}
+	driver, err := db.Lookup(spec.Database.Driver)
+	if err != nil {
+		return nil, "", err
+	}
+	if reason := driver.Info().Unavailable; reason != "" {
+		return nil, "", fmt.Errorf("%s connectors are not available: %s", driver.Info().Label, reason)
+	}
+	credEnv := ""
+	if spec.Options != nil {
+		credEnv = spec.Options.CredentialEnv
+	}
+	secret := ""
+	if credEnv != "" {
+		secret = os.Getenv(credEnv)
+	}
+	if secret == "" && !driver.Info().SecretOptional {
+		if credEnv == "" {
+			return nil, "", fmt.Errorf("database connector %s missing credential env var", spec.Name)
+		}
+		return nil, "", fmt.Errorf("credential env var %s is not set", credEnv)
+	}
+	dsn, err := driver.BuildDSN(*spec.Database, secret)
+	if err != nil {
+		return nil, "", err
+	}
+	return driver, dsn, nil
+}
+
+// localConnector reports whether a database connector opens a local file and
+// so stays usable under the offline-only policy.
+func localConnector(spec config.ConnectorSpec) bool {
+	if spec.Database == nil {
+		return false
+	}
+	driver, err := db.Lookup(spec.Database.Driver)
+	return err == nil && driver.Info().Local
+}
+
+// checkContext returns a context bounded by the driver check timeout.
+func checkContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), db.CheckTimeout)
+}
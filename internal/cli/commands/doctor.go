@@ -1,12 +1,13 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 
+	"github.com/pm-assist/pm-assist/internal/api"
 	"github.com/pm-assist/pm-assist/internal/app"
 	"github.com/pm-assist/pm-assist/internal/config"
 	"github.com/pm-assist/pm-assist/internal/db"
@@ -65,6 +66,13 @@ func NewDoctorCmd(global *app.GlobalFlags) *cobra.Command {
 				fmt.Printf("[SUCCESS] Graphviz found: %s\n", dotPath)
 			}
 
+			for _, name := range db.Drivers() {
+				impl, _ := db.Lookup(name)
+				if reason := impl.Info().Unavailable; reason != "" {
+					fmt.Printf("[INFO] %s connectors are not available: %s\n", impl.Info().Label, reason)
+				}
+			}
+
 			if len(cfg.Connectors) == 0 {
 				fmt.Println("[INFO] No connectors configured.")
 			}
@@ -77,14 +85,16 @@ func NewDoctorCmd(global *app.GlobalFlags) *cobra.Command {
 						continue
 					}
 					for _, path := range connector.File.Paths {
-						if _, err := os.Stat(path); err != nil {
-							fmt.Printf("[ERROR] File connector %s path not accessible: %s (%v)\n", connector.Name, path, err)
-						} else {
+						if files, err := expandPaths([]string{path}); err != nil {
+							fmt.Printf("[ERROR] File connector %s: %v\n", connector.Name, err)
+						} else if len(files) == 1 && files[0] == path {
 							fmt.Printf("[SUCCESS] File connector %s path reachable: %s\n", connector.Name, path)
+						} else {
+							fmt.Printf("[SUCCESS] File connector %s pattern %s matches %d files\n", connector.Name, path, len(files))
 						}
 					}
 				case "database":
-					if policies.OfflineOnly {
+					if policies.OfflineOnly && !localConnector(connector) {
 						fmt.Printf("[WARN] Offline-only policy enabled; skipping database check for %s.\n", connector.Name)
 						continue
 					}
@@ -92,61 +102,74 @@ func NewDoctorCmd(global *app.GlobalFlags) *cobra.Command {
 						fmt.Printf("[WARN] Database connector %s missing config\n", connector.Name)
 						continue
 					}
-					driver := strings.ToLower(strings.TrimSpace(connector.Database.Driver))
-					password := os.Getenv(connector.Options.CredentialEnv)
-					if connector.Options.CredentialEnv == "" {
-						fmt.Printf("[WARN] Database connector %s missing credential env var\n", connector.Name)
+					if impl, err := db.Lookup(connector.Database.Driver); err == nil && impl.Info().Unavailable != "" {
+						fmt.Printf("[ERROR] %s connector %s cannot run in this binary; see above.\n", impl.Info().Label, connector.Name)
 						continue
 					}
-					if password == "" {
-						fmt.Printf("[WARN] Database connector %s credential env var not set: %s\n", connector.Name, connector.Options.CredentialEnv)
+					impl, dsn, err := connectorDriver(connector)
+					if err != nil {
+						fmt.Printf("[WARN] Database connector %s: %v\n", connector.Name, err)
 						continue
 					}
-					switch driver {
-					case "postgres":
-						dsn := fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s", connector.Database.Host, connector.Database.Port, connector.Database.DBName, connector.Database.User, password, connector.Database.SSLMode)
-						if err := db.TestPostgresReadOnly(dsn); err != nil {
-							fmt.Printf("[ERROR] Postgres connector %s failed: %v\n", connector.Name, err)
-						} else {
-							fmt.Printf("[SUCCESS] Postgres connector %s reachable.\n", connector.Name)
-						}
-					case "mysql":
-						dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", connector.Database.User, password, connector.Database.Host, connector.Database.Port, connector.Database.DBName)
-						if err := db.TestMySQLReadOnly(dsn); err != nil {
-							fmt.Printf("[ERROR] MySQL connector %s failed: %v\n", connector.Name, err)
-						} else {
-							fmt.Printf("[SUCCESS] MySQL connector %s reachable.\n", connector.Name)
-						}
-					case "mssql":
-						dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", connector.Database.User, password, connector.Database.Host, connector.Database.Port, connector.Database.DBName)
-						if err := db.TestMSSQLReadOnly(dsn); err != nil {
-							fmt.Printf("[ERROR] SQL Server connector %s failed: %v\n", connector.Name, err)
-						} else {
-							fmt.Printf("[SUCCESS] SQL Server connector %s reachable.\n", connector.Name)
-						}
-					case "snowflake":
-						dsn, err := db.SnowflakeDSN(connector.Database.Host, connector.Database.User, password, connector.Database.DBName, connector.Database.Schema)
-						if err != nil {
-							fmt.Printf("[ERROR] Snowflake connector %s failed: %v\n", connector.Name, err)
-							continue
-						}
-						if err := db.TestSnowflakeReadOnly(dsn); err != nil {
-							fmt.Printf("[ERROR] Snowflake connector %s failed: %v\n", connector.Name, err)
-						} else {
-							fmt.Printf("[SUCCESS] Snowflake connector %s reachable.\n", connector.Name)
-						}
-					case "bigquery":
-						credPath := connector.Database.User
-						if credPath == "" {
-							credPath = password
-						}
-						if err := db.TestBigQueryReadOnly(connector.Database.Host, credPath); err != nil {
-							fmt.Printf("[ERROR] BigQuery connector %s failed: %v\n", connector.Name, err)
-						} else {
-							fmt.Printf("[SUCCESS] BigQuery connector %s reachable.\n", connector.Name)
-						}
-					default:
-						fmt.Printf("[WARN] Connector %s uses unsupported driver %s.\n", connector.Name, driver)
+					label := impl.Info().Label
+					ctx, cancel := checkContext()
+					err = impl.TestReadOnly(ctx, dsn)
+					cancel()
+					if err != nil {
+						fmt.Printf("[ERROR] %s connector %s failed: %v\n", label, connector.Name, err)
+					} else {
+						fmt.Printf("[SUCCESS] %s connector %s reachable.\n", label, connector.Name)
+					}
+				case "api":
+					if policies.OfflineOnly {
+						fmt.Printf("[WARN] Offline-only policy enabled; skipping API check for %s.\n", connector.Name)
+						continue
+					}
+					client, err := connectorAPI(connector)
+					if err != nil {
+						fmt.Printf("[WARN] API connector %s: %v\n", connector.Name, err)
+						continue
+					}
+					ctx, cancel := context.WithTimeout(context.Background(), api.RequestTimeout)
+					records, _, err := client.Probe(ctx)
+					cancel()
+					if err != nil {
+						fmt.Printf("[ERROR] API connector %s failed: %v\n", connector.Name, err)
+					} else {
+						fmt.Printf("[SUCCESS] API connector %s reachable: first page returned %d records.\n", connector.Name, records)
+					}
+				case "object":
+					if policies.OfflineOnly {
+						fmt.Printf("[WARN] Offline-only policy enabled; skipping object storage check for %s.\n", connector.Name)
+						continue
+					}
+					store, err := connectorStore(connector)
+					if err != nil {
+						fmt.Printf("[WARN] Object connector %s: %v\n", connector.Name, err)
+						continue
+					}
+					matched, err := listConnectorObjects(store, *connector.Object)
+					if err != nil {
+						fmt.Printf("[ERROR] Object connector %s failed: %v\n", connector.Name, err)
+					} else {
+						fmt.Printf("[SUCCESS] Object connector %s reachable: %d objects match under %s.\n", connector.Name, len(matched), store.URI(connector.Object.Prefix))
+					}
+				case "stream":
+					if policies.OfflineOnly {
+						fmt.Printf("[WARN] Offline-only policy enabled; skipping stream check for %s.\n", connector.Name)
+						continue
+					}
+					source, err := connectorStream(connector)
+					if err != nil {
+						fmt.Printf("[WARN] Stream connector %s: %v\n", connector.Name, err)
+						continue
+					}
+					offsets, err := describeStream(source, connector.Stream.Partitions)
+					source.Close()
+					if err != nil {
+						fmt.Printf("[ERROR] Stream connector %s failed: %v\n", connector.Name, err)
+					} else {
+						fmt.Printf("[SUCCESS] Stream connector %s reachable: %d partitions of %s.\n", connector.Name, len(offsets), connector.Stream.Topic)
 					}
 				default:
 					fmt.Printf("[WARN] Unknown connector type %s for %s\n", connector.Type, connector.Name)
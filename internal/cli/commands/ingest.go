@@ -1,19 +1,28 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/pm-assist/pm-assist/internal/app"
-	"github.com/pm-assist/pm-assist/internal/cli/prompt"
 	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
 	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/extract"
 	"github.com/pm-assist/pm-assist/internal/logging"
+	"github.com/pm-assist/pm-assist/internal/manifest"
 	"github.com/pm-assist/pm-assist/internal/notebook"
+	"github.com/pm-assist/pm-assist/internal/object"
 	"github.com/pm-assist/pm-assist/internal/paths"
 	"github.com/pm-assist/pm-assist/internal/policy"
 	"github.com/pm-assist/pm-assist/internal/runner"
+	"github.com/pm-assist/pm-assist/internal/state"
 	"github.com/pm-assist/pm-assist/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -32,8 +41,19 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 		flagSheet     string
 		flagJSONLines string
 		flagZipMember string
+		flagAliases   string
+		flagDrift     string
 		flagQuery     string
 		flagConfirm   string
+
+		flagIncremental     string
+		flagWatermarkColumn string
+		flagWatermarkType   string
+		flagQueryName       string
+		flagParams          []string
+		flagSaveQuery       string
+		flagExtract         extractFlags
+		flagStreamRange     streamRangeFlags
 	)
 	cmd := &cobra.Command{
 		Use:   "ingest",
@@ -113,8 +133,7 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 			var (
 				filePath  string
 				format    string
-				encoding  string
-				delimiter string
+				dialect   csvdialect.Dialect
 				sheet     string
 				jsonLines bool
 				zipMember string
@@ -123,26 +142,46 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 			if selected.Type == "database" && selected.Database != nil {
 				driver = selected.Database.Driver
 			}
+			if flagIncremental != "" && selected.Type != "database" {
+				return fmt.Errorf("--incremental is only supported for database connectors")
+			}
+			if flagDrift != "warn" && flagDrift != "fail" {
+				return fmt.Errorf("invalid --schema-drift: %s (use warn|fail)", flagDrift)
+			}
+			var (
+				incremental   bool
+				watermark     state.Watermark
+				previousMark  state.Watermark
+				projectState  *state.State
+				extractedRows int64
+				extractQuery  manifest.QueryEntry
+				extractChunks []manifest.Chunk
+				queryMapping  *config.TemplateMapping
+				objects       []manifest.Object
+				streams       []manifest.StreamRange
+				sourceFiles   []string
+				combined      []manifest.SourceFile
+			)
 			if selected.Type == "file" {
 				if selected.File == nil || len(selected.File.Paths) == 0 {
 					return fmt.Errorf("file connector missing paths")
 				}
-				filePath = selected.File.Paths[0]
+				patterns := selected.File.Paths
 				if flagFile != "" {
-					filePath = flagFile
+					if patterns, err = resolvePathList(flagFile); err != nil {
+						return err
+					}
 				}
-				if _, err := os.Stat(filePath); err != nil {
-					return formatPathError(filePath)
+				if sourceFiles, err = expandPaths(patterns); err != nil {
+					return err
 				}
 				format = selected.File.Format
-				delimiter = selected.File.Delimiter
-				if delimiter == "" {
-					delimiter = ","
-				}
-				encoding = selected.File.Encoding
-				if encoding == "" {
-					encoding = "utf-8"
+				if len(sourceFiles) > 1 && format != "csv" && format != "parquet" {
+					fmt.Printf("[WARN] Combining files is supported for csv and parquet; ingesting %s only.\n", sourceFiles[0])
+					sourceFiles = sourceFiles[:1]
 				}
+				filePath = sourceFiles[0]
+				dialect = fileDialect(*selected.File)
 				sheet = selected.File.Sheet
 				jsonLines = selected.File.JSONLines
 				zipMember = selected.File.ZipMember
@@ -150,73 +189,280 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 				if selected.Database == nil || selected.Options == nil {
 					return fmt.Errorf("database connector missing config")
 				}
-				query := flagQuery
-				if query == "" {
-					query, err = prompt.AskTextArea("Query (read-only SQL). Alt+Enter to submit.", "")
+				if policies.OfflineOnly && !localConnector(*selected) {
+					return fmt.Errorf("database connector %s is blocked in offline-only mode", connectorName)
+				}
+				rendered, queryName, err := resolveIngestQuery(cfg, selected, flagQuery, flagQueryName, flagParams, flagSaveQuery)
+				if err != nil {
+					return err
+				}
+				if tmpl, ok := selected.Query(queryName); ok {
+					queryMapping = tmpl.Mapping
+				}
+				query := rendered.SQL
+				queryArgs := rendered.Args
+				projectState, err = state.Load(projectPath)
+				if err != nil {
+					return fmt.Errorf("load project state: %w", err)
+				}
+				previousMark = projectState.Watermarks[connectorName]
+				if flagIncremental != "" || previousMark.Value != "" {
+					question := "Extract incrementally using a watermark column?"
+					if previousMark.Value != "" {
+						question = fmt.Sprintf("Extract only rows with %s >= %s?", previousMark.Column, previousMark.Value)
+					}
+					// A saved mark only offers the delta; a full extract stays the
+					// default unless --incremental is set or the prompt is confirmed.
+					incremental, err = resolveBool(flagIncremental, question, false)
+					if err != nil {
+						return err
+					}
+					if !incremental && previousMark.Value != "" && flagIncremental == "" {
+						fmt.Printf("[INFO] Running a full extract; pass --incremental true to extract only rows with %s >= %s.\n", previousMark.Column, previousMark.Value)
+					}
+				}
+				if incremental {
+					column, err := resolveString(flagWatermarkColumn, "Watermark column", previousMark.Column, true)
+					if err != nil {
+						return err
+					}
+					defaultKind := previousMark.Kind
+					if defaultKind == "" {
+						defaultKind = state.KindTimestamp
+					}
+					kind, err := resolveChoice(flagWatermarkType, "Watermark type", []string{state.KindTimestamp, state.KindID}, defaultKind, true)
 					if err != nil {
 						return err
 					}
+					watermark = state.Watermark{Column: column, Kind: kind}
+					if column == previousMark.Column && kind == previousMark.Kind {
+						watermark.Value = previousMark.Value
+					} else if previousMark.Value != "" {
+						fmt.Println("[WARN] Watermark column or type changed; running a full extract.")
+						previousMark = state.Watermark{}
+					}
 				}
+				impl, dsn, err := connectorDriver(*selected)
 				if err != nil {
 					return err
 				}
-				credEnv := selected.Options.CredentialEnv
-				password := ""
-				if credEnv != "" {
-					password = os.Getenv(credEnv)
-				}
-				if password == "" && driver != "bigquery" {
-					return fmt.Errorf("credential env var %s is not set", credEnv)
+				extractCfg, err := resolveExtractConfig(selected.Database.Extract, flagExtract)
+				if err != nil {
+					return err
 				}
 				extractDir := filepath.Join(outputPath, "stage_00_extract")
 				if err := os.MkdirAll(extractDir, 0o755); err != nil {
 					return err
 				}
 				extractPath := filepath.Join(extractDir, "source_extract.csv")
+				resume := false
+				if flagExtract.Resume != "" || extract.HasCheckpoint(extractPath) {
+					resume, err = resolveBool(flagExtract.Resume, "Resume the previous extract from its last completed chunk?", true)
+					if err != nil {
+						return err
+					}
+				}
+				if resume && global.RunID == "" {
+					fmt.Println("[WARN] --resume reads the checkpoint of the run given by --run-id; none was given.")
+				}
 				driver = selected.Database.Driver
-				fmt.Printf("[INFO] Extracting data using %s...\n", driver)
-				var rows int64
-				switch driver {
-				case "postgres":
-					dsn := fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s", selected.Database.Host, selected.Database.Port, selected.Database.DBName, selected.Database.User, password, selected.Database.SSLMode)
-					rows, err = db.ExtractQueryToCSV("postgres", dsn, query, extractPath)
-				case "mysql":
-					dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", selected.Database.User, password, selected.Database.Host, selected.Database.Port, selected.Database.DBName)
-					rows, err = db.ExtractQueryToCSV("mysql", dsn, query, extractPath)
-				case "mssql":
-					dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", selected.Database.User, password, selected.Database.Host, selected.Database.Port, selected.Database.DBName)
-					rows, err = db.ExtractQueryToCSV("sqlserver", dsn, query, extractPath)
-				case "snowflake":
-					dsn, dsnErr := db.SnowflakeDSN(selected.Database.Host, selected.Database.User, password, selected.Database.DBName, selected.Database.Schema)
-					if dsnErr != nil {
-						return dsnErr
+				if incremental && watermark.Value != "" {
+					query, err = db.IncrementalQuery(driver, query, watermark.Column, len(queryArgs)+1)
+					if err != nil {
+						return err
 					}
-					rows, err = db.ExtractQueryToCSV("snowflake", dsn, query, extractPath)
-				case "bigquery":
-					credPath := selected.Database.User
-					if credPath == "" {
-						credPath = password
+					arg, err := watermark.Arg()
+					if err != nil {
+						return err
 					}
-					rows, err = db.ExtractBigQueryToCSV(selected.Database.Host, credPath, query, extractPath)
-				default:
-					return fmt.Errorf("database driver not supported for ingest: %s", driver)
+					queryArgs = append(queryArgs, arg)
+					fmt.Printf("[INFO] Incremental extract: %s >= %s\n", watermark.Column, watermark.Value)
+				} else if incremental {
+					fmt.Println("[INFO] No watermark recorded yet; running a full extract.")
+				}
+				executed := db.RenderedQuery{SQL: query, Args: queryArgs}
+				extractQuery = manifest.QueryEntry{
+					Connector: connectorName,
+					Name:      queryName,
+					SHA256:    executed.Hash(),
+					Params:    rendered.Values,
 				}
+				fmt.Printf("[INFO] Extracting data using %s...\n", driver)
+				rows, chunks, err := runDatabaseExtract(impl, dsn, query, queryArgs, extractCfg, resume, connectorName, extractPath)
 				if err != nil {
 					return err
 				}
+				extractChunks = chunks
+				if err := recordQueryMapping(extractPath, queryMapping); err != nil {
+					return err
+				}
 				fmt.Printf("[SUCCESS] Extracted %d rows to %s\n", rows, extractPath)
+				extractedRows = rows
+				if incremental {
+					value, ok, err := state.MaxWatermark(extractPath, watermark.Column, watermark.Kind)
+					if err != nil {
+						return err
+					}
+					if ok {
+						watermark.Value = value
+					}
+				}
 				filePath = extractPath
 				format = "csv"
-				delimiter = ","
-				encoding = "utf-8"
+			} else if selected.Type == "api" {
+				if policies.OfflineOnly {
+					return fmt.Errorf("api connector %s is blocked in offline-only mode", connectorName)
+				}
+				client, err := connectorAPI(*selected)
+				if err != nil {
+					return err
+				}
+				extractDir := filepath.Join(outputPath, "stage_00_extract")
+				if err := os.MkdirAll(extractDir, 0o755); err != nil {
+					return err
+				}
+				extractPath := filepath.Join(extractDir, "source_extract.csv")
+				fmt.Printf("[INFO] Extracting records from %s...\n", selected.API.URL)
+				result, err := client.Extract(context.Background(), extractPath)
+				if err != nil {
+					return fmt.Errorf("api extract failed after %d pages: %w", result.Pages, err)
+				}
+				if result.Retries > 0 {
+					fmt.Printf("[INFO] Retried %d requests after rate limiting or transient errors.\n", result.Retries)
+				}
+				fmt.Printf("[SUCCESS] Extracted %d rows from %d pages to %s\n", result.Rows, result.Pages, extractPath)
+				extractedRows = result.Rows
+				filePath = extractPath
+				format = "csv"
+			} else if selected.Type == "stream" {
+				if selected.Stream == nil {
+					return fmt.Errorf("stream connector missing stream config")
+				}
+				if policies.OfflineOnly {
+					return fmt.Errorf("stream connector %s is blocked in offline-only mode", connectorName)
+				}
+				streamConfig, err := applyStreamRange(*selected.Stream, flagStreamRange)
+				if err != nil {
+					return err
+				}
+				spec := *selected
+				spec.Stream = &streamConfig
+				extractDir := filepath.Join(outputPath, "stage_00_extract")
+				if err := os.MkdirAll(extractDir, 0o755); err != nil {
+					return err
+				}
+				extractPath := filepath.Join(extractDir, "source_extract.csv")
+				fmt.Printf("[INFO] Consuming %s (read-only, no offsets committed)...\n", streamLocation(streamConfig))
+				result, err := materializeConnectorStream(spec, extractPath)
+				if err != nil {
+					return fmt.Errorf("stream extract failed: %w", err)
+				}
+				for _, read := range result.Partitions {
+					fmt.Printf("  - partition %d: offsets %d to %d (%d messages)\n", read.Partition, read.Start, read.End, read.Messages)
+				}
+				fmt.Printf("[SUCCESS] Extracted %d rows to %s\n", result.Rows, extractPath)
+				streams = streamEntries(connectorName, result)
+				extractedRows = result.Rows
+				filePath = extractPath
+				format = "csv"
+			} else if selected.Type == "object" {
+				if selected.Object == nil {
+					return fmt.Errorf("object connector missing object config")
+				}
+				if policies.OfflineOnly {
+					return fmt.Errorf("object connector %s is blocked in offline-only mode", connectorName)
+				}
+				objectDir := filepath.Join(outputPath, "stage_00_extract", "objects")
+				downloaded, err := downloadConnectorObject(*selected, objectDir, flagFile)
+				if err != nil {
+					return err
+				}
+				objects = objectEntries(connectorName, []object.Downloaded{downloaded})
+				filePath = downloaded.Path
+				fmt.Printf("[SUCCESS] Downloaded %s\n", filePath)
+				format = selected.Object.Format
+				if format == "" {
+					format = "csv"
+				}
+				dialect = csvdialect.Dialect{Delimiter: selected.Object.Delimiter, Encoding: selected.Object.Encoding}
 			} else {
 				return fmt.Errorf("unsupported connector type: %s", selected.Type)
 			}
+			if flagDelimiter != "" {
+				dialect.Delimiter = flagDelimiter
+			}
+			if flagEncoding != "" {
+				dialect.Encoding = flagEncoding
+			}
+			dialect = dialect.Normalize()
+			if err := dialect.Validate(); err != nil {
+				return err
+			}
+			if len(sourceFiles) > 1 {
+				aliases := map[string]string{}
+				for alias, canonical := range selected.File.Aliases {
+					aliases[alias] = canonical
+				}
+				extra, err := parseAliases(flagAliases)
+				if err != nil {
+					return err
+				}
+				for alias, canonical := range extra {
+					aliases[alias] = canonical
+				}
+				combineDir := filepath.Join(outputPath, "stage_00_extract")
+				if err := os.MkdirAll(combineDir, 0o755); err != nil {
+					return err
+				}
+				combinedPath := filepath.Join(combineDir, "combined_source.csv")
+				report, err := eventlog.Concat(sourceFiles, combinedPath, eventlog.ConcatOptions{
+					Options: eventlog.Options{Dialect: dialect},
+					Aliases: aliases,
+				})
+				if err != nil {
+					return fmt.Errorf("combine files: %w", err)
+				}
+				for _, file := range report.Files {
+					for from, to := range file.Renamed {
+						fmt.Printf("[INFO] %s: column %q read as %q\n", file.Source, from, to)
+					}
+				}
+				drifted := report.Drifted()
+				for _, file := range drifted {
+					var changes []string
+					if len(file.Missing) > 0 {
+						changes = append(changes, "missing "+strings.Join(file.Missing, ", "))
+					}
+					if len(file.Extra) > 0 {
+						changes = append(changes, "extra "+strings.Join(file.Extra, ", "))
+					}
+					fmt.Printf("[WARN] Schema drift in %s: %s\n", file.Source, strings.Join(changes, "; "))
+				}
+				if len(drifted) > 0 && flagDrift == "fail" {
+					return fmt.Errorf("schema drift in %d of %d files; add --aliases or rerun with --schema-drift warn", len(drifted), len(report.Files))
+				}
+				for _, file := range report.Files {
+					combined = append(combined, manifest.SourceFile{
+						Connector:  selected.Name,
+						Path:       file.Path,
+						SourceFile: file.Source,
+						Rows:       file.Rows,
+						Renamed:    file.Renamed,
+						Missing:    file.Missing,
+						Extra:      file.Extra,
+					})
+				}
+				fmt.Printf("[SUCCESS] Combined %d files into %s (%d rows, %s column added)\n", len(report.Files), combinedPath, report.Rows, eventlog.SourceFileColumn)
+				filePath = combinedPath
+				format = "csv"
+				// The combined file is plain UTF-8 CSV; numbers keep their separator.
+				dialect = csvdialect.Dialect{Decimal: dialect.Decimal}.Normalize()
+			}
 			defaultCase := "case_id"
 			defaultActivity := "activity"
 			defaultTimestamp := "timestamp"
 			if format == "csv" && filePath != "" {
-				headers := readCSVHeaders(filePath, delimiter)
+				headers := csvHeaders(filePath, dialect)
 				if len(headers) > 0 {
 					caseGuess, activityGuess, timestampGuess := inferMapping(headers)
 					if caseGuess != "" {
@@ -230,6 +476,11 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 					}
 				}
 			}
+			defaultResource := ""
+			if queryMapping != nil {
+				defaultCase, defaultActivity, defaultTimestamp = queryMapping.CaseID, queryMapping.Activity, queryMapping.Timestamp
+				defaultResource = queryMapping.Resource
+			}
 			caseCol, err := resolveString(flagCase, "Case ID column", defaultCase, true)
 			if err != nil {
 				return err
@@ -242,15 +493,16 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			resourceCol, err := resolveString(flagResource, "Resource column (optional)", "", false)
+			resourceCol, err := resolveString(flagResource, "Resource column (optional)", defaultResource, false)
 			if err != nil {
 				return err
 			}
-			if flagDelimiter != "" {
-				delimiter = flagDelimiter
-			}
-			if flagEncoding != "" {
-				encoding = flagEncoding
+			for _, file := range combined {
+				for _, column := range []string{caseCol, activityCol, timestampCol} {
+					if slices.Contains(file.Missing, column) {
+						return fmt.Errorf("column %q is missing from %s; map its name there with --aliases", column, file.SourceFile)
+					}
+				}
 			}
 			if flagSheet != "" {
 				sheet = flagSheet
@@ -306,80 +558,177 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 			}
 
 			printStepProgress(1, 3, "Preparing ingest inputs")
-			if err := manifestManager.AddInputs([]string{filePath}); err != nil {
-				return err
+			inputs := []string{filePath}
+			if len(combined) > 0 {
+				inputs = sourceFiles
 			}
-
-			venvRunner := &runner.Runner{ProjectPath: projectPath}
-			skillsRoot, err := paths.SkillsRoot(projectPath)
-			if err != nil {
-				return err
+			if _, err := os.Stat(eventlog.SchemaPath(filePath)); err == nil {
+				inputs = append(inputs, eventlog.SchemaPath(filePath))
 			}
-			reqPath := paths.SkillPath(skillsRoot, "pm-99-utils-and-standards", "requirements.txt")
-			options, err := resolveVenvOptions(projectPath, policies)
-			if err != nil {
+			if err := manifestManager.AddInputs(inputs); err != nil {
 				return err
 			}
-			printDependencyNotice(options)
-			if err := ensureVenvWithSpinner(venvRunner, reqPath, options); err != nil {
-				return err
+			if extractQuery.SHA256 != "" {
+				if err := manifestManager.AddQuery(extractQuery); err != nil {
+					return err
+				}
 			}
-
-			printStepProgress(2, 3, "Running ingest script")
-			scriptPath := paths.SkillPath(skillsRoot, "pm-02-ingest-profile", "scripts", "01_ingest.py")
-			argsList := []string{
-				"--file", filePath,
-				"--format", format,
-				"--case", caseCol,
-				"--activity", activityCol,
-				"--timestamp", timestampCol,
-				"--output", outputPath,
+			if len(extractChunks) > 0 {
+				if err := manifestManager.AddChunks(extractChunks); err != nil {
+					return err
+				}
 			}
-			if format == "csv" || format == "zip-csv" {
-				argsList = append(argsList, "--delimiter", delimiter, "--encoding", encoding)
+			if len(streams) > 0 {
+				if err := manifestManager.AddStreams(streams); err != nil {
+					return err
+				}
 			}
-			if format == "xlsx" && sheet != "" {
-				argsList = append(argsList, "--sheet", sheet)
+			if len(objects) > 0 {
+				if err := manifestManager.AddObjects(objects); err != nil {
+					return err
+				}
 			}
-			if format == "json" && jsonLines {
-				argsList = append(argsList, "--json-lines")
+			if len(combined) > 0 {
+				if err := manifestManager.AddSourceFiles(combined); err != nil {
+					return err
+				}
 			}
-			if format == "zip-csv" && zipMember != "" {
-				argsList = append(argsList, "--zip-member", zipMember)
+			normalisedPath := filepath.Join(outputPath, "stage_01_ingest_profile", "normalised_log.csv")
+			baseLog := ""
+			if incremental && previousMark.LogPath != "" {
+				candidate := filepath.Join(projectPath, filepath.FromSlash(previousMark.LogPath))
+				if _, err := os.Stat(candidate); err == nil {
+					baseLog = candidate
+				} else {
+					fmt.Printf("[WARN] Previous log %s not found; the new log holds only this delta.\n", candidate)
+				}
 			}
-			if resourceCol != "" {
-				argsList = append(argsList, "--resource", resourceCol)
+			if baseLog != "" && filepath.Clean(baseLog) == filepath.Clean(normalisedPath) {
+				// The ingest script overwrites the log in place, so keep a copy to merge into.
+				snapshot := filepath.Join(filepath.Dir(normalisedPath), "normalised_log.base.csv")
+				if err := copyLocalFile(baseLog, snapshot); err != nil {
+					return err
+				}
+				baseLog = snapshot
+				defer os.Remove(snapshot)
 			}
+			carryForward := baseLog != "" && extractedRows == 0
 
-			fmt.Println("[INFO] Running ingest script...")
-			logging.Info("running ingest script", map[string]any{"script": scriptPath})
-			if err := venvRunner.RunScript(scriptPath, argsList, nil); err != nil {
-				return err
-			}
+			if carryForward {
+				fmt.Println("[INFO] No new rows since the last watermark; carrying the previous log forward.")
+				if err := os.MkdirAll(filepath.Dir(normalisedPath), 0o755); err != nil {
+					return err
+				}
+				if err := copyLocalFile(baseLog, normalisedPath); err != nil {
+					return err
+				}
+			} else {
+				venvRunner := &runner.Runner{ProjectPath: projectPath}
+				skillsRoot, err := paths.SkillsRoot(projectPath)
+				if err != nil {
+					return err
+				}
+				reqPath := paths.SkillPath(skillsRoot, "pm-99-utils-and-standards", "requirements.txt")
+				options, err := resolveVenvOptions(projectPath, policies)
+				if err != nil {
+					return err
+				}
+				printDependencyNotice(options)
+				if err := ensureVenvWithSpinner(venvRunner, reqPath, options); err != nil {
+					return err
+				}
 
-			nbPath := filepath.Join(outputPath, "analysis_notebook.ipynb")
-			markdown := "## Ingest\nWe ingested the source file and normalized the log."
-			code := fmt.Sprintf("!python %s --file %s --format %s --case %s --activity %s --timestamp %s --output %s", scriptPath, filePath, format, caseCol, activityCol, timestampCol, outputPath)
-			if format == "csv" || format == "zip-csv" {
-				code += fmt.Sprintf(" --delimiter %s --encoding %s", delimiter, encoding)
-			}
-			if format == "xlsx" && sheet != "" {
-				code += fmt.Sprintf(" --sheet %s", sheet)
-			}
-			if format == "json" && jsonLines {
-				code += " --json-lines"
+				printStepProgress(2, 3, "Running ingest script")
+				scriptPath := paths.SkillPath(skillsRoot, "pm-02-ingest-profile", "scripts", "01_ingest.py")
+				argsList := []string{
+					"--file", filePath,
+					"--format", format,
+					"--case", caseCol,
+					"--activity", activityCol,
+					"--timestamp", timestampCol,
+					"--output", outputPath,
+				}
+				if format == "csv" || format == "zip-csv" {
+					argsList = append(argsList, dialectArgs(dialect)...)
+				}
+				if format == "xlsx" && sheet != "" {
+					argsList = append(argsList, "--sheet", sheet)
+				}
+				if format == "json" && jsonLines {
+					argsList = append(argsList, "--json-lines")
+				}
+				if format == "zip-csv" && zipMember != "" {
+					argsList = append(argsList, "--zip-member", zipMember)
+				}
+				if resourceCol != "" {
+					argsList = append(argsList, "--resource", resourceCol)
+				}
+
+				fmt.Println("[INFO] Running ingest script...")
+				logging.Info("running ingest script", map[string]any{"script": scriptPath})
+				if err := venvRunner.RunScript(scriptPath, argsList, nil); err != nil {
+					return err
+				}
+
+				nbPath := filepath.Join(outputPath, "analysis_notebook.ipynb")
+				markdown := "## Ingest\nWe ingested the source file and normalized the log."
+				code := fmt.Sprintf("!python %s --file %s --format %s --case %s --activity %s --timestamp %s --output %s", scriptPath, filePath, format, caseCol, activityCol, timestampCol, outputPath)
+				if format == "csv" || format == "zip-csv" {
+					code += " " + strings.Join(quoteArgs(dialectArgs(dialect)), " ")
+				}
+				if format == "xlsx" && sheet != "" {
+					code += fmt.Sprintf(" --sheet %s", sheet)
+				}
+				if format == "json" && jsonLines {
+					code += " --json-lines"
+				}
+				if format == "zip-csv" && zipMember != "" {
+					code += fmt.Sprintf(" --zip-member %s", zipMember)
+				}
+				if resourceCol != "" {
+					code += fmt.Sprintf(" --resource %s", resourceCol)
+				}
+				if err := notebook.AppendStep(nbPath, "Ingest", markdown, code); err != nil {
+					return err
+				}
 			}
-			if format == "zip-csv" && zipMember != "" {
-				code += fmt.Sprintf(" --zip-member %s", zipMember)
+
+			printStepProgress(3, 3, "Finalizing ingest outputs")
+			if incremental {
+				delta := manifest.Delta{
+					Connector:       connectorName,
+					WatermarkColumn: watermark.Column,
+					WatermarkKind:   watermark.Kind,
+					From:            previousMark.Value,
+					To:              watermark.Value,
+					RowsExtracted:   extractedRows,
+				}
+				if baseLog != "" && !carryForward {
+					stats, err := eventlog.AppendDedup(baseLog, normalisedPath, normalisedPath)
+					if err != nil {
+						return fmt.Errorf("append delta to previous log: %w", err)
+					}
+					delta.RowsAppended = stats.Appended
+					delta.DuplicateRows = stats.Duplicates
+					fmt.Printf("[INFO] Appended %d new events to the previous log (%d duplicates dropped).\n", stats.Appended, stats.Duplicates)
+				} else if baseLog == "" {
+					delta.RowsAppended = extractedRows
+				}
+				if baseLog != "" {
+					delta.BaseLog = previousMark.LogPath
+				}
+				if err := manifestManager.AddDelta(delta); err != nil {
+					return err
+				}
 			}
-			if resourceCol != "" {
-				code += fmt.Sprintf(" --resource %s", resourceCol)
+			// The script renames the mapped columns and rewrites timestamps, so
+			// translate the extract's sidecar for map and review.
+			if err := eventlog.DeriveSchema(filePath, normalisedPath, xesRenames(caseCol, activityCol, timestampCol, resourceCol)); err != nil {
+				return fmt.Errorf("write schema sidecar: %w", err)
 			}
-			if err := notebook.AppendStep(nbPath, "Ingest", markdown, code); err != nil {
+			if err := materializeStageLog(cfg, normalisedPath, []string{timestampCol, "time:timestamp"}); err != nil {
 				return err
 			}
-
-			printStepProgress(3, 3, "Finalizing ingest outputs")
 			if err := manifestManager.AddOutputs([]string{outputPath}); err != nil {
 				return err
 			}
@@ -389,6 +738,19 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 			if err := manifestManager.SetStatus("completed"); err != nil {
 				return err
 			}
+			if incremental {
+				watermark.RunID = runID
+				watermark.LogPath = normalisedPath
+				if rel, err := filepath.Rel(projectPath, normalisedPath); err == nil {
+					watermark.LogPath = filepath.ToSlash(rel)
+				}
+				watermark.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+				projectState.Watermarks[connectorName] = watermark
+				if err := projectState.Save(projectPath); err != nil {
+					return fmt.Errorf("save project state: %w", err)
+				}
+				fmt.Printf("[INFO] Watermark for %s is now %s = %s\n", connectorName, watermark.Column, watermark.Value)
+			}
 			stepSuccess = true
 			success = true
 
@@ -400,7 +762,9 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 		Example: "  pm-assist ingest",
 	}
 	cmd.Flags().StringVar(&flagConnector, "connector", "", "Connector name")
-	cmd.Flags().StringVar(&flagFile, "file", "", "Input file path override")
+	cmd.Flags().StringVar(&flagFile, "file", "", "Input file paths or glob patterns overriding the connector's (object key for object connectors)")
+	cmd.Flags().StringVar(&flagAliases, "aliases", "", "Extra column aliases for combining files (alias=canonical,...)")
+	cmd.Flags().StringVar(&flagDrift, "schema-drift", "warn", "Schema drift between combined files (warn|fail)")
 	cmd.Flags().StringVar(&flagCase, "case", "", "Case ID column")
 	cmd.Flags().StringVar(&flagActivity, "activity", "", "Activity column")
 	cmd.Flags().StringVar(&flagTimestamp, "timestamp", "", "Timestamp column")
@@ -411,6 +775,23 @@ func NewIngestCmd(global *app.GlobalFlags) *cobra.Command {
 	cmd.Flags().StringVar(&flagJSONLines, "json-lines", "", "JSON lines format (true|false)")
 	cmd.Flags().StringVar(&flagZipMember, "zip-member", "", "Zip member name")
 	cmd.Flags().StringVar(&flagQuery, "query", "", "SQL query for database connectors")
+	cmd.Flags().StringVar(&flagQueryName, "query-name", "", "Named query template from the connector config")
+	cmd.Flags().StringArrayVar(&flagParams, "param", nil, "Query template parameter as name=value (repeatable; lists are comma-separated)")
+	cmd.Flags().StringVar(&flagSaveQuery, "save-query", "", "Save the ad-hoc --query as a named template")
 	cmd.Flags().StringVar(&flagConfirm, "confirm", "", "Run ingest now (true|false)")
+	cmd.Flags().StringVar(&flagIncremental, "incremental", "", "Extract only rows at or past the connector's watermark (true|false)")
+	cmd.Flags().StringVar(&flagWatermarkColumn, "watermark-column", "", "Column tracked as the incremental watermark")
+	cmd.Flags().StringVar(&flagWatermarkType, "watermark-type", "", "Watermark type (timestamp|id)")
+	cmd.Flags().StringVar(&flagExtract.Timeout, "extract-timeout", "", "Per-query extract timeout, e.g. 45m (0 disables)")
+	cmd.Flags().StringVar(&flagExtract.ChunkColumn, "chunk-column", "", "Unique ordered column for keyset-chunked extraction")
+	cmd.Flags().StringVar(&flagExtract.ChunkType, "chunk-type", "", "Chunk column type (id|timestamp)")
+	cmd.Flags().StringVar(&flagExtract.ChunkSize, "chunk-size", "", "Rows per extract chunk")
+	cmd.Flags().StringVar(&flagExtract.PartitionColumn, "partition-column", "", "Date column used to partition the extract")
+	cmd.Flags().StringVar(&flagExtract.PartitionBy, "partition-by", "", "Partition granularity (day|month|year)")
+	cmd.Flags().StringVar(&flagExtract.PartitionStart, "partition-start", "", "First partition date (inclusive)")
+	cmd.Flags().StringVar(&flagExtract.PartitionEnd, "partition-end", "", "Partition end date (exclusive)")
+	cmd.Flags().StringVar(&flagExtract.Parallel, "parallel", "", "Partitions extracted in parallel")
+	addStreamRangeFlags(cmd, &flagStreamRange)
+	cmd.Flags().StringVar(&flagExtract.Resume, "resume", "", "Resume a failed chunked extract of --run-id (true|false)")
 	return cmd
 }
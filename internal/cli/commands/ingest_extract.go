@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/extract"
+	"github.com/pm-assist/pm-assist/internal/manifest"
+	"github.com/pm-assist/pm-assist/internal/state"
+	"github.com/pm-assist/pm-assist/internal/ui"
+)
+
+// extractFlags override the connector's extract config for one run.
+type extractFlags struct {
+	Timeout         string
+	ChunkColumn     string
+	ChunkType       string
+	ChunkSize       string
+	PartitionColumn string
+	PartitionBy     string
+	PartitionStart  string
+	PartitionEnd    string
+	Parallel        string
+	Resume          string
+}
+
+// resolveExtractConfig merges flag overrides into the connector's extract
+// settings and validates the result.
+func resolveExtractConfig(base *config.ExtractConfig, flags extractFlags) (config.ExtractConfig, error) {
+	var cfg config.ExtractConfig
+	if base != nil {
+		cfg = *base
+	}
+	for _, override := range []struct {
+		flag   string
+		target *string
+	}{
+		{flags.Timeout, &cfg.Timeout},
+		{flags.ChunkColumn, &cfg.ChunkColumn},
+		{flags.ChunkType, &cfg.ChunkType},
+		{flags.PartitionColumn, &cfg.PartitionColumn},
+		{flags.PartitionBy, &cfg.PartitionBy},
+		{flags.PartitionStart, &cfg.PartitionStart},
+		{flags.PartitionEnd, &cfg.PartitionEnd},
+	} {
+		if override.flag != "" {
+			*override.target = override.flag
+		}
+	}
+	for _, override := range []struct {
+		name   string
+		flag   string
+		target *int
+	}{
+		{"--chunk-size", flags.ChunkSize, &cfg.ChunkSize},
+		{"--parallel", flags.Parallel, &cfg.Parallel},
+	} {
+		if override.flag == "" {
+			continue
+		}
+		value, err := strconv.Atoi(override.flag)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s: %s", override.name, override.flag)
+		}
+		*override.target = value
+	}
+	if cfg.ChunkColumn != "" && cfg.ChunkSize == 0 {
+		return cfg, fmt.Errorf("chunk column %s set without a chunk size", cfg.ChunkColumn)
+	}
+	return cfg, cfg.Validate()
+}
+
+// extractTimeout returns the per-query timeout; zero means no limit.
+func extractTimeout(cfg config.ExtractConfig) time.Duration {
+	if cfg.Timeout == "" {
+		return db.ExtractTimeout
+	}
+	timeout, _ := time.ParseDuration(cfg.Timeout)
+	return timeout
+}
+
+// runDatabaseExtract writes the query result to extractPath. Chunked or
+// partitioned configs go through part files with a resumable checkpoint and
+// return the chunks for the run manifest.
+func runDatabaseExtract(impl db.Driver, dsn string, query string, args []any, cfg config.ExtractConfig, resume bool, connector string, extractPath string) (int64, []manifest.Chunk, error) {
+	timeout := extractTimeout(cfg)
+	if cfg.ChunkSize == 0 && cfg.PartitionBy == "" {
+		if resume {
+			fmt.Println("[WARN] --resume only applies to chunked or partitioned extracts; running a full extract.")
+		}
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		defer cancel()
+		rows, err := impl.Extract(ctx, dsn, query, extractPath, args...)
+		return rows, nil, err
+	}
+
+	plan := extract.Plan{
+		Driver:          impl,
+		DSN:             dsn,
+		Query:           query,
+		Args:            args,
+		Timeout:         timeout,
+		ChunkColumn:     cfg.ChunkColumn,
+		ChunkType:       cfg.ChunkType,
+		ChunkSize:       cfg.ChunkSize,
+		PartitionColumn: cfg.PartitionColumn,
+		Parallel:        cfg.Parallel,
+	}
+	if plan.ChunkType == "" {
+		plan.ChunkType = state.KindID
+	}
+	if cfg.PartitionBy != "" {
+		start, err := eventlog.ParseTimestamp(cfg.PartitionStart)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid partition start %q: %w", cfg.PartitionStart, err)
+		}
+		end, err := eventlog.ParseTimestamp(cfg.PartitionEnd)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid partition end %q: %w", cfg.PartitionEnd, err)
+		}
+		plan.Partitions, err = db.Partitions(cfg.PartitionBy, start, end)
+		if err != nil {
+			return 0, nil, err
+		}
+		fmt.Printf("[INFO] Extracting %d %s partitions of %s.\n", len(plan.Partitions), cfg.PartitionBy, cfg.PartitionColumn)
+	}
+	if cfg.ChunkSize > 0 {
+		fmt.Printf("[INFO] Chunking by %s, %d rows per chunk.\n", cfg.ChunkColumn, cfg.ChunkSize)
+	}
+
+	var result extract.Result
+	err := ui.RunWithProgress(fmt.Sprintf("Extracting from %s...", impl.Info().Label), func(update func(string)) error {
+		var err error
+		result, err = extract.Run(context.Background(), plan, extractPath, resume, update)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if result.Resumed > 0 {
+		fmt.Printf("[INFO] Resumed after %d completed chunks.\n", result.Resumed)
+	}
+	chunks := make([]manifest.Chunk, len(result.Chunks))
+	for i, chunk := range result.Chunks {
+		chunks[i] = manifest.Chunk{
+			Connector: connector,
+			Partition: chunk.Partition,
+			Index:     chunk.Index,
+			Path:      chunk.Path,
+			Rows:      chunk.Rows,
+			SHA256:    chunk.SHA256,
+			LastKey:   chunk.LastKey,
+		}
+	}
+	return result.Rows, chunks, nil
+}
+
+// recordQueryMapping adds a query template's event log mapping to the
+// extract's schema sidecar so map can pre-fill it.
+func recordQueryMapping(extractPath string, mapping *config.TemplateMapping) error {
+	if mapping == nil {
+		return nil
+	}
+	schema, err := eventlog.ReadSchema(extractPath)
+	if err != nil || schema == nil {
+		return err
+	}
+	schema.Mapping = &eventlog.SchemaMapping{
+		CaseID:          mapping.CaseID,
+		Activity:        mapping.Activity,
+		Timestamp:       mapping.Timestamp,
+		Resource:        mapping.Resource,
+		TimestampFormat: mapping.TimestampFormat,
+	}
+	return eventlog.WriteSchema(extractPath, *schema)
+}
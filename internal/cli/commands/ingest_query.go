@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/cli/prompt"
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/db"
+)
+
+const customQueryOption = "custom SQL"
+
+// resolveIngestQuery picks the extraction query for a database connector:
+// an ad-hoc --query, a named template selected by --query-name (filled from
+// --param values, defaults, or prompts), or an interactive choice between the
+// two. It returns the rendered query and the template name, if any.
+func resolveIngestQuery(cfg *config.Config, connector *config.ConnectorSpec, flagQuery string, flagQueryName string, flagParams []string, flagSaveQuery string) (db.RenderedQuery, string, error) {
+	if flagQuery != "" && flagQueryName != "" {
+		return db.RenderedQuery{}, "", fmt.Errorf("use either --query or --query-name, not both")
+	}
+	values, err := parseParamFlags(flagParams)
+	if err != nil {
+		return db.RenderedQuery{}, "", err
+	}
+	queryName := flagQueryName
+	if flagQuery == "" && queryName == "" && len(connector.Queries) > 0 {
+		options := make([]string, 0, len(connector.Queries)+1)
+		for _, query := range connector.Queries {
+			options = append(options, query.Name)
+		}
+		options = append(options, customQueryOption)
+		choice, err := resolveChoice("", "Query template", options, options[0], true)
+		if err != nil {
+			return db.RenderedQuery{}, "", err
+		}
+		if choice != customQueryOption {
+			queryName = choice
+		}
+	}
+
+	if queryName == "" {
+		if len(values) > 0 {
+			return db.RenderedQuery{}, "", fmt.Errorf("--param requires --query-name")
+		}
+		query := flagQuery
+		if query == "" {
+			query, err = prompt.AskTextArea("Query (read-only SQL). Alt+Enter to submit.", "")
+			if err != nil {
+				return db.RenderedQuery{}, "", err
+			}
+		}
+		saveName, err := resolveString(flagSaveQuery, "Save this query as a named template (optional)", "", false)
+		if err != nil {
+			return db.RenderedQuery{}, "", err
+		}
+		if saveName != "" && strings.TrimSpace(query) != "" {
+			if err := saveQueryTemplate(cfg, connector, config.QueryTemplate{Name: saveName, SQL: query}); err != nil {
+				return db.RenderedQuery{}, "", err
+			}
+			fmt.Printf("[SUCCESS] Saved query template %s for connector %s\n", saveName, connector.Name)
+			queryName = saveName
+		}
+		return db.RenderedQuery{SQL: query}, queryName, nil
+	}
+
+	tmpl, ok := connector.Query(queryName)
+	if !ok {
+		return db.RenderedQuery{}, "", fmt.Errorf("query template not found for connector %s: %s", connector.Name, queryName)
+	}
+	for _, param := range tmpl.Params {
+		if _, ok := values[param.Name]; ok {
+			continue
+		}
+		value, err := resolveString("", fmt.Sprintf("%s (%s)", param.Name, param.Type), param.Default, param.Required)
+		if err != nil {
+			return db.RenderedQuery{}, "", fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		values[param.Name] = value
+	}
+	rendered, err := db.RenderQuery(connector.Database.Driver, tmpl, values)
+	if err != nil {
+		return db.RenderedQuery{}, "", err
+	}
+	return rendered, queryName, nil
+}
+
+// parseParamFlags turns repeated name=value flags into a map.
+func parseParamFlags(flags []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --param %q (expected name=value)", flag)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+func saveQueryTemplate(cfg *config.Config, connector *config.ConnectorSpec, tmpl config.QueryTemplate) error {
+	if _, exists := connector.Query(tmpl.Name); exists {
+		return fmt.Errorf("query template already exists for connector %s: %s", connector.Name, tmpl.Name)
+	}
+	if cfg.Path == "" {
+		return fmt.Errorf("no pm-assist.yaml found; run `pm-assist init` first")
+	}
+	connector.Queries = append(connector.Queries, tmpl)
+	return cfg.Save()
+}
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/pm-assist/pm-assist/internal/app"
 	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
 	"github.com/pm-assist/pm-assist/internal/logging"
 	"github.com/pm-assist/pm-assist/internal/policy"
 	"github.com/pm-assist/pm-assist/internal/preview"
+	"github.com/pm-assist/pm-assist/internal/timeformat"
 	"github.com/pm-assist/pm-assist/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -28,6 +32,11 @@ func NewMapCmd(global *app.GlobalFlags) *cobra.Command {
 		flagDelimiter  string
 		flagEncoding   string
 		flagPreview    string
+
+		flagLayout        string
+		flagTimestampCols string
+		flagNaming        string
+		flagActivityNames string
 	)
 	cmd := &cobra.Command{
 		Use:   "map",
@@ -92,28 +101,105 @@ func NewMapCmd(global *app.GlobalFlags) *cobra.Command {
 				}
 			}()
 
-			defaultInput := filepath.Join(outputPath, "stage_01_ingest_profile", "normalised_log.csv")
+			defaultInput := stageLogPath(cfg, outputPath, "stage_01_ingest_profile", "normalised_log")
 			inputPath, err := resolveString(flagInput, "Input log path", defaultInput, true)
 			if err != nil {
 				return err
 			}
-			caseCol, err := resolveString(flagCase, "Case ID column", "case_id", true)
+			defaultCase, defaultActivity, defaultTimestamp, defaultFormat := "case_id", "activity", "timestamp", ""
+			defaultResource := ""
+			schema, err := eventlog.ReadSchema(inputPath)
 			if err != nil {
-				return err
+				fmt.Printf("[WARN] Ignoring unreadable schema sidecar: %v\n", err)
+			} else if schema != nil {
+				fmt.Printf("[INFO] Source column types from %s:\n", filepath.Base(eventlog.SchemaPath(inputPath)))
+				profiles := make([]db.ColumnProfile, len(schema.Columns))
+				for i, column := range schema.Columns {
+					fmt.Printf("  - %s: %s (%s)\n", column.Name, column.SourceType, column.Type)
+					profiles[i] = db.ColumnProfile{Column: db.Column{Name: column.Name, Type: column.SourceType}}
+				}
+				suggestion := db.SuggestMapping(profiles)
+				if best := db.Best(suggestion.CaseID); best != "" {
+					defaultCase = best
+				}
+				if best := db.Best(suggestion.Activity); best != "" {
+					defaultActivity = best
+				}
+				for _, candidate := range suggestion.Timestamp {
+					if column, ok := schema.Column(candidate.Column); ok && column.Temporal() {
+						defaultTimestamp = column.Name
+						break
+					}
+				}
+				if mapping := schema.Mapping; mapping != nil {
+					fmt.Println("[INFO] Pre-filling the mapping declared by the extraction query template.")
+					defaultCase, defaultActivity, defaultTimestamp = mapping.CaseID, mapping.Activity, mapping.Timestamp
+					defaultResource, defaultFormat = mapping.Resource, mapping.TimestampFormat
+				}
 			}
-			activityCol, err := resolveString(flagActivity, "Activity column", "activity", true)
+			caseCol, err := resolveString(flagCase, "Case ID column", defaultCase, true)
 			if err != nil {
 				return err
 			}
-			timestampCol, err := resolveString(flagTimestamp, "Timestamp column", "timestamp", true)
+			layout, err := resolveChoice(flagLayout, "Log layout (long: one row per event, wide: one row per case)", []string{"long", "wide"}, "long", true)
 			if err != nil {
 				return err
 			}
-			resourceCol, err := resolveString(flagResource, "Resource column (optional)", "", false)
+			var (
+				activityCol  string
+				timestampCol string
+				wideColumns  []eventlog.WideColumn
+			)
+			formatColumns := []string{}
+			if layout == "wide" {
+				wideColumns, err = resolveWideColumns(cfg, inputPath, caseCol, flagTimestampCols, flagNaming, flagActivityNames)
+				if err != nil {
+					return err
+				}
+				activityCol, timestampCol = eventlog.ActivityColumn, eventlog.TimestampColumn
+				for _, column := range wideColumns {
+					formatColumns = append(formatColumns, column.Column)
+				}
+			} else {
+				activityCol, err = resolveString(flagActivity, "Activity column", defaultActivity, true)
+				if err != nil {
+					return err
+				}
+				timestampCol, err = resolveString(flagTimestamp, "Timestamp column", defaultTimestamp, true)
+				if err != nil {
+					return err
+				}
+				formatColumns = append(formatColumns, timestampCol)
+			}
+			// Typed extract columns are already normalised, so the format is known.
+			if column, ok := schema.Column(formatColumns[0]); ok {
+				if eventlog.FormatOf(inputPath) == eventlog.FormatParquet {
+					// Parquet keeps typed values, whatever layout the CSV had.
+					column.Layout = ""
+				}
+				defaultFormat = column.Format()
+			}
+			if defaultFormat == "" {
+				if inference, err := inferTimestampFormat(cfg, inputPath, formatColumns...); err != nil {
+					fmt.Printf("[WARN] Timestamp format inference skipped: %v\n", err)
+				} else if inference.Layout == "" {
+					fmt.Printf("[WARN] Timestamp format not recognised: %s\n", inference.Describe())
+				} else {
+					fmt.Printf("[INFO] Proposed timestamp format: %s\n", inference.Describe())
+					if inference.Ambiguous {
+						fmt.Printf("[WARN] Day/month order is ambiguous: %s also fits every sampled value. Check the format before confirming.\n", strings.Join(inference.Alternatives, ", "))
+					}
+					if inference.Confidence < 0.95 {
+						fmt.Println("[WARN] Some sampled values do not match the proposed format; they are parsed with the next best layouts.")
+					}
+					defaultFormat = inference.Layout
+				}
+			}
+			resourceCol, err := resolveString(flagResource, "Resource column (optional)", defaultResource, false)
 			if err != nil {
 				return err
 			}
-			timestampFormat, err := resolveString(flagTimeFormat, "Timestamp format (optional)", "", false)
+			timestampFormat, err := resolveString(flagTimeFormat, "Timestamp format (optional)", defaultFormat, false)
 			if err != nil {
 				return err
 			}
@@ -126,29 +212,62 @@ func NewMapCmd(global *app.GlobalFlags) *cobra.Command {
 				return formatPathError(inputPath)
 			}
 
-			previewNow, err := resolveBool(flagPreview, "Preview CSV headers and sample rows?", true)
+			previewNow, err := resolveBool(flagPreview, "Preview headers and sample rows?", true)
 			if err != nil {
 				return err
 			}
-			if previewNow && strings.HasSuffix(strings.ToLower(inputPath), ".csv") {
-				delimiter, err := resolveString(flagDelimiter, "CSV delimiter", ",", true)
+			if previewNow {
+				format, jsonLines := preview.FormatOf(inputPath)
+				opts := preview.Options{Format: format, JSONLines: jsonLines, SampleRows: 5}
+				if format == preview.FormatCSV || format == preview.FormatZipCSV {
+					opts.Dialect = dialectForPath(cfg, inputPath).Normalize()
+					if opts.Dialect.Delimiter, err = resolveString(flagDelimiter, "CSV delimiter", opts.Dialect.Delimiter, true); err != nil {
+						return err
+					}
+					if opts.Dialect.Encoding, err = resolveString(flagEncoding, "CSV encoding", opts.Dialect.Encoding, true); err != nil {
+						return err
+					}
+				}
+				sample, err := preview.Preview(inputPath, opts)
 				if err != nil {
+					fmt.Printf("[WARN] Preview failed: %v\n", err)
+				} else {
+					fmt.Println(preview.FormatSample(sample))
+				}
+			}
+
+			sourcePath := ""
+			if layout == "wide" {
+				unpivotPath := filepath.Join(outputPath, "stage_01_ingest_profile", "unpivoted_log.csv")
+				if err := os.MkdirAll(filepath.Dir(unpivotPath), 0o755); err != nil {
 					return err
 				}
-				encoding, err := resolveString(flagEncoding, "CSV encoding", "utf-8", true)
+				dialect := dialectForPath(cfg, inputPath)
+				var keep []string
+				for _, column := range csvHeaders(inputPath, dialect) {
+					if column != caseCol && !slices.ContainsFunc(wideColumns, func(wide eventlog.WideColumn) bool { return wide.Column == column }) {
+						keep = append(keep, column)
+					}
+				}
+				stats, err := eventlog.Unpivot(inputPath, unpivotPath, eventlog.UnpivotOptions{
+					Options:    eventlog.Options{Dialect: dialect},
+					CaseColumn: caseCol,
+					Columns:    wideColumns,
+					Keep:       keep,
+				})
 				if err != nil {
-					return err
+					return fmt.Errorf("unpivot wide table: %w", err)
 				}
-				if strings.ToLower(encoding) != "utf-8" {
-					fmt.Printf("[WARN] Preview skipped for encoding %s (only utf-8 supported).\n", encoding)
-				} else {
-					sample, err := preview.PreviewCSV(inputPath, delimiter, 5, false)
-					if err != nil {
-						fmt.Printf("[WARN] Preview failed: %v\n", err)
-					} else {
-						fmt.Println(preview.FormatSample(sample))
+				for _, column := range keep {
+					if renamed, ok := stats.Renamed[column]; ok {
+						fmt.Printf("[WARN] Column %s clashes with an event log column; kept as %s.\n", column, renamed)
+						if resourceCol == column {
+							resourceCol = renamed
+						}
 					}
 				}
+				fmt.Printf("[SUCCESS] Unpivoted %d cases into %d events at %s (%d empty timestamps skipped)\n", stats.Rows, stats.Events, unpivotPath, stats.Skipped)
+				sourcePath, inputPath = inputPath, unpivotPath
 			}
 
 			cfg.Mapping = &config.MappingConfig{
@@ -160,11 +279,21 @@ func NewMapCmd(global *app.GlobalFlags) *cobra.Command {
 				TimestampFormat: timestampFormat,
 				Timezone:        timezone,
 			}
+			if layout == "wide" {
+				cfg.Mapping.Layout, cfg.Mapping.SourcePath = layout, sourcePath
+				for _, column := range wideColumns {
+					cfg.Mapping.WideColumns = append(cfg.Mapping.WideColumns, config.WideColumnSpec{Column: column.Column, Activity: column.Activity})
+				}
+			}
 			if err := cfg.Save(); err != nil {
 				return err
 			}
 
-			if err := manifestManager.AddInputs([]string{inputPath}); err != nil {
+			inputs := []string{inputPath}
+			if sourcePath != "" {
+				inputs = []string{sourcePath}
+			}
+			if err := manifestManager.AddInputs(inputs); err != nil {
 				return err
 			}
 			if err := manifestManager.AddOutputs([]string{outputPath}); err != nil {
@@ -190,11 +319,86 @@ func NewMapCmd(global *app.GlobalFlags) *cobra.Command {
 	cmd.Flags().StringVar(&flagCase, "case", "", "Case ID column")
 	cmd.Flags().StringVar(&flagActivity, "activity", "", "Activity column")
 	cmd.Flags().StringVar(&flagTimestamp, "timestamp", "", "Timestamp column")
+	cmd.Flags().StringVar(&flagLayout, "layout", "", "Log layout (long|wide)")
+	cmd.Flags().StringVar(&flagTimestampCols, "timestamp-columns", "", "Wide layout: timestamp columns that become activities (comma-separated)")
+	cmd.Flags().StringVar(&flagNaming, "activity-naming", "", "Wide layout: activity naming rule (humanize|column)")
+	cmd.Flags().StringVar(&flagActivityNames, "activity-names", "", "Wide layout: activity name overrides (column=name,...)")
 	cmd.Flags().StringVar(&flagResource, "resource", "", "Resource column")
 	cmd.Flags().StringVar(&flagTimeFormat, "timestamp-format", "", "Timestamp format")
 	cmd.Flags().StringVar(&flagTimezone, "timezone", "", "Timezone")
 	cmd.Flags().StringVar(&flagDelimiter, "delimiter", "", "CSV delimiter")
-	cmd.Flags().StringVar(&flagEncoding, "encoding", "", "CSV encoding")
-	cmd.Flags().StringVar(&flagPreview, "preview", "", "Preview CSV headers and sample rows (true|false)")
+	cmd.Flags().StringVar(&flagEncoding, "encoding", "", "CSV encoding (e.g., utf-8, windows-1252, utf-16)")
+	cmd.Flags().StringVar(&flagPreview, "preview", "", "Preview headers and sample rows (true|false)")
 	return cmd
 }
+
+// inferTimestampFormat samples the timestamp columns of a CSV or Parquet
+// log and infers their shared layout.
+func inferTimestampFormat(cfg *config.Config, path string, columns ...string) (timeformat.Inference, error) {
+	if format, _ := preview.FormatOf(path); format != preview.FormatCSV && format != preview.FormatParquet {
+		return timeformat.Inference{}, fmt.Errorf("%s input", format)
+	}
+	opts := eventlog.Options{Dialect: dialectForPath(cfg, path)}
+	var sample []string
+	for _, column := range columns {
+		values, err := eventlog.SampleColumn(path, opts, column, 1000/len(columns))
+		if err != nil {
+			return timeformat.Inference{}, err
+		}
+		sample = append(sample, slices.DeleteFunc(values, eventlog.IsNull)...)
+	}
+	return timeformat.Infer(sample), nil
+}
+
+// resolveWideColumns asks for the timestamp columns of a wide table,
+// proposing those whose sampled values read as timestamps, and names the
+// activity each records.
+func resolveWideColumns(cfg *config.Config, path string, caseCol string, flagColumns string, flagNaming string, flagNames string) ([]eventlog.WideColumn, error) {
+	if format, _ := preview.FormatOf(path); format != preview.FormatCSV && format != preview.FormatParquet {
+		return nil, fmt.Errorf("wide layout needs a CSV or Parquet input, got %s", format)
+	}
+	dialect := dialectForPath(cfg, path)
+	opts := eventlog.Options{Dialect: dialect}
+	var detected []string
+	for _, column := range csvHeaders(path, dialect) {
+		if column == caseCol {
+			continue
+		}
+		sample, err := eventlog.SampleColumn(path, opts, column, 200)
+		if err != nil {
+			return nil, err
+		}
+		if inference := timeformat.Infer(slices.DeleteFunc(sample, eventlog.IsNull)); inference.Layout != "" && inference.Confidence >= 0.9 {
+			detected = append(detected, column)
+		}
+	}
+	columnList, err := resolveString(flagColumns, "Timestamp columns that become activities (comma-separated)", strings.Join(detected, ","), true)
+	if err != nil {
+		return nil, err
+	}
+	naming, err := resolveChoice(flagNaming, "Activity naming (humanize: created_at -> Created, column: keep names)", []string{eventlog.NamingHumanize, eventlog.NamingColumn}, eventlog.NamingHumanize, true)
+	if err != nil {
+		return nil, err
+	}
+	names, err := parsePairs(flagNames, "activity name", "column=name")
+	if err != nil {
+		return nil, err
+	}
+	var columns []eventlog.WideColumn
+	for _, column := range splitCSV(columnList) {
+		activity, ok := names[column]
+		if !ok {
+			activity = eventlog.ActivityName(column, naming)
+		}
+		columns = append(columns, eventlog.WideColumn{Column: column, Activity: activity})
+		fmt.Printf("[INFO] %s -> activity %q\n", column, activity)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("wide layout needs at least one timestamp column")
+	}
+	for _, group := range eventlog.SharedActivities(columns) {
+		activity := columns[slices.IndexFunc(columns, func(column eventlog.WideColumn) bool { return column.Column == group[0] })].Activity
+		fmt.Printf("[WARN] Columns %s all become activity %q and cannot be told apart; name them with --activity-names.\n", strings.Join(group, ", "), activity)
+	}
+	return columns, nil
+}
@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/manifest"
+	"github.com/pm-assist/pm-assist/internal/object"
+)
+
+// objectListTimeout bounds listing a bucket prefix; downloads are unbounded.
+const objectListTimeout = time.Minute
+
+// objectFlags carries the connect flags for object storage connectors.
+type objectFlags struct {
+	Provider  string
+	Bucket    string
+	Prefix    string
+	Glob      string
+	Endpoint  string
+	Region    string
+	Account   string
+	Format    string
+	Delimiter string
+	Encoding  string
+	CredEnv   string
+}
+
+// resolveObjectConfig asks for the object storage location and returns it
+// with the credential env var name.
+func resolveObjectConfig(flags objectFlags) (config.ObjectConfig, string, error) {
+	var cfg config.ObjectConfig
+	provider, err := resolveChoice(flags.Provider, "Storage provider", object.Providers(), object.ProviderS3, true)
+	if err != nil {
+		return cfg, "", err
+	}
+	azure := provider == object.ProviderAzure
+	bucketPrompt := "Bucket"
+	if azure {
+		bucketPrompt = "Container"
+	}
+	bucket, err := resolveString(flags.Bucket, bucketPrompt, "", true)
+	if err != nil {
+		return cfg, "", err
+	}
+	prefix, err := resolveString(flags.Prefix, "Key prefix (optional, e.g., exports/2024/)", "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	glob, err := resolveString(flags.Glob, "File glob (optional, e.g., *.csv)", "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	if _, err := object.Match(nil, "", glob); err != nil {
+		return cfg, "", err
+	}
+	endpointPrompt := "Endpoint URL (optional, e.g., http://localhost:9000 for MinIO)"
+	if azure {
+		endpointPrompt = "Endpoint URL (optional, e.g., http://127.0.0.1:10000/devstoreaccount1 for Azurite)"
+	}
+	endpoint, err := resolveString(flags.Endpoint, endpointPrompt, "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	region := ""
+	if !azure {
+		region, err = resolveString(flags.Region, "Region (optional)", "", false)
+		if err != nil {
+			return cfg, "", err
+		}
+	}
+	accountPrompt := "Access key ID (optional; uses the default AWS credential chain when empty)"
+	if azure {
+		accountPrompt = "Storage account name"
+	}
+	account, err := resolveString(flags.Account, accountPrompt, "", azure && endpoint == "")
+	if err != nil {
+		return cfg, "", err
+	}
+	credEnvPrompt := "Credential env var for the secret access key"
+	if azure {
+		credEnvPrompt = "Credential env var for the account key or SAS token (optional for public containers)"
+	}
+	credEnv, err := resolveString(flags.CredEnv, credEnvPrompt, "", !azure && account != "")
+	if err != nil {
+		return cfg, "", err
+	}
+	if credEnv != "" {
+		fmt.Println("[INFO] Credentials are never stored in config. Set the env var before connecting.")
+		fmt.Printf("[INFO] Using credential env var: %s\n", credEnv)
+	} else if !azure && account != "" {
+		return cfg, "", fmt.Errorf("credential env var is required with an access key ID")
+	}
+	format, err := resolveChoice(flags.Format, "Format", []string{"csv", "parquet", "xlsx", "json", "zip-csv", "xes"}, "csv", true)
+	if err != nil {
+		return cfg, "", err
+	}
+	delimiter, encoding := "", ""
+	if format == "csv" || format == "zip-csv" {
+		delimiter, err = resolveString(flags.Delimiter, "CSV delimiter", ",", true)
+		if err != nil {
+			return cfg, "", err
+		}
+		encoding, err = resolveString(flags.Encoding, "CSV encoding", "utf-8", true)
+		if err != nil {
+			return cfg, "", err
+		}
+	}
+	return config.ObjectConfig{
+		Provider:  provider,
+		Bucket:    bucket,
+		Prefix:    prefix,
+		Glob:      glob,
+		Endpoint:  endpoint,
+		Region:    region,
+		Account:   account,
+		Format:    format,
+		Delimiter: delimiter,
+		Encoding:  encoding,
+	}, credEnv, nil
+}
+
+// connectorStore opens the store for an object connector with the secret
+// from its credential env var.
+func connectorStore(spec config.ConnectorSpec) (object.Store, error) {
+	if spec.Object == nil {
+		return nil, fmt.Errorf("object connector %s missing object config", spec.Name)
+	}
+	secret := ""
+	if spec.Options != nil && spec.Options.CredentialEnv != "" {
+		secret = os.Getenv(spec.Options.CredentialEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("credential env var %s is not set", spec.Options.CredentialEnv)
+		}
+	}
+	return object.New(context.Background(), *spec.Object, secret)
+}
+
+// listConnectorObjects lists the connector's prefix and applies its glob.
+func listConnectorObjects(store object.Store, cfg config.ObjectConfig) ([]object.Object, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), objectListTimeout)
+	defer cancel()
+	listed, err := store.List(ctx, cfg.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	return object.Match(listed, cfg.Prefix, cfg.Glob)
+}
+
+// downloadConnectorObject picks the matching object to ingest and downloads
+// only that one into dir.
+func downloadConnectorObject(spec config.ConnectorSpec, dir string, key string) (object.Downloaded, error) {
+	store, err := connectorStore(spec)
+	if err != nil {
+		return object.Downloaded{}, err
+	}
+	matched, err := listConnectorObjects(store, *spec.Object)
+	if err != nil {
+		return object.Downloaded{}, fmt.Errorf("list %s: %w", store.URI(spec.Object.Prefix), err)
+	}
+	if len(matched) == 0 {
+		return object.Downloaded{}, fmt.Errorf("no objects under %s match %q", store.URI(spec.Object.Prefix), spec.Object.Glob)
+	}
+	chosen, err := selectConnectorObject(matched, spec.Object.Prefix, key)
+	if err != nil {
+		return object.Downloaded{}, err
+	}
+	fmt.Printf("[INFO] Downloading %s...\n", store.URI(chosen.Key))
+	downloaded, err := object.Download(context.Background(), store, []object.Object{chosen}, spec.Object.Prefix, dir)
+	if err != nil {
+		return object.Downloaded{}, err
+	}
+	item := downloaded[0]
+	check := "sha256 recorded"
+	if item.MD5Verified {
+		check = "MD5 verified"
+	}
+	fmt.Printf("  - %s (%d bytes, %s)\n", object.RelativeKey(item.Key, spec.Object.Prefix), item.Size, check)
+	return item, nil
+}
+
+// selectConnectorObject picks the object to ingest: the one named by key
+// (relative to the prefix, or its base name), the only match, or a prompt.
+func selectConnectorObject(matched []object.Object, prefix string, key string) (object.Object, error) {
+	keys := make([]string, len(matched))
+	for i, item := range matched {
+		keys[i] = object.RelativeKey(item.Key, prefix)
+	}
+	if key != "" {
+		for i, item := range matched {
+			if keys[i] == key || item.Key == key || path.Base(item.Key) == key {
+				return item, nil
+			}
+		}
+		return object.Object{}, fmt.Errorf("object %s does not match the connector (matching: %s)", key, strings.Join(keys, ", "))
+	}
+	if len(matched) == 1 {
+		return matched[0], nil
+	}
+	fmt.Printf("[INFO] %d objects match; one is downloaded and ingested per run (use --file <key> to choose).\n", len(matched))
+	choice, err := resolveChoice("", "Object to ingest", keys, keys[0], true)
+	if err != nil {
+		return object.Object{}, err
+	}
+	for i := range keys {
+		if keys[i] == choice {
+			return matched[i], nil
+		}
+	}
+	return object.Object{}, fmt.Errorf("object not found: %s", choice)
+}
+
+// objectEntries converts downloads into manifest records.
+func objectEntries(connector string, downloaded []object.Downloaded) []manifest.Object {
+	out := make([]manifest.Object, len(downloaded))
+	for i, item := range downloaded {
+		out[i] = manifest.Object{
+			Connector:   connector,
+			URI:         item.URI,
+			Path:        item.Path,
+			SizeBytes:   item.Size,
+			ETag:        item.ETag,
+			SHA256:      item.SHA256,
+			MD5Verified: item.MD5Verified,
+		}
+	}
+	return out
+}
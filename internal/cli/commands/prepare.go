@@ -7,6 +7,7 @@ import (
 
 	"github.com/pm-assist/pm-assist/internal/app"
 	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
 	"github.com/pm-assist/pm-assist/internal/logging"
 	"github.com/pm-assist/pm-assist/internal/notebook"
 	"github.com/pm-assist/pm-assist/internal/paths"
@@ -269,6 +270,20 @@ func NewPrepareCmd(global *app.GlobalFlags) *cobra.Command {
 			}
 
 			printStepProgress(3, 3, "Finalizing preparation outputs")
+			filteredLog := filepath.Join(outputPath, "stage_03_clean_filter", "filtered_log.csv")
+			// Carry the source column types forward so review parses strictly.
+			renames := xesRenames(caseCol, activityCol, timestampCol, resourceCol)
+			if err := eventlog.DeriveSchema(inputPath, cleanInput, renames); err != nil {
+				return fmt.Errorf("write schema sidecar for %s: %w", cleanInput, err)
+			}
+			if err := eventlog.DeriveSchema(cleanInput, filteredLog, renames); err != nil {
+				return fmt.Errorf("write schema sidecar for %s: %w", filteredLog, err)
+			}
+			for _, stageLog := range []string{cleanInput, filteredLog} {
+				if err := materializeStageLog(cfg, stageLog, []string{timestampCol, "time:timestamp"}); err != nil {
+					return err
+				}
+			}
 			if err := manifestManager.AddOutputs([]string{outputPath}); err != nil {
 				return err
 			}
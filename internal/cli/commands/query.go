@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/app"
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/query"
+	"github.com/pm-assist/pm-assist/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewQueryCmd returns the query command.
+func NewQueryCmd(global *app.GlobalFlags) *cobra.Command {
+	var (
+		flagInput     string
+		flagCase      string
+		flagActivity  string
+		flagTimestamp string
+		flagResource  string
+		flagFormat    string
+		flagOutput    string
+	)
+	cmd := &cobra.Command{
+		Use:   "query <SQL>",
+		Short: "Run ad-hoc SQL against the current run's event log",
+		Long: "Load the run's event log into an embedded SQLite engine and run a SQL query.\n\n" +
+			"Views:\n" +
+			"  events     event_index, case_id, activity, timestamp, resource\n" +
+			"  cases      case_id, event_count, start_time, end_time, duration_seconds, variant\n" +
+			"  variants   variant, case_count, case_share\n" +
+			"  dfg_edges  source, target, frequency\n" +
+			"The raw columns are available in the log table, joined on event_index;\n" +
+			"a source column named event_index is renamed source_event_index.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if global.JSONOutput && !cmd.Flags().Changed("format") {
+				flagFormat = "json"
+			}
+			format, err := resolveChoice(flagFormat, "Output format", []string{"table", "csv", "json"}, "table", true)
+			if err != nil {
+				return err
+			}
+			framed := format == "table" && flagOutput == ""
+			success := false
+			if framed {
+				ui.PrintCommandStart(ui.CommandFrame{
+					Title:   "pm-assist query",
+					Purpose: "Answer ad-hoc questions with SQL over the event log",
+					Writes:  []string{},
+					Asks:    []string{},
+				})
+				defer func() {
+					ui.PrintCommandEnd(ui.CommandFrame{Title: "pm-assist query"}, success)
+				}()
+			}
+			projectPath := global.ProjectPath
+			if projectPath == "" {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return err
+				}
+				projectPath = cwd
+			}
+			cfg, err := config.Load(global.ConfigPath)
+			if err != nil {
+				return err
+			}
+
+			inputPath := flagInput
+			if inputPath == "" {
+				runID := global.RunID
+				if runID == "" {
+					latest, _ := latestManifest(projectPath)
+					if latest == nil {
+						return fmt.Errorf("no runs found; pass --run-id or --input")
+					}
+					runID = latest.RunID
+				}
+				inputPath = resolveRunLog(cfg, filepath.Join(projectPath, "outputs", runID))
+				if inputPath == "" {
+					return fmt.Errorf("no event log found for run %s; run `pm-assist ingest` first", runID)
+				}
+			}
+			if _, err := os.Stat(inputPath); err != nil {
+				return formatPathError(inputPath)
+			}
+
+			reader, err := eventlog.Open(inputPath, eventlog.Options{})
+			if err != nil {
+				return err
+			}
+			headers := reader.Header()
+			reader.Close()
+			columns := resolveQueryColumns(cfg, headers, query.Columns{
+				CaseID:    flagCase,
+				Activity:  flagActivity,
+				Timestamp: flagTimestamp,
+				Resource:  flagResource,
+			})
+
+			if framed {
+				fmt.Printf("[INFO] Loading %s (case=%s, activity=%s, timestamp=%s)\n", inputPath, columns.CaseID, columns.Activity, columns.Timestamp)
+			}
+			ctx := context.Background()
+			engine, err := query.Load(ctx, inputPath, columns, eventlog.Options{})
+			if err != nil {
+				return err
+			}
+			defer engine.Close()
+
+			result, err := engine.Run(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("query failed: %w", err)
+			}
+
+			out := os.Stdout
+			if flagOutput != "" {
+				file, err := os.Create(flagOutput)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				out = file
+			}
+			switch format {
+			case "csv":
+				err = query.WriteCSV(out, result)
+			case "json":
+				err = query.WriteJSON(out, result)
+			default:
+				err = query.WriteTable(out, result)
+			}
+			if err != nil {
+				return err
+			}
+			if flagOutput != "" {
+				fmt.Fprintf(os.Stderr, "[SUCCESS] Wrote %d rows to %s\n", len(result.Rows), flagOutput)
+			}
+			success = true
+			return nil
+		},
+		Example: "  pm-assist query \"SELECT variant, case_count FROM variants ORDER BY case_count DESC LIMIT 5\"\n" +
+			"  pm-assist --non-interactive query --format csv \"SELECT * FROM dfg_edges\" > dfg.csv",
+	}
+	cmd.Flags().StringVar(&flagInput, "input", "", "Event log path (default: latest run's filtered or normalised log)")
+	cmd.Flags().StringVar(&flagCase, "case", "", "Case ID column")
+	cmd.Flags().StringVar(&flagActivity, "activity", "", "Activity column")
+	cmd.Flags().StringVar(&flagTimestamp, "timestamp", "", "Timestamp column")
+	cmd.Flags().StringVar(&flagResource, "resource", "", "Resource column")
+	cmd.Flags().StringVar(&flagFormat, "format", "table", "Output format (table|csv|json)")
+	cmd.Flags().StringVar(&flagOutput, "output", "", "Write results to a file instead of stdout")
+	return cmd
+}
+
+// resolveRunLog returns the most processed stage log available in a run folder.
+func resolveRunLog(cfg *config.Config, outputPath string) string {
+	for _, stage := range [][2]string{
+		{"stage_03_clean_filter", "filtered_log"},
+		{"stage_01_ingest_profile", "normalised_log"},
+	} {
+		candidates := []string{
+			stageLogPath(cfg, outputPath, stage[0], stage[1]),
+			filepath.Join(outputPath, stage[0], stage[1]+".csv"),
+		}
+		for _, candidate := range candidates {
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// resolveQueryColumns fills unset columns from the saved mapping when the log
+// contains them, then from well-known header names.
+func resolveQueryColumns(cfg *config.Config, headers []string, cols query.Columns) query.Columns {
+	present := map[string]bool{}
+	for _, header := range headers {
+		present[header] = true
+	}
+	pick := func(current string, mapped string, guessed string, fallback string) string {
+		if current != "" {
+			return current
+		}
+		if mapped != "" && present[mapped] {
+			return mapped
+		}
+		if guessed != "" {
+			return guessed
+		}
+		return fallback
+	}
+	var mapping config.MappingConfig
+	if cfg != nil && cfg.Mapping != nil {
+		mapping = *cfg.Mapping
+	}
+	caseGuess, activityGuess, timestampGuess := inferMapping(headers)
+	resourceGuess := ""
+	for _, header := range headers {
+		name := strings.ToLower(strings.TrimSpace(header))
+		if name == "resource" || name == "org:resource" {
+			resourceGuess = header
+			break
+		}
+	}
+	return query.Columns{
+		CaseID:    pick(cols.CaseID, mapping.CaseID, caseGuess, "case_id"),
+		Activity:  pick(cols.Activity, mapping.Activity, activityGuess, "activity"),
+		Timestamp: pick(cols.Timestamp, mapping.Timestamp, timestampGuess, "timestamp"),
+		Resource:  pick(cols.Resource, mapping.Resource, resourceGuess, ""),
+	}
+}
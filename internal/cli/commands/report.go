@@ -167,6 +167,8 @@ func NewReportCmd(global *app.GlobalFlags) *cobra.Command {
 				"manifest/config_snapshot.yaml":    filepath.Join(outputPath, "config_snapshot.yaml"),
 				"quality/qa_summary.md":            filepath.Join(outputPath, "quality", "qa_summary.md"),
 				"quality/qa_results.json":          filepath.Join(outputPath, "quality", "qa_results.json"),
+				"quality/readiness_scorecard.json": filepath.Join(outputPath, "quality", "readiness_scorecard.json"),
+				"quality/readiness_scorecard.md":   filepath.Join(outputPath, "quality", "readiness_scorecard.md"),
 			}
 			htmlCandidate := strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ".html"
 			pdfCandidate := strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ".pdf"
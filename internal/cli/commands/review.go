@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 
 	"github.com/pm-assist/pm-assist/internal/app"
 	"github.com/pm-assist/pm-assist/internal/cli/prompt"
 	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
 	"github.com/pm-assist/pm-assist/internal/logging"
 	"github.com/pm-assist/pm-assist/internal/qa"
 	"github.com/pm-assist/pm-assist/internal/ui"
@@ -28,6 +30,12 @@ func NewReviewCmd(global *app.GlobalFlags) *cobra.Command {
 		flagOrder         string
 		flagParse         string
 		flagAllowBlocking string
+		flagRules         string
+		flagExceptions    string
+		flagExceptionMax  string
+		flagMemoryBudget  string
+		flagDuplicates    string
+		flagWorkers       string
 	)
 	cmd := &cobra.Command{
 		Use:   "review",
@@ -86,7 +94,7 @@ func NewReviewCmd(global *app.GlobalFlags) *cobra.Command {
 				}
 			}()
 
-			inputPath, err := resolveString(flagInput, "Input log path", filepath.Join(outputPath, "stage_03_clean_filter", "filtered_log.csv"), true)
+			inputPath, err := resolveString(flagInput, "Input log path", stageLogPath(cfg, outputPath, "stage_03_clean_filter", "filtered_log"), true)
 			if err != nil {
 				return err
 			}
@@ -132,17 +140,110 @@ func NewReviewCmd(global *app.GlobalFlags) *cobra.Command {
 				return err
 			}
 
-			if err := manifestManager.AddInputs([]string{inputPath}); err != nil {
+			defaultRules := ""
+			if _, err := os.Stat(filepath.Join(projectPath, qa.RulePackFile)); err == nil {
+				defaultRules = filepath.Join(projectPath, qa.RulePackFile)
+			}
+			rulesPath, err := resolveString(flagRules, "QA rule pack (optional)", defaultRules, false)
+			if err != nil {
+				return err
+			}
+			var pack qa.RulePack
+			if rulesPath != "" {
+				if _, err := os.Stat(rulesPath); err != nil {
+					return formatPathError(rulesPath)
+				}
+				if pack, err = qa.LoadRulePack(rulesPath); err != nil {
+					return fmt.Errorf("load rule pack: %w", err)
+				}
+				fmt.Printf("[INFO] Loaded %d QA rules from %s\n", len(pack.Rules), rulesPath)
+			}
+
+			exportExceptions, err := resolveBool(flagExceptions, "Export offending rows of failed checks?", false)
+			if err != nil {
+				return err
+			}
+			exceptionLimit := 0
+			if exportExceptions {
+				limit, err := resolveString(flagExceptionMax, "Maximum rows per exception file", strconv.Itoa(qa.DefaultExceptionLimit), true)
+				if err != nil {
+					return err
+				}
+				if exceptionLimit, err = strconv.Atoi(limit); err != nil || exceptionLimit < 1 {
+					return fmt.Errorf("exception limit must be a positive integer")
+				}
+			}
+
+			var memoryBudget int64
+			if flagMemoryBudget != "" {
+				if memoryBudget, err = parseByteSize(flagMemoryBudget); err != nil {
+					return err
+				}
+			}
+			duplicates := qa.DuplicatesExact
+			if flagDuplicates != "" {
+				if duplicates, err = resolveChoice(flagDuplicates, "Duplicate detection", []string{qa.DuplicatesExact, qa.DuplicatesEstimate}, qa.DuplicatesExact, true); err != nil {
+					return err
+				}
+			}
+
+			workers := runtime.NumCPU()
+			if flagWorkers != "" {
+				if workers, err = strconv.Atoi(flagWorkers); err != nil || workers < 1 {
+					return fmt.Errorf("workers must be a positive integer")
+				}
+			}
+
+			inputs := []string{inputPath}
+			if rulesPath != "" {
+				inputs = append(inputs, rulesPath)
+			}
+			if err := manifestManager.AddInputs(inputs); err != nil {
 				return err
 			}
 
-			results, backlog, err := qa.RunCSV(inputPath, caseCol, activityCol, timestampCol, timestampFormat, thresholds)
+			results, backlog, err := qa.Run(inputPath, qa.Options{
+				Options:         eventlog.Options{Dialect: dialectForPath(cfg, inputPath)},
+				CaseColumn:      caseCol,
+				ActivityColumn:  activityCol,
+				TimestampColumn: timestampCol,
+				TimestampFormat: timestampFormat,
+				Thresholds:      thresholds,
+				Rules:           pack.Rules,
+				MemoryBudget:    memoryBudget,
+				SpillDir:        outputPath,
+				Duplicates:      duplicates,
+				Workers:         workers,
+				ExceptionDir:    filepath.Join(outputPath, "quality", "exceptions"),
+				ExceptionLimit:  exceptionLimit,
+			})
 			if err != nil {
 				return err
 			}
+			for _, rule := range results.Rules {
+				if rule.Error != "" {
+					fmt.Printf("[WARN] Rule %s could not run: %s\n", rule.Name, rule.Error)
+				} else if !rule.Passed {
+					fmt.Printf("[WARN] Rule %s failed (%s): %d of %d violated\n", rule.Name, rule.Severity, rule.Violations, rule.Checked)
+				}
+			}
 			if err := qa.WriteOutputs(outputPath, results, backlog); err != nil {
 				return err
 			}
+			if results.Partitions > 0 {
+				fmt.Printf("[INFO] Checked in %d partitions to stay within the memory budget\n", results.Partitions)
+			}
+			if estimate := results.DuplicateEstimate; estimate != nil {
+				fmt.Printf("[INFO] Duplicate rate %.4f is an estimate (false positive rate %.4f)\n", results.DuplicateRate, estimate.FalsePositiveRate)
+			}
+			for _, file := range results.Exceptions {
+				fmt.Printf("[INFO] Exceptions for %s: %d of %d rows written to quality/%s\n", file.Check, file.Written, file.Total, file.Path)
+			}
+			scorecard := qa.BuildScorecard(results, pack.Scorecard)
+			if err := qa.WriteScorecard(outputPath, scorecard); err != nil {
+				return err
+			}
+			fmt.Printf("[INFO] Data readiness: %.2f (%s), %d assumptions recorded\n", scorecard.Score, scorecard.Status, len(scorecard.Assumptions))
 
 			if len(results.BlockingIssues) > 0 {
 				fmt.Printf("[WARN] Blocking issues detected: %v\n", results.BlockingIssues)
@@ -198,6 +299,12 @@ func NewReviewCmd(global *app.GlobalFlags) *cobra.Command {
 	cmd.Flags().StringVar(&flagDuplicate, "duplicate-threshold", "", "Duplicate threshold")
 	cmd.Flags().StringVar(&flagOrder, "order-threshold", "", "Order violation threshold")
 	cmd.Flags().StringVar(&flagParse, "parse-threshold", "", "Timestamp parse failure threshold")
+	cmd.Flags().StringVar(&flagRules, "rules", "", "QA rule pack (YAML; defaults to qa_rules.yaml in the project)")
+	cmd.Flags().StringVar(&flagExceptions, "exceptions", "", "Export offending rows of failed checks to quality/exceptions (true|false)")
+	cmd.Flags().StringVar(&flagExceptionMax, "exception-limit", "", "Maximum rows per exception file")
+	cmd.Flags().StringVar(&flagMemoryBudget, "memory-budget", "", "Approximate memory for duplicate, order, and case checks, e.g. 2GB; larger logs spill to disk")
+	cmd.Flags().StringVar(&flagDuplicates, "duplicates", "", "Duplicate detection (exact|estimate)")
+	cmd.Flags().StringVar(&flagWorkers, "workers", "", "Goroutines decoding and checking records, sharded by case (default: number of CPUs)")
 	cmd.Flags().StringVar(&flagAllowBlocking, "allow-blocking", "", "Proceed despite blocking issues (true|false)")
 	return cmd
 }
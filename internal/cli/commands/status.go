@@ -59,7 +59,7 @@ func NewStatusCmd(global *app.GlobalFlags) *cobra.Command {
 			for _, connector := range cfg.Connectors {
 				status := "unknown"
 				if connector.Type == "file" && connector.File != nil && len(connector.File.Paths) > 0 {
-					if _, err := os.Stat(connector.File.Paths[0]); err == nil {
+					if _, err := expandPaths(connector.File.Paths); err == nil {
 						status = "ok"
 					} else {
 						status = "missing"
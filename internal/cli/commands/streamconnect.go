@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/api"
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/manifest"
+	"github.com/pm-assist/pm-assist/internal/stream"
+	"github.com/spf13/cobra"
+)
+
+// streamProbeTimeout bounds reading topic metadata and offsets; the
+// extract itself is bounded by the range.
+const streamProbeTimeout = time.Minute
+
+// streamFlags carries the connect flags for stream connectors.
+type streamFlags struct {
+	Brokers        string
+	Topic          string
+	Partitions     string
+	Format         string
+	SchemaRegistry string
+	Fields         string
+	SASL           string
+	User           string
+	TLS            string
+	CredEnv        string
+	Range          streamRangeFlags
+}
+
+// streamRangeFlags selects the messages to read; ingest flags override the
+// range saved with the connector.
+type streamRangeFlags struct {
+	StartOffset string
+	EndOffset   string
+	StartTime   string
+	EndTime     string
+	MaxMessages string
+}
+
+// resolveStreamConfig asks for the brokers, topic, range, value format, and
+// SASL settings, and returns them with the credential env var name.
+func resolveStreamConfig(flags streamFlags) (config.StreamConfig, string, error) {
+	var cfg config.StreamConfig
+	brokers, err := resolveString(flags.Brokers, "Kafka brokers (host:port, comma-separated)", "", true)
+	if err != nil {
+		return cfg, "", err
+	}
+	cfg.Brokers = splitCSV(brokers)
+	if cfg.Topic, err = resolveString(flags.Topic, "Topic", "", true); err != nil {
+		return cfg, "", err
+	}
+	partitionList, err := resolveString(flags.Partitions, "Partitions (comma-separated; empty reads all)", "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	if cfg.Partitions, err = parsePartitions(partitionList); err != nil {
+		return cfg, "", err
+	}
+	if cfg.StartOffset, err = resolveString(flags.Range.StartOffset, "Start offset (earliest|latest|number)", stream.OffsetEarliest, true); err != nil {
+		return cfg, "", err
+	}
+	if cfg.EndOffset, err = resolveString(flags.Range.EndOffset, "End offset, exclusive (latest|number)", stream.OffsetLatest, true); err != nil {
+		return cfg, "", err
+	}
+	if cfg.StartTime, err = resolveString(flags.Range.StartTime, "Start time (optional, RFC 3339; overrides the start offset)", "", false); err != nil {
+		return cfg, "", err
+	}
+	if cfg.EndTime, err = resolveString(flags.Range.EndTime, "End time (optional, RFC 3339; overrides the end offset)", "", false); err != nil {
+		return cfg, "", err
+	}
+	if cfg.MaxMessages, err = parseMaxMessages(flags.Range.MaxMessages); err != nil {
+		return cfg, "", err
+	}
+	if _, err := stream.RangeFromConfig(cfg); err != nil {
+		return cfg, "", err
+	}
+
+	if cfg.Format, err = resolveChoice(flags.Format, "Value format", stream.Formats(), stream.FormatJSON, true); err != nil {
+		return cfg, "", err
+	}
+	if cfg.Format == stream.FormatAvro {
+		if cfg.SchemaRegistry, err = resolveString(flags.SchemaRegistry, "Schema registry URL", "", true); err != nil {
+			return cfg, "", err
+		}
+	}
+	fieldList, err := resolveString(flags.Fields, "Fields as column=JSONPath, comma-separated (empty projects every top-level key)", "", false)
+	if err != nil {
+		return cfg, "", err
+	}
+	if cfg.Fields, err = parseAPIFields(fieldList); err != nil {
+		return cfg, "", err
+	}
+
+	mechanisms := []string{"none", stream.SASLPlain, stream.SASLScramSHA256, stream.SASLScramSHA512}
+	mechanism, err := resolveChoice(flags.SASL, "SASL mechanism", mechanisms, "none", true)
+	if err != nil {
+		return cfg, "", err
+	}
+	credEnv := ""
+	if mechanism != "none" {
+		cfg.SASLMechanism = mechanism
+		if cfg.User, err = resolveString(flags.User, "SASL user", "", true); err != nil {
+			return cfg, "", err
+		}
+		if credEnv, err = resolveString(flags.CredEnv, "Credential env var holding the SASL password", "", true); err != nil {
+			return cfg, "", err
+		}
+		fmt.Println("[INFO] Credentials are never stored in config. Set the env var before connecting.")
+		fmt.Printf("[INFO] Using credential env var: %s\n", credEnv)
+	}
+	if cfg.TLS, err = resolveBool(flags.TLS, "Use TLS?", cfg.SASLMechanism != ""); err != nil {
+		return cfg, "", err
+	}
+	return cfg, credEnv, nil
+}
+
+// parsePartitions reads a comma-separated partition list.
+func parsePartitions(list string) ([]int, error) {
+	var partitions []int
+	for _, item := range splitCSV(list) {
+		partition, err := strconv.Atoi(item)
+		if err != nil || partition < 0 {
+			return nil, fmt.Errorf("invalid partition: %s", item)
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions, nil
+}
+
+func parseMaxMessages(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid --max-messages: %s", value)
+	}
+	return limit, nil
+}
+
+// applyStreamRange overrides the saved range with the ingest flags. A time
+// bound replaces the offset bound on the same side.
+func applyStreamRange(cfg config.StreamConfig, flags streamRangeFlags) (config.StreamConfig, error) {
+	if flags.StartOffset != "" {
+		cfg.StartOffset, cfg.StartTime = flags.StartOffset, ""
+	}
+	if flags.EndOffset != "" {
+		cfg.EndOffset, cfg.EndTime = flags.EndOffset, ""
+	}
+	if flags.StartTime != "" {
+		cfg.StartTime = flags.StartTime
+	}
+	if flags.EndTime != "" {
+		cfg.EndTime = flags.EndTime
+	}
+	if flags.MaxMessages != "" {
+		limit, err := parseMaxMessages(flags.MaxMessages)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.MaxMessages = limit
+	}
+	return cfg, nil
+}
+
+// connectorStream opens the source for a stream connector with the SASL
+// password from its credential env var.
+func connectorStream(spec config.ConnectorSpec) (stream.Source, error) {
+	if spec.Stream == nil {
+		return nil, fmt.Errorf("stream connector %s missing stream config", spec.Name)
+	}
+	password := ""
+	if spec.Options != nil && spec.Options.CredentialEnv != "" {
+		password = os.Getenv(spec.Options.CredentialEnv)
+		if password == "" {
+			return nil, fmt.Errorf("credential env var %s is not set", spec.Options.CredentialEnv)
+		}
+	}
+	return stream.NewKafkaSource(*spec.Stream, password)
+}
+
+// describeStream returns the available offsets of each selected partition.
+func describeStream(source stream.Source, partitions []int) ([]stream.PartitionRange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), streamProbeTimeout)
+	defer cancel()
+	if len(partitions) == 0 {
+		var err error
+		if partitions, err = source.Partitions(ctx); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]stream.PartitionRange, 0, len(partitions))
+	for _, partition := range partitions {
+		first, last, err := source.Offsets(ctx, partition)
+		if err != nil {
+			return nil, fmt.Errorf("partition %d: %w", partition, err)
+		}
+		out = append(out, stream.PartitionRange{Partition: partition, Start: first, End: last})
+	}
+	return out, nil
+}
+
+// materializeConnectorStream reads the connector's range into outputPath.
+func materializeConnectorStream(spec config.ConnectorSpec, outputPath string) (stream.Result, error) {
+	cfg := *spec.Stream
+	source, err := connectorStream(spec)
+	if err != nil {
+		return stream.Result{}, err
+	}
+	defer source.Close()
+	r, err := stream.RangeFromConfig(cfg)
+	if err != nil {
+		return stream.Result{}, err
+	}
+	decoder, err := stream.NewDecoder(cfg.Format, cfg.SchemaRegistry)
+	if err != nil {
+		return stream.Result{}, err
+	}
+	project, err := api.NewProjection(cfg.Fields)
+	if err != nil {
+		return stream.Result{}, err
+	}
+	return stream.Materialize(context.Background(), source, cfg.Topic, cfg.Partitions, r, decoder, project, outputPath)
+}
+
+// streamEntries converts consumed partition ranges into manifest records.
+func streamEntries(connector string, result stream.Result) []manifest.StreamRange {
+	out := make([]manifest.StreamRange, len(result.Partitions))
+	for i, read := range result.Partitions {
+		out[i] = manifest.StreamRange{
+			Connector:   connector,
+			Topic:       result.Topic,
+			Partition:   read.Partition,
+			StartOffset: read.Start,
+			EndOffset:   read.End,
+			Messages:    read.Messages,
+		}
+	}
+	return out
+}
+
+// streamLocation is the summary line for a stream connector.
+func streamLocation(cfg config.StreamConfig) string {
+	return fmt.Sprintf("%s/%s", strings.Join(cfg.Brokers, ","), cfg.Topic)
+}
+
+// addStreamRangeFlags registers the range flags shared by connect and ingest.
+func addStreamRangeFlags(cmd *cobra.Command, flags *streamRangeFlags) {
+	cmd.Flags().StringVar(&flags.StartOffset, "start-offset", "", "First stream offset to read (earliest|latest|number)")
+	cmd.Flags().StringVar(&flags.EndOffset, "end-offset", "", "Stream offset to stop before (latest|number)")
+	cmd.Flags().StringVar(&flags.StartTime, "start-time", "", "Read stream messages from this time (RFC 3339)")
+	cmd.Flags().StringVar(&flags.EndTime, "end-time", "", "Read stream messages before this time (RFC 3339)")
+	cmd.Flags().StringVar(&flags.MaxMessages, "max-messages", "", "Stop each stream partition after this many messages")
+}
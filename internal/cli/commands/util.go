@@ -1,8 +1,8 @@
 package commands
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,6 +11,8 @@ import (
 
 	"github.com/pm-assist/pm-assist/internal/cli/prompt"
 	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
 	"github.com/pm-assist/pm-assist/internal/logging"
 	"github.com/pm-assist/pm-assist/internal/manifest"
 	"github.com/pm-assist/pm-assist/internal/paths"
@@ -43,6 +45,65 @@ func initRunManifest(runID string, outputPath string, cfg *config.Config) (*mani
 	return manager, nil
 }
 
+// stageLogPath returns the path of a stage log in the configured storage format.
+func stageLogPath(cfg *config.Config, outputPath string, stage string, name string) string {
+	format := eventlog.FormatCSV
+	if cfg != nil {
+		format = cfg.Storage.Format
+	}
+	return eventlog.StagePath(filepath.Join(outputPath, stage, name+".csv"), format)
+}
+
+// materializeStageLog writes a typed Parquet copy of a stage CSV when the
+// project stores stage logs as Parquet. Python stages keep reading the CSV.
+func materializeStageLog(cfg *config.Config, csvPath string, timestampCols []string) error {
+	if cfg == nil || !strings.EqualFold(cfg.Storage.Format, eventlog.FormatParquet) {
+		return nil
+	}
+	if _, err := os.Stat(csvPath); err != nil {
+		return nil
+	}
+	parquetPath := eventlog.StagePath(csvPath, eventlog.FormatParquet)
+	rows, err := eventlog.ConvertToParquet(csvPath, parquetPath, timestampCols, eventlog.Options{})
+	if err != nil {
+		return fmt.Errorf("write parquet stage log %s: %w", parquetPath, err)
+	}
+	logging.Info("wrote parquet stage log", map[string]any{"path": parquetPath, "rows": rows})
+	return nil
+}
+
+// xesRenames maps the mapped columns to the XES names the Python stage
+// scripts write them under.
+func xesRenames(caseCol string, activityCol string, timestampCol string, resourceCol string) map[string]string {
+	renames := map[string]string{
+		caseCol:      "case:concept:name",
+		activityCol:  "concept:name",
+		timestampCol: "time:timestamp",
+	}
+	if resourceCol != "" {
+		renames[resourceCol] = "org:resource"
+	}
+	return renames
+}
+
+// copyLocalFile copies src to dst, replacing dst if it exists.
+func copyLocalFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 func resolveString(flagValue string, question string, defaultValue string, required bool) (string, error) {
 	if flagValue != "" {
 		return flagValue, nil
@@ -220,49 +281,179 @@ func isInteractiveTerminal() bool {
 	return os.Getenv("TERM") != ""
 }
 
-func detectDelimiter(path string) string {
-	file, err := os.Open(path)
-	if err != nil {
-		return ","
-	}
-	defer file.Close()
-
-	buf := make([]byte, 4096)
-	n, _ := file.Read(buf)
-	line := string(buf[:n])
-	if idx := strings.Index(line, "\n"); idx != -1 {
-		line = line[:idx]
-	}
-	candidates := []rune{',', ';', '\t', '|'}
-	best := ','
-	bestCount := 0
-	for _, candidate := range candidates {
-		count := strings.Count(line, string(candidate))
-		if count > bestCount {
-			bestCount = count
-			best = candidate
+// fileDialect returns the CSV dialect saved on a file connector.
+func fileDialect(file config.FileConfig) csvdialect.Dialect {
+	return csvdialect.Dialect{
+		Delimiter: file.Delimiter,
+		Quote:     file.Quote,
+		Escape:    file.Escape,
+		Encoding:  file.Encoding,
+		NoHeader:  file.NoHeader,
+		Decimal:   file.Decimal,
+	}
+}
+
+// expandPaths expands glob patterns in sorted order and checks that plain
+// paths exist; files listed twice are kept once.
+func expandPaths(patterns []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path pattern %s: %w", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", pattern)
+			}
+		} else if _, err := os.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("path not accessible: %s. If using WSL, use /mnt/<drive>/... paths", pattern)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
 		}
 	}
-	return string(best)
+	return files, nil
+}
+
+// parseAliases reads column aliases written as alias=canonical pairs
+// separated by commas.
+func parseAliases(value string) (map[string]string, error) {
+	return parsePairs(value, "column alias", "alias=canonical")
 }
 
-func readCSVHeaders(path string, delimiter string) []string {
-	file, err := os.Open(path)
+// parsePairs reads comma-separated key=value pairs; what and form name
+// them in errors.
+func parsePairs(value string, what string, form string) (map[string]string, error) {
+	pairs := map[string]string{}
+	for _, pair := range splitCSV(value) {
+		key, val, ok := strings.Cut(pair, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !ok || key == "" || val == "" {
+			return nil, fmt.Errorf("invalid %s %q (use %s)", what, pair, form)
+		}
+		pairs[key] = val
+	}
+	return pairs, nil
+}
+
+// parseEscape maps the --escape flag to a csvdialect escape style.
+func parseEscape(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "doubled", "double":
+		return csvdialect.EscapeDouble, nil
+	case "backslash", `\`:
+		return csvdialect.EscapeBackslash, nil
+	}
+	return "", fmt.Errorf("invalid escape style: %s (use doubled|backslash)", value)
+}
+
+// parseByteSize reads a size such as 512MB, 2GiB, or a plain byte count.
+// Decimal and binary units are both taken as powers of 1024.
+func parseByteSize(value string) (int64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			trimmed, multiplier = strings.TrimSpace(strings.TrimSuffix(trimmed, unit.suffix)), unit.size
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid size: %s (use e.g. 512MB or 2GB)", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// dialectArgs passes a dialect to the Python ingest script; settings at
+// their defaults are left out.
+func dialectArgs(d csvdialect.Dialect) []string {
+	d = d.Normalize()
+	args := []string{"--delimiter", d.Delimiter, "--encoding", d.Encoding}
+	if d.Quote != `"` {
+		args = append(args, "--quotechar", d.Quote)
+	}
+	if d.Escape == csvdialect.EscapeBackslash {
+		args = append(args, "--escape", "backslash")
+	}
+	if d.NoHeader {
+		args = append(args, "--no-header")
+	}
+	if d.Decimal != "." {
+		args = append(args, "--decimal", d.Decimal)
+	}
+	return args
+}
+
+// quoteArgs single-quotes arguments for a notebook shell cell when they
+// hold anything but plain word characters.
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,") == "" {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return quoted
+}
+
+// dialectForPath returns the dialect of the file connector registered for
+// path, or sniffs the file when none is.
+func dialectForPath(cfg *config.Config, path string) csvdialect.Dialect {
+	if eventlog.FormatOf(path) == eventlog.FormatParquet {
+		return csvdialect.Dialect{}
+	}
+	if cfg != nil {
+		for _, connector := range cfg.Connectors {
+			if connector.Type != "file" || connector.File == nil {
+				continue
+			}
+			for _, candidate := range connector.File.Paths {
+				if samePath(candidate, path) {
+					return fileDialect(*connector.File)
+				}
+			}
+		}
+	}
+	dialect, err := csvdialect.SniffFile(path)
 	if err != nil {
-		return nil
+		return csvdialect.Dialect{}
 	}
-	defer file.Close()
+	return dialect
+}
 
-	reader := bufio.NewReader(file)
-	line, err := reader.ReadString('\n')
-	if err != nil && line == "" {
-		return nil
+// samePath reports whether path is candidate, or matches it when candidate
+// is a glob pattern.
+func samePath(candidate string, path string) bool {
+	absCandidate, errA := filepath.Abs(candidate)
+	absPath, errB := filepath.Abs(path)
+	if errA != nil || errB != nil {
+		return false
 	}
-	line = strings.TrimSpace(line)
-	if delimiter == "\t" {
-		return strings.Split(line, "\t")
+	matched, err := filepath.Match(absCandidate, absPath)
+	return absCandidate == absPath || err == nil && matched
+}
+
+// csvHeaders reads the header row of a CSV file in the dialect.
+func csvHeaders(path string, dialect csvdialect.Dialect) []string {
+	reader, err := eventlog.Open(path, eventlog.Options{Dialect: dialect})
+	if err != nil {
+		return nil
 	}
-	return strings.Split(line, delimiter)
+	defer reader.Close()
+	return reader.Header()
 }
 
 func inferMapping(headers []string) (string, string, string) {
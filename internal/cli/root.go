@@ -79,6 +79,7 @@ func init() {
 		commands.NewMineCmd(Global),
 		commands.NewReportCmd(Global),
 		commands.NewReviewCmd(Global),
+		commands.NewQueryCmd(Global),
 		commands.NewAgentCmd(Global),
 		commands.NewProfileCmd(Global),
 		commands.NewBusinessCmd(Global),
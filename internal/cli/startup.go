@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/pm-assist/pm-assist/internal/buildinfo"
 	"github.com/pm-assist/pm-assist/internal/cli/prompt"
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,6 +22,7 @@ type Config struct {
 	Business   BusinessConfig  `yaml:"business"`
 	LLM        LLMConfig       `yaml:"llm"`
 	Policy     PolicyConfig    `yaml:"policy"`
+	Storage    StorageConfig   `yaml:"storage"`
 	Connectors []ConnectorSpec `yaml:"connectors"`
 	Mapping    *MappingConfig  `yaml:"mapping,omitempty"`
 }
@@ -53,12 +55,66 @@ type PolicyConfig struct {
 	DeniedConnectors  []string `yaml:"denied_connectors,omitempty"`
 }
 
+// StorageConfig selects the on-disk format for intermediate stage logs.
+type StorageConfig struct {
+	Format string `yaml:"format"`
+}
+
 type ConnectorSpec struct {
-	Name     string       `yaml:"name"`
-	Type     string       `yaml:"type"`
-	File     *FileConfig  `yaml:"file,omitempty"`
-	Database *DBConfig    `yaml:"database,omitempty"`
-	Options  *ExtraConfig `yaml:"options,omitempty"`
+	Name     string          `yaml:"name"`
+	Type     string          `yaml:"type"`
+	File     *FileConfig     `yaml:"file,omitempty"`
+	Database *DBConfig       `yaml:"database,omitempty"`
+	Object   *ObjectConfig   `yaml:"object,omitempty"`
+	API      *APIConfig      `yaml:"api,omitempty"`
+	Stream   *StreamConfig   `yaml:"stream,omitempty"`
+	Options  *ExtraConfig    `yaml:"options,omitempty"`
+	Queries  []QueryTemplate `yaml:"queries,omitempty"`
+}
+
+// QueryTemplate is a named extraction query for a database connector.
+// Parameters are referenced as ${name} in SQL and bound as driver
+// placeholders at run time; list parameters expand to one placeholder per item.
+type QueryTemplate struct {
+	Name        string       `yaml:"name"`
+	Description string       `yaml:"description,omitempty"`
+	SQL         string       `yaml:"sql"`
+	Params      []QueryParam `yaml:"params,omitempty"`
+	// Process names the built-in process template the query was generated
+	// from, e.g. "sap-p2p".
+	Process string `yaml:"process,omitempty"`
+	// Mapping is the event log layout of the query's result, used to pre-fill
+	// ingest and map.
+	Mapping *TemplateMapping `yaml:"mapping,omitempty"`
+}
+
+// TemplateMapping names the result columns that hold each event log field.
+type TemplateMapping struct {
+	CaseID          string `yaml:"case_id"`
+	Activity        string `yaml:"activity"`
+	Timestamp       string `yaml:"timestamp"`
+	Resource        string `yaml:"resource,omitempty"`
+	TimestampFormat string `yaml:"timestamp_format,omitempty"`
+}
+
+// QueryParamTypes lists the supported query template parameter types.
+var QueryParamTypes = []string{"string", "int", "float", "bool", "date", "timestamp", "string_list", "int_list"}
+
+type QueryParam struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Default  string `yaml:"default,omitempty"`
+	Required bool   `yaml:"required,omitempty"`
+}
+
+// Query returns the named query template, if defined.
+func (c ConnectorSpec) Query(name string) (QueryTemplate, bool) {
+	for _, query := range c.Queries {
+		if query.Name == name {
+			return query, true
+		}
+	}
+	return QueryTemplate{}, false
 }
 
 type FileConfig struct {
@@ -66,9 +122,18 @@ type FileConfig struct {
 	Format    string   `yaml:"format"`
 	Delimiter string   `yaml:"delimiter,omitempty"`
 	Encoding  string   `yaml:"encoding,omitempty"`
-	Sheet     string   `yaml:"sheet,omitempty"`
-	JSONLines bool     `yaml:"json_lines,omitempty"`
-	ZipMember string   `yaml:"zip_member,omitempty"`
+	// Quote, Escape ("" for doubled quotes or a backslash), NoHeader, and
+	// Decimal complete the CSV dialect detected at connect time.
+	Quote     string `yaml:"quote,omitempty"`
+	Escape    string `yaml:"escape,omitempty"`
+	NoHeader  bool   `yaml:"no_header,omitempty"`
+	Decimal   string `yaml:"decimal,omitempty"`
+	Sheet     string `yaml:"sheet,omitempty"`
+	JSONLines bool   `yaml:"json_lines,omitempty"`
+	ZipMember string `yaml:"zip_member,omitempty"`
+	// Aliases maps alternative column names (alias -> canonical) so files
+	// exported with renamed columns line up when combined at ingest.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
 }
 
 type DBConfig struct {
@@ -79,6 +144,133 @@ type DBConfig struct {
 	Schema  string `yaml:"schema,omitempty"`
 	User    string `yaml:"user,omitempty"`
 	SSLMode string `yaml:"ssl_mode,omitempty"`
+	// Path is the database file for local drivers (sqlite, duckdb).
+	Path string `yaml:"path,omitempty"`
+	// Extract tunes how the connector's query is pulled; nil runs it as a
+	// single query under the default timeout.
+	Extract *ExtractConfig `yaml:"extract,omitempty"`
+}
+
+// ObjectConfig locates files in object storage. Provider is "s3" (AWS or any
+// S3-compatible service such as MinIO) or "azure" (Azure Blob Storage). The
+// secret (S3 secret access key, Azure account key or SAS token) is read from
+// the connector's credential env var.
+type ObjectConfig struct {
+	Provider string `yaml:"provider"`
+	// Bucket is the S3 bucket or Azure container.
+	Bucket string `yaml:"bucket"`
+	Prefix string `yaml:"prefix,omitempty"`
+	// Glob filters keys below Prefix, e.g. "*.csv" or "2024/*/events.csv";
+	// a pattern without "/" matches the base name only.
+	Glob string `yaml:"glob,omitempty"`
+	// Endpoint overrides the service URL for stand-ins such as MinIO or
+	// Azurite; S3 endpoints use path-style addressing.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Region   string `yaml:"region,omitempty"`
+	// Account is the S3 access key ID or the Azure storage account name.
+	Account   string `yaml:"account,omitempty"`
+	Format    string `yaml:"format"`
+	Delimiter string `yaml:"delimiter,omitempty"`
+	Encoding  string `yaml:"encoding,omitempty"`
+}
+
+// APIConfig describes a paginated JSON HTTP source (REST or OData). Records
+// are read from each page at the Records JSONPath and projected into CSV
+// columns by Fields. The bearer token or OAuth2 client secret is read from
+// the connector's credential env var.
+type APIConfig struct {
+	URL string `yaml:"url"`
+	// Records locates the record array in each page, e.g. $.value (OData),
+	// $.result (ServiceNow) or $.issues (Jira); empty means the page is the
+	// array.
+	Records string `yaml:"records,omitempty"`
+	// Fields project each record into columns; empty projects every
+	// top-level key of the first record.
+	Fields     []APIField        `yaml:"fields,omitempty"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	Pagination APIPagination     `yaml:"pagination,omitempty"`
+	Auth       APIAuth           `yaml:"auth,omitempty"`
+	// MaxPages stops the extract after this many pages; 0 reads them all.
+	MaxPages int `yaml:"max_pages,omitempty"`
+}
+
+// APIField maps a CSV column to a JSONPath within a record.
+type APIField struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// APIPagination selects how the next page is requested. Type is "none",
+// "next_link" (a URL in the response, such as @odata.nextLink), "offset"
+// (offset and limit query parameters), or "cursor" (a token from the response
+// sent back as a query parameter).
+type APIPagination struct {
+	Type        string `yaml:"type,omitempty"`
+	NextLink    string `yaml:"next_link,omitempty"`
+	OffsetParam string `yaml:"offset_param,omitempty"`
+	LimitParam  string `yaml:"limit_param,omitempty"`
+	PageSize    int    `yaml:"page_size,omitempty"`
+	CursorParam string `yaml:"cursor_param,omitempty"`
+	CursorPath  string `yaml:"cursor_path,omitempty"`
+}
+
+// APIAuth is "none", "bearer" (a static token), or "oauth2" (the client
+// credentials grant against TokenURL).
+type APIAuth struct {
+	Type     string `yaml:"type,omitempty"`
+	TokenURL string `yaml:"token_url,omitempty"`
+	ClientID string `yaml:"client_id,omitempty"`
+	Scope    string `yaml:"scope,omitempty"`
+}
+
+// StreamConfig reads a Kafka topic. The topic is consumed without a consumer
+// group, so no offsets are committed and other consumers are unaffected. The
+// SASL password is read from the connector's credential env var.
+type StreamConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// Partitions limits the read to these partitions; empty reads all.
+	Partitions []int `yaml:"partitions,omitempty"`
+	// StartOffset is "earliest" (the default), "latest", or an absolute
+	// offset; EndOffset is "latest" (the high watermark when the read
+	// starts, the default) or an absolute, exclusive offset.
+	StartOffset string `yaml:"start_offset,omitempty"`
+	EndOffset   string `yaml:"end_offset,omitempty"`
+	// StartTime and EndTime (RFC 3339) select by message timestamp and take
+	// precedence over the offsets.
+	StartTime string `yaml:"start_time,omitempty"`
+	EndTime   string `yaml:"end_time,omitempty"`
+	// Format is "json" or "avro"; Avro values use the Confluent wire format
+	// with schemas fetched from SchemaRegistry.
+	Format         string     `yaml:"format"`
+	SchemaRegistry string     `yaml:"schema_registry,omitempty"`
+	Fields         []APIField `yaml:"fields,omitempty"`
+	// SASLMechanism is "plain", "scram-sha-256", or "scram-sha-512".
+	SASLMechanism string `yaml:"sasl_mechanism,omitempty"`
+	User          string `yaml:"user,omitempty"`
+	TLS           bool   `yaml:"tls,omitempty"`
+	// MaxMessages caps the messages read per partition; 0 reads the range.
+	MaxMessages int64 `yaml:"max_messages,omitempty"`
+}
+
+// ExtractConfig controls timeouts, keyset chunking, and date partitioning for
+// database extraction.
+type ExtractConfig struct {
+	// Timeout bounds each query (one per chunk when chunking) as a Go
+	// duration such as "45m"; "0" disables the limit.
+	Timeout string `yaml:"timeout,omitempty"`
+	// ChunkColumn is a unique, ordered key used for keyset pagination.
+	ChunkColumn string `yaml:"chunk_column,omitempty"`
+	// ChunkType is "id" or "timestamp" and types the key bound between chunks.
+	ChunkType string `yaml:"chunk_type,omitempty"`
+	ChunkSize int    `yaml:"chunk_size,omitempty"`
+	// PartitionColumn is filtered into [start, end) ranges of PartitionBy
+	// (day|month|year) that extract in parallel.
+	PartitionColumn string `yaml:"partition_column,omitempty"`
+	PartitionBy     string `yaml:"partition_by,omitempty"`
+	PartitionStart  string `yaml:"partition_start,omitempty"`
+	PartitionEnd    string `yaml:"partition_end,omitempty"`
+	Parallel        int    `yaml:"parallel,omitempty"`
 }
 
 type ExtraConfig struct {
@@ -94,6 +286,17 @@ type MappingConfig struct {
 	Resource        string `yaml:"resource,omitempty"`
 	TimestampFormat string `yaml:"timestamp_format,omitempty"`
 	Timezone        string `yaml:"timezone,omitempty"`
+	// Layout is "wide" when the source has one row per case and a timestamp
+	// column per activity; map unpivots SourcePath into InputPath.
+	Layout      string           `yaml:"layout,omitempty"`
+	SourcePath  string           `yaml:"source_path,omitempty"`
+	WideColumns []WideColumnSpec `yaml:"wide_columns,omitempty"`
+}
+
+// WideColumnSpec maps a timestamp column of a wide table to its activity.
+type WideColumnSpec struct {
+	Column   string `yaml:"column"`
+	Activity string `yaml:"activity"`
 }
 
 // Load returns a Config with the resolved path if a config exists.
@@ -142,6 +345,11 @@ func (c *Config) Validate() error {
 	if c.Version != CurrentSchemaVersion {
 		return fmt.Errorf("unsupported config schema version: %d", c.Version)
 	}
+	switch strings.ToLower(c.Storage.Format) {
+	case "", "csv", "parquet":
+	default:
+		return fmt.Errorf("unsupported storage format: %s", c.Storage.Format)
+	}
 	for _, connector := range c.Connectors {
 		if connector.Type == "" {
 			return errors.New("connector type is required")
@@ -152,6 +360,23 @@ func (c *Config) Validate() error {
 		if connector.Type == "file" && connector.File == nil {
 			return errors.New("file connector missing file config")
 		}
+		if connector.Type == "object" && connector.Object == nil {
+			return errors.New("object connector missing object config")
+		}
+		if connector.Type == "api" && connector.API == nil {
+			return errors.New("api connector missing api config")
+		}
+		if connector.Type == "stream" && connector.Stream == nil {
+			return errors.New("stream connector missing stream config")
+		}
+		if err := validateQueries(connector); err != nil {
+			return err
+		}
+		if connector.Database != nil && connector.Database.Extract != nil {
+			if err := connector.Database.Extract.Validate(); err != nil {
+				return fmt.Errorf("connector %s: %w", connector.Name, err)
+			}
+		}
 	}
 	if c.Mapping != nil {
 		if c.Mapping.InputPath == "" {
@@ -164,6 +389,75 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func validateQueries(connector ConnectorSpec) error {
+	seen := map[string]bool{}
+	for _, query := range connector.Queries {
+		if query.Name == "" {
+			return fmt.Errorf("connector %s: query name is required", connector.Name)
+		}
+		if seen[query.Name] {
+			return fmt.Errorf("connector %s: duplicate query name: %s", connector.Name, query.Name)
+		}
+		seen[query.Name] = true
+		if strings.TrimSpace(query.SQL) == "" {
+			return fmt.Errorf("connector %s: query %s has no sql", connector.Name, query.Name)
+		}
+		params := map[string]bool{}
+		for _, param := range query.Params {
+			if param.Name == "" {
+				return fmt.Errorf("connector %s: query %s has a parameter without a name", connector.Name, query.Name)
+			}
+			if params[param.Name] {
+				return fmt.Errorf("connector %s: query %s: duplicate parameter: %s", connector.Name, query.Name, param.Name)
+			}
+			params[param.Name] = true
+			if !isQueryParamType(param.Type) {
+				return fmt.Errorf("connector %s: query %s: unsupported type %q for parameter %s", connector.Name, query.Name, param.Type, param.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that chunking and partitioning settings are complete.
+func (e ExtractConfig) Validate() error {
+	if e.Timeout != "" {
+		if _, err := time.ParseDuration(e.Timeout); err != nil {
+			return fmt.Errorf("invalid extract timeout %q: %w", e.Timeout, err)
+		}
+	}
+	if e.ChunkSize < 0 || e.Parallel < 0 {
+		return errors.New("extract chunk_size and parallel must not be negative")
+	}
+	if e.ChunkSize > 0 && e.ChunkColumn == "" {
+		return errors.New("extract chunk_size requires chunk_column")
+	}
+	switch e.ChunkType {
+	case "", "id", "timestamp":
+	default:
+		return fmt.Errorf("unsupported extract chunk_type: %s (use id or timestamp)", e.ChunkType)
+	}
+	switch e.PartitionBy {
+	case "":
+	case "day", "month", "year":
+		if e.PartitionColumn == "" || e.PartitionStart == "" || e.PartitionEnd == "" {
+			return errors.New("extract partition_by requires partition_column, partition_start, and partition_end")
+		}
+	default:
+		return fmt.Errorf("unsupported extract partition_by: %s (use day, month, or year)", e.PartitionBy)
+	}
+	return nil
+}
+
+func isQueryParamType(value string) bool {
+	for _, candidate := range QueryParamTypes {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) applyDefaults() {
 	if c.Version == 0 {
 		c.Version = CurrentSchemaVersion
@@ -177,6 +471,9 @@ func (c *Config) applyDefaults() {
 	if c.LLM.Provider == "" {
 		c.LLM.Provider = "none"
 	}
+	if c.Storage.Format == "" {
+		c.Storage.Format = "csv"
+	}
 	if c.Policy.LLMEnabled == nil {
 		defaultLLM := strings.ToLower(c.LLM.Provider) != "none"
 		c.Policy.LLMEnabled = &defaultLLM
@@ -0,0 +1,142 @@
+package csvdialect
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// CanChunk reports whether ReadChunk can split this dialect: the delimiter,
+// quote, and escape must be single bytes.
+func (r *Reader) CanChunk() bool {
+	return r.comma < utf8.RuneSelf && r.quote < utf8.RuneSelf && r.escape < utf8.RuneSelf
+}
+
+// ReadChunk returns the UTF-8 text of the next whole records, at least size
+// bytes of it unless the input ends first, or io.EOF after the last record.
+// It only follows quoting to find where records end, which is much cheaper
+// than decoding them, so chunks can be handed to ParseChunk in parallel.
+// Records are split as Read would split them.
+func (r *Reader) ReadChunk(size int) ([]byte, error) {
+	if !r.CanChunk() {
+		return nil, errors.New("the dialect's delimiter, quote, or escape is not a single byte")
+	}
+	var chunk []byte
+	if r.pending != nil {
+		chunk = r.encode(r.pending)
+		r.pending = nil
+	}
+	split := splitter{empty: true}
+	for len(chunk) < size || split.quoted {
+		start := len(chunk)
+		line, err := r.in.ReadSlice('\n')
+		for errors.Is(err, bufio.ErrBufferFull) {
+			chunk = append(chunk, line...)
+			line, err = r.in.ReadSlice('\n')
+		}
+		chunk = append(chunk, line...)
+		split.scan(r, chunk[start:])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(chunk) == 0 {
+		return nil, io.EOF
+	}
+	return chunk, nil
+}
+
+// ParseChunk decodes the records of a chunk from ReadChunk. It is safe for
+// concurrent use.
+func (r *Reader) ParseChunk(chunk []byte) ([][]string, error) {
+	parser := &Reader{in: bufio.NewReader(bytes.NewReader(chunk)), comma: r.comma, quote: r.quote, escape: r.escape}
+	if r.std != nil {
+		parser.std = newStdReader(parser.in, r.comma)
+	}
+	var records [][]string
+	for {
+		record, err := parser.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// encode writes a record back as dialect text, every field quoted.
+func (r *Reader) encode(record []string) []byte {
+	quote := string(r.quote)
+	escaped := quote + quote
+	if r.escape != 0 {
+		escaped = string(r.escape) + quote
+	}
+	var out []byte
+	for i, field := range record {
+		if i > 0 {
+			out = utf8.AppendRune(out, r.comma)
+		}
+		if r.escape != 0 {
+			field = strings.ReplaceAll(field, string(r.escape), string(r.escape)+string(r.escape))
+		}
+		out = append(out, quote...)
+		out = append(out, strings.ReplaceAll(field, quote, escaped)...)
+		out = append(out, quote...)
+	}
+	return append(out, '\n')
+}
+
+// splitter tracks whether the text so far ends inside a quoted field,
+// following the same quoting rules as Read.
+type splitter struct {
+	quoted bool
+	// empty is set while the current field has no content yet, where a
+	// quote opens a quoted field.
+	empty bool
+}
+
+// scan follows one line, newline included.
+func (s *splitter) scan(r *Reader, line []byte) {
+	comma, quote, escape := byte(r.comma), byte(r.quote), byte(r.escape)
+	if !s.quoted && bytes.IndexByte(line, quote) < 0 {
+		// Nothing to follow; the line ends a record.
+		s.empty = true
+		return
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.quoted:
+			switch {
+			case escape != 0 && c == escape:
+				i++
+				s.empty = false
+			case c == quote && escape == 0 && i+1 < len(line) && line[i+1] == quote:
+				i++
+				s.empty = false
+			case c == quote && r.std != nil && i+1 < len(line) && line[i+1] != comma && line[i+1] != '\n' && line[i+1] != '\r':
+				// encoding/csv reads a stray quote inside a quoted field
+				// as text (LazyQuotes).
+				s.empty = false
+			case c == quote:
+				s.quoted = false
+			default:
+				s.empty = false
+			}
+		case c == comma || c == '\n':
+			s.empty = true
+		case c == quote && s.empty:
+			s.quoted = true
+		default:
+			s.empty = false
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package csvdialect
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	utf16 := []byte{0xff, 0xfe}
+	for _, r := range "case\tamount\nA\t1.5\nB\t2.25\n" {
+		utf16 = append(utf16, byte(r), byte(r>>8))
+	}
+	cases := []struct {
+		name string
+		data []byte
+		want Dialect
+	}{
+		{
+			"semicolon windows-1252 decimal comma",
+			[]byte("case;activity;amount;timestamp\n1;Caf\xe9 bestellt;12,50;05.01.2024 10:00\n2;\"Lieferung; Teil 1\";1.234,75;06.01.2024 11:00\n"),
+			Dialect{Delimiter: ";", Quote: `"`, Encoding: "cp1252", Decimal: ","},
+		},
+		{
+			"tab with BOM and no header",
+			append([]byte{0xef, 0xbb, 0xbf}, []byte("1001\t2024-01-05\t3.5\n1002\t2024-01-06\t4.5\n1003\t2024-01-07\t5.5\n")...),
+			Dialect{Delimiter: "\t", Quote: `"`, Encoding: "utf-8-sig", NoHeader: true, Decimal: "."},
+		},
+		{
+			"pipe with single quotes and backslash escapes",
+			[]byte("id|note|status\n1|'it\\'s done'|open\n2|'a|b'|closed\n3|'x'|open\n"),
+			Dialect{Delimiter: "|", Quote: "'", Escape: EscapeBackslash, Encoding: "utf-8", Decimal: "."},
+		},
+		{
+			"comma with doubled quotes",
+			[]byte("id,note,when\n1,\"say \"\"hi\"\"\",2024-01-01\n2,\"\",2024-01-02\n"),
+			Dialect{Delimiter: ",", Quote: `"`, Encoding: "utf-8", Decimal: "."},
+		},
+		{"utf-16 with BOM", utf16, Dialect{Delimiter: "\t", Quote: `"`, Encoding: "utf-16", Decimal: "."}},
+	}
+	for _, tc := range cases {
+		got, err := Sniff(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	input := "7|'it\\'s\nmulti-line'|\n\n8|plain|\r\n"
+	reader, err := NewReader(strings.NewReader(input), Dialect{Delimiter: "|", Quote: "'", Escape: EscapeBackslash, NoHeader: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := reader.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	want := [][]string{{"7", "it's\nmulti-line", ""}, {"8", "plain", ""}}
+	if !reflect.DeepEqual(header, []string{"column_1", "column_2", "column_3"}) || !reflect.DeepEqual(records, want) {
+		t.Fatalf("header %q, records %q", header, records)
+	}
+	if _, err := NewReader(strings.NewReader(""), Dialect{Delimiter: ";;"}); err == nil {
+		t.Fatal("expected a multi-character delimiter to fail")
+	}
+}
+
+func TestChunksMatchRead(t *testing.T) {
+	cases := []struct {
+		input   string
+		dialect Dialect
+	}{
+		{"id,note\n1,\"a,\"\"b\"\"\nc\"\n\n2,x\"y\r\n3,\"lazy \"quote\" here\",z\n4,\"\"\n", Dialect{}},
+		{"7|'it\\'s\nmulti-line'|\n\n8|plain|\r\n9|'a\\\\'|'|'\n", Dialect{Delimiter: "|", Quote: "'", Escape: EscapeBackslash, NoHeader: true}},
+		{"a;b\n'x'';y';'';''\n'z\n';w", Dialect{Delimiter: ";", Quote: "'"}},
+		{strings.Repeat("k,\"v\nv\"\n", 2000), Dialect{NoHeader: true}},
+	}
+	for _, tc := range cases {
+		reader, _ := NewReader(strings.NewReader(tc.input), tc.dialect)
+		header, _ := reader.Header()
+		var want [][]string
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, record)
+		}
+		for _, size := range []int{1, 16, 1 << 20} {
+			chunked, _ := NewReader(strings.NewReader(tc.input), tc.dialect)
+			chunkHeader, _ := chunked.Header()
+			var got [][]string
+			for {
+				chunk, err := chunked.ReadChunk(size)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				records, err := chunked.ParseChunk(chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, records...)
+			}
+			if !reflect.DeepEqual(chunkHeader, header) || !reflect.DeepEqual(got, want) {
+				t.Fatalf("%q in chunks of %d:\n got %q\nwant %q", tc.input[:min(len(tc.input), 40)], size, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package csvdialect describes, detects, and reads the variants of delimited
+// text that source systems export: the delimiter, quote and escape style,
+// encoding and byte order mark, header presence, and decimal separator.
+package csvdialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Escape styles.
+const (
+	// EscapeDouble writes a quote inside a quoted field as two quotes.
+	EscapeDouble = ""
+	// EscapeBackslash writes it as a backslash and the quote.
+	EscapeBackslash = `\`
+)
+
+// Dialect describes how a delimited file is written. The zero value is
+// RFC 4180 CSV in UTF-8 with a header row.
+type Dialect struct {
+	Delimiter string
+	Quote     string
+	Escape    string
+	// Encoding is a Python codec name; "utf-8-sig" marks a UTF-8 byte order
+	// mark and "utf-16" one of either byte order.
+	Encoding string
+	// NoHeader means the first row is data; columns are named column_1,
+	// column_2, and so on.
+	NoHeader bool
+	// Decimal is the decimal separator of numeric fields, "." or ",".
+	Decimal string
+}
+
+// Normalize fills defaults for unset fields.
+func (d Dialect) Normalize() Dialect {
+	if d.Delimiter == "" {
+		d.Delimiter = ","
+	}
+	if d.Delimiter == `\t` {
+		d.Delimiter = "\t"
+	}
+	if d.Quote == "" {
+		d.Quote = `"`
+	}
+	if d.Encoding == "" {
+		d.Encoding = "utf-8"
+	}
+	if d.Decimal == "" {
+		d.Decimal = "."
+	}
+	return d
+}
+
+// Validate checks that the separators are single characters and distinct.
+func (d Dialect) Validate() error {
+	d = d.Normalize()
+	for _, field := range []struct{ name, value string }{
+		{"delimiter", d.Delimiter},
+		{"quote", d.Quote},
+		{"decimal", d.Decimal},
+	} {
+		if len([]rune(field.value)) != 1 {
+			return fmt.Errorf("%s must be a single character: %q", field.name, field.value)
+		}
+	}
+	if d.Escape != EscapeDouble && d.Escape != EscapeBackslash {
+		return fmt.Errorf("escape must be empty (doubled quotes) or a backslash: %q", d.Escape)
+	}
+	if d.Delimiter == d.Quote {
+		return fmt.Errorf("delimiter and quote must differ")
+	}
+	if _, err := lookupEncoding(d.Encoding); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String summarises the dialect for CLI output.
+func (d Dialect) String() string {
+	d = d.Normalize()
+	escape := "doubled quotes"
+	if d.Escape == EscapeBackslash {
+		escape = "backslash escapes"
+	}
+	header := "header row"
+	if d.NoHeader {
+		header = "no header row"
+	}
+	return strings.Join([]string{
+		"delimiter " + describeChar(d.Delimiter),
+		"quote " + describeChar(d.Quote),
+		escape,
+		"encoding " + d.Encoding,
+		header,
+		"decimal " + describeChar(d.Decimal),
+	}, ", ")
+}
+
+func describeChar(value string) string {
+	if value == "\t" {
+		return "tab"
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+// ColumnName is the generated name of the 0-based column i in a file
+// without a header row.
+func ColumnName(i int) string {
+	return fmt.Sprintf("column_%d", i+1)
+}
@@ -0,0 +1,61 @@
+package csvdialect
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// encodingAliases maps Python codec spellings that the IANA and WHATWG
+// registries do not know.
+var encodingAliases = map[string]string{
+	"latin-1":   "iso-8859-1",
+	"latin_1":   "iso-8859-1",
+	"utf_8":     "utf-8",
+	"utf8":      "utf-8",
+	"utf_16":    "utf-16",
+	"utf16":     "utf-16",
+	"utf-16-le": "utf-16le",
+	"utf_16_le": "utf-16le",
+	"utf-16-be": "utf-16be",
+	"utf_16_be": "utf-16be",
+	"utf-8-sig": "utf-8",
+	"utf_8_sig": "utf-8",
+}
+
+// Decode returns a reader that transcodes r from the named encoding to
+// UTF-8. A leading byte order mark is dropped; "utf-16" without one is read
+// as little-endian, like Python's codec on common platforms.
+func Decode(r io.Reader, name string) (io.Reader, error) {
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewReader(r, unicode.BOMOverride(enc.NewDecoder())), nil
+}
+
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := encodingAliases[key]; ok {
+		key = alias
+	}
+	switch key {
+	case "", "utf-8":
+		return unicode.UTF8, nil
+	case "utf-16":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	}
+	if enc, err := ianaindex.IANA.Encoding(key); err == nil && enc != nil {
+		return enc, nil
+	}
+	if enc, err := htmlindex.Get(key); err == nil {
+		return enc, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding: %s", name)
+}
@@ -0,0 +1,183 @@
+package csvdialect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Reader reads records in a dialect. Unlike encoding/csv it accepts any
+// quote character and backslash escapes; like it, blank lines are skipped
+// and records may have differing field counts. Double-quoted dialects are
+// read with encoding/csv.
+type Reader struct {
+	std      *csv.Reader
+	in       *bufio.Reader
+	comma    rune
+	quote    rune
+	escape   rune
+	noHeader bool
+	pending  []string
+	line     int
+}
+
+// NewReader transcodes r from the dialect's encoding and reads it as
+// delimited records.
+func NewReader(r io.Reader, d Dialect) (*Reader, error) {
+	d = d.Normalize()
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	var in *bufio.Reader
+	if enc, _ := lookupEncoding(d.Encoding); enc == unicode.UTF8 {
+		// Only the byte order mark needs dropping.
+		in = bufio.NewReader(r)
+		if bom, _ := in.Peek(3); bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+			in.Discard(3)
+		}
+	} else {
+		decoded, err := Decode(r, d.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		in = bufio.NewReader(decoded)
+	}
+	reader := &Reader{
+		in:       in,
+		comma:    []rune(d.Delimiter)[0],
+		quote:    []rune(d.Quote)[0],
+		noHeader: d.NoHeader,
+	}
+	if d.Escape == EscapeBackslash {
+		reader.escape = '\\'
+	}
+	if reader.quote == '"' && reader.escape == 0 {
+		reader.std = newStdReader(in, reader.comma)
+	}
+	return reader, nil
+}
+
+func newStdReader(in io.Reader, comma rune) *csv.Reader {
+	std := csv.NewReader(in)
+	std.Comma = comma
+	std.FieldsPerRecord = -1
+	std.LazyQuotes = true
+	return std
+}
+
+// Header reads the header row. Without one, it names the columns of the
+// first record, which is then returned by the next Read.
+func (r *Reader) Header() ([]string, error) {
+	record, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	if !r.noHeader {
+		return record, nil
+	}
+	header := make([]string, len(record))
+	for i := range record {
+		header[i] = ColumnName(i)
+	}
+	r.pending = record
+	return header, nil
+}
+
+// Read returns the next record, or io.EOF at the end of the input.
+func (r *Reader) Read() ([]string, error) {
+	if r.pending != nil {
+		record := r.pending
+		r.pending = nil
+		return record, nil
+	}
+	if r.std != nil {
+		return r.std.Read()
+	}
+	for {
+		record, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 1 && record[0] == "" {
+			continue
+		}
+		return record, nil
+	}
+}
+
+func (r *Reader) readRecord() ([]string, error) {
+	var (
+		record []string
+		field  strings.Builder
+		quoted bool
+		read   bool
+	)
+	r.line++
+	start := r.line
+	for {
+		c, _, err := r.in.ReadRune()
+		if errors.Is(err, io.EOF) {
+			if quoted {
+				return nil, fmt.Errorf("line %d: unterminated quoted field", start)
+			}
+			if !read {
+				return nil, io.EOF
+			}
+			return append(record, field.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		read = true
+		switch {
+		case quoted:
+			switch {
+			case r.escape != 0 && c == r.escape:
+				next, _, err := r.in.ReadRune()
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unterminated quoted field", start)
+				}
+				field.WriteRune(next)
+			case c == r.quote:
+				next, _, err := r.in.ReadRune()
+				if err == nil && next == r.quote && r.escape == 0 {
+					field.WriteRune(c)
+					continue
+				}
+				if err == nil {
+					r.in.UnreadRune()
+				}
+				quoted = false
+			default:
+				if c == '\n' {
+					r.line++
+				}
+				field.WriteRune(c)
+			}
+		case c == r.comma:
+			record = append(record, field.String())
+			field.Reset()
+		case c == '\n':
+			return append(record, field.String()), nil
+		case c == '\r':
+			next, _, err := r.in.ReadRune()
+			if err == nil && next == '\n' {
+				return append(record, field.String()), nil
+			}
+			if err == nil {
+				r.in.UnreadRune()
+			}
+			field.WriteRune(c)
+		case c == r.quote && field.Len() == 0:
+			quoted = true
+		default:
+			field.WriteRune(c)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package csvdialect
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// sampleBytes bounds how much of a file Sniff reads.
+	sampleBytes = 64 << 10
+	// sampleRecords bounds the records parsed per candidate dialect.
+	sampleRecords = 200
+)
+
+// delimiterCandidates are tried in order; earlier ones win ties.
+var delimiterCandidates = []string{",", ";", "\t", "|"}
+
+var (
+	numberPattern       = regexp.MustCompile(`^[-+]?(\d+|\d{1,3}([.,]\d{3})+)([.,]\d+)?$`)
+	datePattern         = regexp.MustCompile(`^\d{1,4}[-/.]\d{1,2}[-/.]\d{1,4}`)
+	commaDecimalPattern = regexp.MustCompile(`^[-+]?(\d+|\d{1,3}(\.\d{3})+),\d+$`)
+	dotDecimalPattern   = regexp.MustCompile(`^[-+]?(\d+|\d{1,3}(,\d{3})+)\.\d+$`)
+)
+
+// SniffFile detects the dialect of the file at path from its first 64 KiB.
+func SniffFile(path string) (Dialect, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Dialect{}, err
+	}
+	defer file.Close()
+	return Sniff(file)
+}
+
+// Sniff detects the dialect from the start of r: the encoding from a byte
+// order mark or the byte patterns, then the quote character, the delimiter
+// that splits the sampled records most consistently, the escape style, the
+// header row, and the decimal separator. An empty input yields the defaults.
+func Sniff(r io.Reader) (Dialect, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, sampleBytes+1))
+	if err != nil {
+		return Dialect{}, err
+	}
+	truncated := len(raw) > sampleBytes
+	if truncated {
+		raw = raw[:sampleBytes]
+	}
+	d := Dialect{Encoding: detectEncoding(raw, truncated)}
+	decoded, err := Decode(bytes.NewReader(raw), d.Encoding)
+	if err != nil {
+		return Dialect{}, err
+	}
+	textBytes, err := io.ReadAll(decoded)
+	if err != nil {
+		return Dialect{}, err
+	}
+	text := string(textBytes)
+	if truncated {
+		// Drop the partial last line.
+		if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+			text = text[:i+1]
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return d.Normalize(), nil
+	}
+
+	d.Quote = detectQuote(text)
+	d.Delimiter = detectDelimiter(text, d.Quote)
+	d.Escape = detectEscape(text, d.Quote, d.Delimiter)
+	records := sampleText(text, d)
+	d.NoHeader = !hasHeader(records)
+	d.Decimal = detectDecimal(records, d.NoHeader)
+	return d.Normalize(), nil
+}
+
+// detectEncoding names the encoding in Python's spelling.
+func detectEncoding(raw []byte, truncated bool) string {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8-sig"
+	case bytes.HasPrefix(raw, []byte{0xff, 0xfe}), bytes.HasPrefix(raw, []byte{0xfe, 0xff}):
+		return "utf-16"
+	}
+	// ASCII text in UTF-16 has a zero byte in every other position.
+	head := raw[:min(len(raw), 1024)]
+	evenZeros, oddZeros := 0, 0
+	for i, b := range head {
+		if b == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	if half := len(head) / 2; half > 0 {
+		if oddZeros*10 > half*3 && evenZeros*10 < half {
+			return "utf-16-le"
+		}
+		if evenZeros*10 > half*3 && oddZeros*10 < half {
+			return "utf-16-be"
+		}
+	}
+	if truncated {
+		// The sample may end inside a multi-byte character.
+		for cut := 0; cut < utf8.UTFMax && len(raw) > cut; cut++ {
+			if utf8.Valid(raw[:len(raw)-cut]) {
+				return "utf-8"
+			}
+		}
+	} else if utf8.Valid(raw) {
+		return "utf-8"
+	}
+	return "cp1252"
+}
+
+// detectQuote prefers the double quote unless single quotes are the ones
+// opening fields.
+func detectQuote(text string) string {
+	opens := map[byte]int{}
+	atStart := true
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if atStart && (c == '"' || c == '\'') {
+			opens[c]++
+		}
+		switch c {
+		case '\n', ',', ';', '\t', '|':
+			atStart = true
+		case ' ':
+		default:
+			atStart = false
+		}
+	}
+	if opens['\''] > opens['"'] {
+		return "'"
+	}
+	return `"`
+}
+
+// detectDelimiter picks the candidate whose most common field count (above
+// one) covers the largest share of records, then the larger field count.
+func detectDelimiter(text string, quote string) string {
+	best, bestShare, bestFields := ",", 0.0, 1
+	for _, candidate := range delimiterCandidates {
+		records := sampleText(text, Dialect{Delimiter: candidate, Quote: quote})
+		if len(records) == 0 {
+			continue
+		}
+		counts := map[int]int{}
+		for _, record := range records {
+			counts[len(record)]++
+		}
+		fields, frequency := 1, 0
+		for n, count := range counts {
+			if n > 1 && (count > frequency || count == frequency && n > fields) {
+				fields, frequency = n, count
+			}
+		}
+		if fields == 1 {
+			continue
+		}
+		share := float64(frequency) / float64(len(records))
+		if share > bestShare+1e-9 || share > bestShare-1e-9 && fields > bestFields {
+			best, bestShare, bestFields = candidate, share, fields
+		}
+	}
+	return best
+}
+
+// detectEscape reports backslash escapes when a backslash-quote pair sits
+// inside a field and no doubled quote does. A pair at a field start is an
+// empty field, and one at a field end may close a path such as "C:\dir\".
+func detectEscape(text string, quote string, delimiter string) string {
+	backslashed := 0
+	for _, after := range strings.Split(text, `\`+quote)[1:] {
+		if after != "" && !strings.HasPrefix(after, delimiter) && after[0] != '\n' && after[0] != '\r' {
+			backslashed++
+		}
+	}
+	doubled := 0
+	pair := quote + quote
+	for rest, offset := text, 0; ; {
+		i := strings.Index(rest, pair)
+		if i < 0 {
+			break
+		}
+		at := offset + i
+		if at > 0 && text[at-1] != '\n' && !strings.HasSuffix(text[:at], delimiter) {
+			doubled++
+		}
+		rest, offset = rest[i+len(pair):], at+len(pair)
+	}
+	if backslashed > 0 && doubled == 0 {
+		return EscapeBackslash
+	}
+	return EscapeDouble
+}
+
+// sampleText parses up to sampleRecords records; a parse error ends the
+// sample early.
+func sampleText(text string, d Dialect) [][]string {
+	reader, err := NewReader(strings.NewReader(text), Dialect{Delimiter: d.Delimiter, Quote: d.Quote, Escape: d.Escape})
+	if err != nil {
+		return nil
+	}
+	var records [][]string
+	for len(records) < sampleRecords {
+		record, err := reader.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && len(records) == 0 {
+				return nil
+			}
+			break
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// hasHeader compares the first record with the rest. A column whose values
+// are numbers or dates votes for a header when the first cell is not one,
+// and against it when the first cell is one too. A header is assumed unless
+// the votes are against it.
+func hasHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return true
+	}
+	first, rows := records[0], records[1:min(len(records), 51)]
+	votes := 0
+	for col, cell := range first {
+		typed, filled := 0, 0
+		for _, row := range rows {
+			if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+				continue
+			}
+			filled++
+			if isTyped(row[col]) {
+				typed++
+			}
+		}
+		if filled == 0 || typed*10 < filled*9 {
+			continue
+		}
+		if isTyped(cell) {
+			votes--
+		} else {
+			votes++
+		}
+	}
+	return votes >= 0
+}
+
+func isTyped(value string) bool {
+	value = strings.TrimSpace(value)
+	return numberPattern.MatchString(value) || datePattern.MatchString(value)
+}
+
+// detectDecimal counts fields that read as decimals with a comma or a dot
+// separator; "1,234" alone is ambiguous and not counted.
+func detectDecimal(records [][]string, noHeader bool) string {
+	if !noHeader && len(records) > 0 {
+		records = records[1:]
+	}
+	comma, dot := 0, 0
+	for _, record := range records {
+		for _, field := range record {
+			field = strings.TrimSpace(field)
+			if commaDecimalPattern.MatchString(field) && !thousandsOnly(field, ',') {
+				comma++
+			} else if dotDecimalPattern.MatchString(field) && !thousandsOnly(field, '.') {
+				dot++
+			}
+		}
+	}
+	if comma > dot {
+		return ","
+	}
+	return "."
+}
+
+// thousandsOnly reports whether sep is the only separator and is followed
+// by exactly three digits, as in "1,234" or "1.234".
+func thousandsOnly(field string, sep byte) bool {
+	other := byte('.')
+	if sep == '.' {
+		other = ','
+	}
+	i := strings.LastIndexByte(field, sep)
+	return strings.IndexByte(field, other) < 0 && strings.Count(field, string(sep)) == 1 && len(field)-i-1 == 3
+}
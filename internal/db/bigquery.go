@@ -2,40 +2,86 @@ package db
 
 import (
 	"context"
-	"encoding/csv"
 	"fmt"
+	"math/big"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
-func bigQueryClient(projectID string, credentialsPath string) (*bigquery.Client, error) {
-	if projectID == "" {
-		return nil, fmt.Errorf("bigquery project ID is required")
+func init() {
+	Register(bigQueryDriver{})
+}
+
+// bigQueryDriver talks to BigQuery through its client library. Its DSN is
+// bigquery://<project>?credentials=<path>; without credentials the client
+// falls back to application default credentials.
+type bigQueryDriver struct{}
+
+func (bigQueryDriver) Info() Info {
+	return Info{
+		Name:           "bigquery",
+		Label:          "BigQuery",
+		Dialect:        sqlguard.DialectBigQuery,
+		SecretOptional: true,
+		Prompts: Prompts{
+			Host:           "Project ID",
+			DBName:         "Dataset (optional)",
+			DBNameOptional: true,
+			User:           "Service account credentials JSON path (optional)",
+		},
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+}
 
+// BuildDSN reads the project from the host field and the service account
+// credentials path from the user field, falling back to the secret.
+func (bigQueryDriver) BuildDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("bigquery project ID is required")
+	}
+	credentialsPath := cfg.User
+	if credentialsPath == "" {
+		credentialsPath = secret
+	}
+	dsn := url.URL{Scheme: "bigquery", Host: cfg.Host}
 	if credentialsPath != "" {
-		return bigquery.NewClient(ctx, projectID, option.WithCredentialsFile(credentialsPath))
+		dsn.RawQuery = url.Values{"credentials": {credentialsPath}}.Encode()
 	}
-	return bigquery.NewClient(ctx, projectID, option.WithScopes(bigquery.Scope))
+	return dsn.String(), nil
 }
 
-// TestBigQueryReadOnly validates connectivity by listing datasets.
-func TestBigQueryReadOnly(projectID string, credentialsPath string) error {
-	client, err := bigQueryClient(projectID, credentialsPath)
+func (bigQueryDriver) Placeholder(int) string {
+	return "?"
+}
+
+func bigQueryClient(ctx context.Context, dsn string) (*bigquery.Client, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.Scheme != "bigquery" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid bigquery DSN")
+	}
+	if credentialsPath := parsed.Query().Get("credentials"); credentialsPath != "" {
+		return bigquery.NewClient(ctx, parsed.Host, option.WithCredentialsFile(credentialsPath))
+	}
+	return bigquery.NewClient(ctx, parsed.Host, option.WithScopes(bigquery.Scope))
+}
+
+// TestReadOnly validates connectivity by listing datasets.
+func (bigQueryDriver) TestReadOnly(ctx context.Context, dsn string) error {
+	client, err := bigQueryClient(ctx, dsn)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	it := client.Datasets(ctx)
 	_, err = it.Next()
 	if err == iterator.Done {
@@ -44,17 +90,14 @@ func TestBigQueryReadOnly(projectID string, credentialsPath string) error {
 	return err
 }
 
-// ListSchemasBigQuery returns dataset IDs for the project.
-func ListSchemasBigQuery(projectID string, credentialsPath string) ([]string, error) {
-	client, err := bigQueryClient(projectID, credentialsPath)
+// ListSchemas returns dataset IDs for the project.
+func (bigQueryDriver) ListSchemas(ctx context.Context, dsn string) ([]string, error) {
+	client, err := bigQueryClient(ctx, dsn)
 	if err != nil {
 		return nil, err
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	out := []string{}
 	it := client.Datasets(ctx)
 	for {
@@ -70,20 +113,17 @@ func ListSchemasBigQuery(projectID string, credentialsPath string) ([]string, er
 	return out, nil
 }
 
-// ListTablesBigQuery returns table IDs for a dataset.
-func ListTablesBigQuery(projectID string, datasetID string, credentialsPath string) ([]string, error) {
+// ListTables returns table IDs for a dataset.
+func (bigQueryDriver) ListTables(ctx context.Context, dsn string, datasetID string) ([]string, error) {
 	if datasetID == "" {
 		return nil, fmt.Errorf("bigquery dataset ID is required")
 	}
-	client, err := bigQueryClient(projectID, credentialsPath)
+	client, err := bigQueryClient(ctx, dsn)
 	if err != nil {
 		return nil, err
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	out := []string{}
 	it := client.Dataset(datasetID).Tables(ctx)
 	for {
@@ -99,24 +139,97 @@ func ListTablesBigQuery(projectID string, datasetID string, credentialsPath stri
 	return out, nil
 }
 
-// ExtractBigQueryToCSV runs a query and writes the result to CSV.
-func ExtractBigQueryToCSV(projectID string, credentialsPath string, query string, outputPath string) (int64, error) {
+// ListColumns returns the top-level fields of a table schema.
+func (bigQueryDriver) ListColumns(ctx context.Context, dsn string, datasetID string, tableID string) ([]Column, error) {
+	client, err := bigQueryClient(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	meta, err := client.Dataset(datasetID).Table(tableID).Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Column, 0, len(meta.Schema))
+	for _, field := range meta.Schema {
+		out = append(out, Column{Name: field.Name, Type: string(field.Type), Nullable: !field.Required})
+	}
+	return out, nil
+}
+
+// DescribeTable reads the schema and row count from table metadata and
+// samples rows through the table data API, which does not bill a query.
+func (d bigQueryDriver) DescribeTable(ctx context.Context, dsn string, datasetID string, tableID string, sampleRows int) (TableInfo, error) {
+	info := TableInfo{Schema: datasetID, Table: tableID, RowEstimate: -1}
+	client, err := bigQueryClient(ctx, dsn)
+	if err != nil {
+		return info, err
+	}
+	defer client.Close()
+
+	table := client.Dataset(datasetID).Table(tableID)
+	meta, err := table.Metadata(ctx)
+	if err != nil {
+		return info, err
+	}
+	info.RowEstimate = int64(meta.NumRows)
+	samples := map[string][]string{}
+	if sampleRows > 0 {
+		it := table.Read(ctx)
+		for i := 0; i < sampleRows; i++ {
+			var row []bigquery.Value
+			err := it.Next(&row)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return info, err
+			}
+			for j, value := range row {
+				if j < len(meta.Schema) {
+					name := meta.Schema[j].Name
+					samples[name] = appendSample(samples[name], value)
+				}
+			}
+		}
+	}
+	for _, field := range meta.Schema {
+		info.Columns = append(info.Columns, ColumnProfile{
+			Column:  Column{Name: field.Name, Type: string(field.Type), Nullable: !field.Required},
+			Samples: samples[field.Name],
+		})
+	}
+	return info, nil
+}
+
+func (bigQueryDriver) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// Extract runs a query and writes the result to CSV with a schema sidecar.
+// Args are bound to positional (?) parameters.
+func (d bigQueryDriver) Extract(ctx context.Context, dsn string, query string, outputPath string, args ...any) (int64, error) {
 	if query == "" {
 		return 0, fmt.Errorf("query is required")
 	}
 	if outputPath == "" {
 		return 0, fmt.Errorf("output path is required")
 	}
-	client, err := bigQueryClient(projectID, credentialsPath)
+	if err := sqlguard.Check(d.Info().Dialect, query); err != nil {
+		return 0, err
+	}
+	client, err := bigQueryClient(ctx, dsn)
 	if err != nil {
 		return 0, err
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	job, err := client.Query(query).Run(ctx)
+	q := client.Query(query)
+	for _, arg := range args {
+		q.Parameters = append(q.Parameters, bigquery.QueryParameter{Value: arg})
+	}
+	job, err := q.Run(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -132,10 +245,21 @@ func ExtractBigQueryToCSV(projectID string, credentialsPath string, query string
 	if err != nil {
 		return 0, err
 	}
-	schema := it.Schema
-	headers := make([]string, len(schema))
-	for i, field := range schema {
+	headers := make([]string, len(it.Schema))
+	kinds := make([]string, len(it.Schema))
+	schema := eventlog.Schema{Source: d.Info().Name}
+	for i, field := range it.Schema {
 		headers[i] = field.Name
+		kinds[i] = logicalType(string(field.Type))
+		nullable := !field.Required
+		schema.Columns = append(schema.Columns, eventlog.SchemaColumn{
+			Name:       field.Name,
+			SourceType: string(field.Type),
+			Type:       kinds[i],
+			Nullable:   &nullable,
+			Precision:  field.Precision,
+			Scale:      field.Scale,
+		})
 	}
 
 	file, err := os.Create(outputPath)
@@ -144,12 +268,14 @@ func ExtractBigQueryToCSV(projectID string, credentialsPath string, query string
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	if err := writer.Write(headers); err != nil {
+	writer := newRecordWriter(file)
+	if err := writer.Write(headers, nil); err != nil {
 		return 0, err
 	}
 
 	var rowCount int64
+	record := make([]string, len(headers))
+	nulls := make([]bool, len(headers))
 	for {
 		var row []bigquery.Value
 		err := it.Next(&row)
@@ -159,22 +285,36 @@ func ExtractBigQueryToCSV(projectID string, credentialsPath string, query string
 		if err != nil {
 			return rowCount, err
 		}
-		record := make([]string, len(row))
 		for i, value := range row {
-			if value == nil {
-				record[i] = ""
-			} else {
-				record[i] = fmt.Sprint(value)
-			}
+			record[i], nulls[i] = formatBigQueryValue(value, it.Schema[i].Type, kinds[i])
 		}
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(record, nulls); err != nil {
 			return rowCount, err
 		}
 		rowCount++
 	}
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	if err := writer.Flush(); err != nil {
 		return rowCount, err
 	}
-	return rowCount, nil
+	return rowCount, eventlog.WriteSchema(outputPath, schema)
+}
+
+// formatBigQueryValue handles the civil and big.Rat values the client library
+// returns for DATETIME, DATE, TIME, and NUMERIC columns.
+func formatBigQueryValue(value bigquery.Value, fieldType bigquery.FieldType, kind string) (string, bool) {
+	switch v := value.(type) {
+	case civil.DateTime:
+		// DATETIME has no zone; it is written as UTC.
+		return v.In(time.UTC).Format(time.RFC3339Nano), false
+	case civil.Date:
+		return v.String(), false
+	case civil.Time:
+		return v.String(), false
+	case *big.Rat:
+		if fieldType == bigquery.BigNumericFieldType {
+			return bigquery.BigNumericString(v), false
+		}
+		return bigquery.NumericString(v), false
+	}
+	return formatValue(value, kind)
 }
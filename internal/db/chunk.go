@@ -0,0 +1,96 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+)
+
+// Partition is a half-open [From, To) range of a partition column.
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// Partitions splits [start, end) at day, month, or year boundaries. Each range
+// is named by the period it falls in.
+func Partitions(by string, start time.Time, end time.Time) ([]Partition, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("partition start %s must be before end %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	var (
+		floor  func(time.Time) time.Time
+		step   func(time.Time) time.Time
+		layout string
+	)
+	switch by {
+	case "day":
+		floor = func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()) }
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		layout = "2006-01-02"
+	case "month":
+		floor = func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()) }
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		layout = "2006-01"
+	case "year":
+		floor = func(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()) }
+		step = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+		layout = "2006"
+	default:
+		return nil, fmt.Errorf("unsupported partition granularity: %s", by)
+	}
+	var out []Partition
+	for from := start; from.Before(end); {
+		to := step(floor(from))
+		if to.After(end) {
+			to = end
+		}
+		out = append(out, Partition{Name: from.Format(layout), From: from, To: to})
+		from = to
+	}
+	return out, nil
+}
+
+// PartitionQuery wraps a user query so only rows with from <= column < to
+// are returned. The bounds are bound as parameters n and n+1.
+func PartitionQuery(driver string, query string, column string, n int) (string, error) {
+	if !identPattern.MatchString(column) {
+		return "", fmt.Errorf("invalid partition column: %q", column)
+	}
+	from, err := Placeholder(driver, n)
+	if err != nil {
+		return "", err
+	}
+	to, err := Placeholder(driver, n+1)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SELECT * FROM %s pm_part WHERE %s >= %s AND %s < %s", subquery(query), column, from, column, to), nil
+}
+
+// ChunkQuery wraps a user query for keyset pagination on column: rows are
+// ordered by column and at most size are returned. When after is true the
+// previous chunk's last key is bound as parameter n.
+func ChunkQuery(driver string, query string, column string, after bool, n int, size int) (string, error) {
+	if !identPattern.MatchString(column) {
+		return "", fmt.Errorf("invalid chunk column: %q", column)
+	}
+	impl, err := Lookup(driver)
+	if err != nil {
+		return "", err
+	}
+	inner := subquery(query)
+	where := ""
+	if after {
+		where = fmt.Sprintf(" WHERE %s > %s", column, impl.Placeholder(n))
+	}
+	switch impl.Info().Dialect {
+	case sqlguard.DialectTSQL:
+		return fmt.Sprintf("SELECT TOP %d * FROM %s pm_chunk%s ORDER BY %s", size, inner, where, column), nil
+	case sqlguard.DialectOracle:
+		return fmt.Sprintf("SELECT * FROM %s pm_chunk%s ORDER BY %s FETCH FIRST %d ROWS ONLY", inner, where, column, size), nil
+	}
+	return fmt.Sprintf("SELECT * FROM %s pm_chunk%s ORDER BY %s LIMIT %d", inner, where, column, size), nil
+}
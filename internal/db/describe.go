@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// DefaultSampleRows is the number of rows DescribeTable samples by default.
+const DefaultSampleRows = 5
+
+// TableInfo is a column-level view of one table in the source catalog.
+type TableInfo struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// RowEstimate comes from catalog statistics and may be stale; -1 when
+	// the database does not expose one.
+	RowEstimate int64           `json:"row_estimate"`
+	Columns     []ColumnProfile `json:"columns"`
+}
+
+// ColumnProfile is a catalog column with a few sampled values.
+type ColumnProfile struct {
+	Column
+	Samples []string `json:"samples,omitempty"`
+}
+
+func (d *sqlDriver) DescribeTable(ctx context.Context, dsn string, schema string, table string, sampleRows int) (TableInfo, error) {
+	info := TableInfo{Schema: schema, Table: table, RowEstimate: -1}
+	columns, err := d.ListColumns(ctx, dsn, schema, table)
+	if err != nil {
+		return info, err
+	}
+	if len(columns) == 0 {
+		return info, fmt.Errorf("table not found or has no visible columns: %s.%s", schema, table)
+	}
+
+	db, err := sql.Open(d.sqlName, dsn)
+	if err != nil {
+		return info, err
+	}
+	defer db.Close()
+
+	if d.estimateQuery != "" {
+		var estimate sql.NullInt64
+		if err := db.QueryRowContext(ctx, d.estimateQuery, schema, table).Scan(&estimate); err == nil && estimate.Valid {
+			info.RowEstimate = estimate.Int64
+		}
+	}
+
+	samples := map[string][]string{}
+	if sampleRows > 0 {
+		rows, err := db.QueryContext(ctx, d.sampleQuery(d.QuoteIdent(schema)+"."+d.QuoteIdent(table), sampleRows))
+		if err != nil {
+			return info, err
+		}
+		defer rows.Close()
+		names, err := rows.Columns()
+		if err != nil {
+			return info, err
+		}
+		values := make([]any, len(names))
+		ptrs := make([]any, len(names))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		for rows.Next() {
+			if err := rows.Scan(ptrs...); err != nil {
+				return info, err
+			}
+			for i, value := range values {
+				samples[names[i]] = appendSample(samples[names[i]], value)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return info, err
+		}
+	}
+	for _, column := range columns {
+		info.Columns = append(info.Columns, ColumnProfile{Column: column, Samples: samples[column.Name]})
+	}
+	return info, nil
+}
+
+// appendSample adds a distinct, non-null, display-trimmed value.
+func appendSample(samples []string, value any) []string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return samples
+	case []byte:
+		text = string(v)
+	default:
+		text = fmt.Sprint(v)
+		for _, format := range valueFormatters {
+			if formatted, ok := format(v); ok {
+				text = formatted
+				break
+			}
+		}
+	}
+	if runes := []rune(text); len(runes) > 40 {
+		text = string(runes[:37]) + "..."
+	}
+	for _, existing := range samples {
+		if existing == text {
+			return samples
+		}
+	}
+	return append(samples, text)
+}
+
+// SelectQuery builds a SELECT of the given columns from schema.table with
+// identifiers quoted for the driver.
+func SelectQuery(driver Driver, schema string, table string, columns []string) string {
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = driver.QuoteIdent(column)
+	}
+	target := driver.QuoteIdent(table)
+	if schema != "" {
+		target = driver.QuoteIdent(schema) + "." + target
+	}
+	return fmt.Sprintf("SELECT %s FROM %s", strings.Join(parts, ", "), target)
+}
+
+func (d *sqlDriver) QuoteIdent(name string) string {
+	switch d.quote {
+	case '`':
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case '[':
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return quoteANSI(name)
+	}
+}
+
+func quoteANSI(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func limitSample(target string, n int) string {
+	return fmt.Sprintf("SELECT * FROM %s LIMIT %d", target, n)
+}
+
+func fetchFirstSample(target string, n int) string {
+	return fmt.Sprintf("SELECT * FROM %s FETCH FIRST %d ROWS ONLY", target, n)
+}
+
+func topSample(target string, n int) string {
+	return fmt.Sprintf("SELECT TOP %d * FROM %s", n, target)
+}
+
+func (c coded) DescribeTable(ctx context.Context, dsn string, schema string, table string, sampleRows int) (TableInfo, error) {
+	info, err := c.Driver.DescribeTable(ctx, dsn, schema, table, sampleRows)
+	return info, c.wrap("table description", CodeCatalogFailed, err)
+}
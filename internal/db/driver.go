@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+)
+
+const (
+	// CheckTimeout bounds connection tests and catalog queries.
+	CheckTimeout = 10 * time.Second
+	// ExtractTimeout bounds a single extraction query, or each chunk of a
+	// chunked extract, unless the connector's extract config overrides it.
+	ExtractTimeout = 30 * time.Minute
+)
+
+// Error codes attached to driver failures.
+const (
+	CodeUnsupportedDriver = "DB_UNSUPPORTED_DRIVER"
+	CodeInvalidConfig     = "DB_INVALID_CONFIG"
+	CodeConnectionFailed  = "DB_CONNECTION_FAILED"
+	CodeCatalogFailed     = "DB_CATALOG_FAILED"
+	CodeExtractFailed     = "DB_EXTRACT_FAILED"
+	CodeTimeout           = "DB_TIMEOUT"
+)
+
+// Info describes a driver's identity and capabilities.
+type Info struct {
+	// Name is the DBConfig.Driver key, e.g. "postgres".
+	Name string
+	// Label is the display name, e.g. "SQL Server".
+	Label string
+	// Dialect selects the sqlguard rules applied before extraction.
+	Dialect string
+	// ReadOnlyTx is true when queries run inside a read-only transaction.
+	ReadOnlyTx bool
+	// SecretOptional is true when ambient credentials may replace the
+	// credential env var.
+	SecretOptional bool
+	// DefaultPort is used when the connector config leaves the port unset;
+	// zero means the driver does not connect to a host port.
+	DefaultPort int
+	// Local is true for file-backed databases opened from DBConfig.Path. They
+	// need no host or credentials and are allowed under offline_only.
+	Local bool
+	// Unavailable explains why this binary cannot use the driver, such as a
+	// connector left out of the build; it is empty when the driver works.
+	Unavailable string
+	// Prompts words the connect prompts where the driver reads a connection
+	// field differently; empty labels keep the defaults.
+	Prompts Prompts
+}
+
+// Prompts labels the connection fields asked for by connect.
+type Prompts struct {
+	Host   string
+	DBName string
+	// DBNameOptional lets the database name be left empty.
+	DBNameOptional bool
+	User           string
+}
+
+// ConnectPrompts returns the driver's prompt labels with defaults filled in.
+func (i Info) ConnectPrompts() Prompts {
+	prompts := i.Prompts
+	if prompts.Host == "" {
+		prompts.Host = "Host"
+	}
+	if prompts.DBName == "" {
+		prompts.DBName = "Database name"
+	}
+	if prompts.User == "" {
+		prompts.User = "Username (optional)"
+	}
+	return prompts
+}
+
+// Column describes a column in the source catalog.
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// Driver is implemented once per database connector type.
+type Driver interface {
+	Info() Info
+	// BuildDSN builds a connection string from connector config and the secret
+	// read from the connector's credential env var.
+	BuildDSN(cfg config.DBConfig, secret string) (string, error)
+	TestReadOnly(ctx context.Context, dsn string) error
+	ListSchemas(ctx context.Context, dsn string) ([]string, error)
+	ListTables(ctx context.Context, dsn string, schema string) ([]string, error)
+	ListColumns(ctx context.Context, dsn string, schema string, table string) ([]Column, error)
+	// DescribeTable returns columns with a row-count estimate and up to
+	// sampleRows rows of sample values.
+	DescribeTable(ctx context.Context, dsn string, schema string, table string, sampleRows int) (TableInfo, error)
+	// Extract runs a read-only query and writes the rows to a CSV file, with
+	// types recorded in an eventlog schema sidecar. Timestamps are RFC 3339,
+	// decimals exact, and NULL distinct from the empty string. Args are bound
+	// to the query's placeholders.
+	Extract(ctx context.Context, dsn string, query string, outputPath string, args ...any) (int64, error)
+	// Placeholder returns the bind placeholder for the nth (1-based) parameter.
+	Placeholder(n int) string
+	// QuoteIdent quotes a schema, table, or column name.
+	QuoteIdent(name string) string
+}
+
+// Error is a driver failure tagged with a stable code.
+type Error struct {
+	Code   string
+	Driver string
+	Op     string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s failed [%s]: %v", e.Driver, e.Op, e.Code, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Driver{}
+)
+
+// Register makes a driver available under its Info().Name. It is meant to be
+// called from init functions.
+func Register(driver Driver) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[driver.Info().Name] = driver
+}
+
+// Lookup returns the driver registered for a DBConfig.Driver value.
+func Lookup(name string) (Driver, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	registryMu.RLock()
+	driver, ok := registry[key]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, &Error{Code: CodeUnsupportedDriver, Driver: name, Op: "lookup", Err: fmt.Errorf("supported drivers: %s", strings.Join(Drivers(), ", "))}
+	}
+	return coded{driver}, nil
+}
+
+// Drivers returns the registered driver names in sorted order.
+func Drivers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LocalDrivers returns the sorted names of file-backed drivers.
+func LocalDrivers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for name, driver := range registry {
+		if driver.Info().Local {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Placeholder returns the bind placeholder for the nth (1-based) query
+// parameter of a registered driver.
+func Placeholder(driver string, n int) (string, error) {
+	impl, err := Lookup(driver)
+	if err != nil {
+		return "", err
+	}
+	return impl.Placeholder(n), nil
+}
+
+// coded wraps a driver so every failure carries an error code.
+type coded struct {
+	Driver
+}
+
+func (c coded) wrap(op string, code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var violation *sqlguard.Violation
+	if errors.As(err, &violation) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		code = CodeTimeout
+	}
+	return &Error{Code: code, Driver: c.Info().Name, Op: op, Err: err}
+}
+
+func (c coded) BuildDSN(cfg config.DBConfig, secret string) (string, error) {
+	dsn, err := c.Driver.BuildDSN(cfg, secret)
+	return dsn, c.wrap("configuration", CodeInvalidConfig, err)
+}
+
+func (c coded) TestReadOnly(ctx context.Context, dsn string) error {
+	return c.wrap("read-only check", CodeConnectionFailed, c.Driver.TestReadOnly(ctx, dsn))
+}
+
+func (c coded) ListSchemas(ctx context.Context, dsn string) ([]string, error) {
+	out, err := c.Driver.ListSchemas(ctx, dsn)
+	return out, c.wrap("schema listing", CodeCatalogFailed, err)
+}
+
+func (c coded) ListTables(ctx context.Context, dsn string, schema string) ([]string, error) {
+	out, err := c.Driver.ListTables(ctx, dsn, schema)
+	return out, c.wrap("table listing", CodeCatalogFailed, err)
+}
+
+func (c coded) ListColumns(ctx context.Context, dsn string, schema string, table string) ([]Column, error) {
+	out, err := c.Driver.ListColumns(ctx, dsn, schema, table)
+	return out, c.wrap("column listing", CodeCatalogFailed, err)
+}
+
+func (c coded) Extract(ctx context.Context, dsn string, query string, outputPath string, args ...any) (int64, error) {
+	rows, err := c.Driver.Extract(ctx, dsn, query, outputPath, args...)
+	return rows, c.wrap("extract", CodeExtractFailed, err)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+)
+
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:           "duckdb",
+			Label:          "DuckDB",
+			Dialect:        sqlguard.DialectDuckDB,
+			SecretOptional: true,
+			Local:          true,
+			Unavailable:    duckdbUnavailable,
+		},
+		sqlName:       "duckdb",
+		dsn:           duckdbDSN,
+		placeholder:   dollarPlaceholder,
+		schemasQuery:  "SELECT DISTINCT schema_name FROM information_schema.schemata WHERE schema_name NOT IN ('information_schema', 'pg_catalog') ORDER BY schema_name",
+		tablesQuery:   "SELECT table_name FROM information_schema.tables WHERE table_catalog = current_database() AND table_schema = $1 ORDER BY table_name",
+		columnsQuery:  "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_catalog = current_database() AND table_schema = $1 AND table_name = $2 ORDER BY ordinal_position",
+		estimateQuery: "SELECT estimated_size FROM duckdb_tables() WHERE database_name = current_database() AND schema_name = $1 AND table_name = $2",
+		sampleQuery:   limitSample,
+		quote:         '"',
+	})
+}
+
+// duckdbDSN opens the file with access_mode=read_only; DuckDB has no
+// read-only transactions, so the open mode is the write barrier. Read-only
+// does not stop reads of other files or URLs, so external access and
+// extension autoloading are switched off and the settings locked, keeping
+// queries inside the file and off the network under offline_only.
+func duckdbDSN(cfg config.DBConfig, _ string) (string, error) {
+	if duckdbUnavailable != "" {
+		return "", errors.New(duckdbUnavailable)
+	}
+	path, err := localDatabasePath(cfg)
+	if err != nil {
+		return "", err
+	}
+	options := url.Values{
+		"access_mode":                  {"read_only"},
+		"enable_external_access":       {"false"},
+		"autoload_known_extensions":    {"false"},
+		"autoinstall_known_extensions": {"false"},
+		"lock_configuration":           {"true"},
+	}
+	return path + "?" + options.Encode(), nil
+}
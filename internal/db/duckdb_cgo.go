@@ -0,0 +1,26 @@
+//go:build duckdb
+
+package db
+
+import (
+	"math/big"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// duckdbUnavailable is empty: the cgo DuckDB driver is linked in.
+const duckdbUnavailable = ""
+
+func init() {
+	valueFormatters = append(valueFormatters, formatDuckDBValue)
+}
+
+// formatDuckDBValue writes DECIMAL values exactly at their declared scale.
+func formatDuckDBValue(value any) (string, bool) {
+	decimal, ok := value.(duckdb.Decimal)
+	if !ok || decimal.Value == nil {
+		return "", false
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimal.Scale)), nil)
+	return new(big.Rat).SetFrac(decimal.Value, scale).FloatString(int(decimal.Scale)), true
+}
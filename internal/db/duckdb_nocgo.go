@@ -0,0 +1,8 @@
+//go:build !duckdb
+
+package db
+
+// duckdbUnavailable explains that the cgo DuckDB driver is not linked in.
+// Release builds cross-compile without cgo, so DuckDB is opt-in via the
+// duckdb tag.
+const duckdbUnavailable = "this pm-assist binary was built without DuckDB support, which needs cgo; build one that includes it with CGO_ENABLED=1 go build -tags duckdb ./cmd/pm-assist"
@@ -3,47 +3,81 @@ package db
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"fmt"
 	"os"
-	"time"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
 )
 
-// ExtractQueryToCSV runs a query and writes results to CSV.
-func ExtractQueryToCSV(driver string, dsn string, query string, outputPath string) (int64, error) {
+// extractQueryToCSV runs a query and writes results to CSV with a schema
+// sidecar. Optional args are bound to the query's placeholders. The query must
+// pass the read-only guard and runs inside a read-only transaction where the
+// driver supports one.
+func extractQueryToCSV(ctx context.Context, d *sqlDriver, dsn string, query string, outputPath string, args ...any) (int64, error) {
+	info := d.info
 	if query == "" {
 		return 0, fmt.Errorf("query is required")
 	}
 	if outputPath == "" {
 		return 0, fmt.Errorf("output path is required")
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	if err := sqlguard.Check(info.Dialect, query); err != nil {
+		return 0, err
+	}
 
-	db, err := sql.Open(driver, dsn)
+	db, err := sql.Open(d.sqlName, dsn)
 	if err != nil {
 		return 0, err
 	}
 	defer db.Close()
 
-	rows, err := db.QueryContext(ctx, query)
-	if err != nil {
-		return 0, err
+	var rows *sql.Rows
+	if info.ReadOnlyTx {
+		tx, err := d.beginReadOnly(ctx, db)
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Rollback()
+		rows, err = tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		rows, err = db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
 	}
 	defer rows.Close()
 
-	columns, err := rows.Columns()
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return 0, err
 	}
+	columns := make([]string, len(columnTypes))
+	kinds := make([]string, len(columnTypes))
+	schema := eventlog.Schema{Source: info.Name}
+	for i, ct := range columnTypes {
+		columns[i] = ct.Name()
+		kinds[i] = logicalType(ct.DatabaseTypeName())
+		column := eventlog.SchemaColumn{Name: ct.Name(), SourceType: ct.DatabaseTypeName(), Type: kinds[i]}
+		if nullable, ok := ct.Nullable(); ok {
+			column.Nullable = &nullable
+		}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			column.Precision, column.Scale = precision, scale
+		}
+		schema.Columns = append(schema.Columns, column)
+	}
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return 0, err
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	if err := writer.Write(columns); err != nil {
+	writer := newRecordWriter(file)
+	if err := writer.Write(columns, nil); err != nil {
 		return 0, err
 	}
 
@@ -54,29 +88,25 @@ func ExtractQueryToCSV(driver string, dsn string, query string, outputPath strin
 	}
 
 	var rowCount int64
+	record := make([]string, len(columns))
+	nulls := make([]bool, len(columns))
 	for rows.Next() {
 		if err := rows.Scan(valuePtrs...); err != nil {
 			return rowCount, err
 		}
-		record := make([]string, len(columns))
 		for i, value := range values {
-			switch v := value.(type) {
-			case nil:
-				record[i] = ""
-			case []byte:
-				record[i] = string(v)
-			default:
-				record[i] = fmt.Sprint(v)
-			}
+			record[i], nulls[i] = formatValue(value, kinds[i])
 		}
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(record, nulls); err != nil {
 			return rowCount, err
 		}
 		rowCount++
 	}
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	if err := rows.Err(); err != nil {
+		return rowCount, err
+	}
+	if err := writer.Flush(); err != nil {
 		return rowCount, err
 	}
-	return rowCount, rows.Err()
+	return rowCount, eventlog.WriteSchema(outputPath, schema)
 }
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+
+	_ "github.com/SAP/go-hdb/driver"
+)
+
+// go-hdb runs SET TRANSACTION READ ONLY for sql.TxOptions.ReadOnly. The
+// default port is the SQL port of instance 00; tenant databases are selected
+// with DBConfig.DBName.
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:        "hana",
+			Label:       "SAP HANA",
+			Dialect:     sqlguard.DialectHANA,
+			DefaultPort: 30015,
+			ReadOnlyTx:  true,
+			Prompts:     Prompts{DBName: "Tenant database (optional)", DBNameOptional: true},
+		},
+		sqlName:       "hdb",
+		dsn:           hanaDSN,
+		placeholder:   questionPlaceholder,
+		schemasQuery:  "SELECT schema_name FROM sys.schemas WHERE has_privileges = 'TRUE' ORDER BY schema_name",
+		tablesQuery:   "SELECT object_name FROM sys.objects WHERE schema_name = ? AND object_type IN ('TABLE', 'VIEW') ORDER BY object_name",
+		columnsQuery:  hanaColumnsQuery,
+		estimateQuery: "SELECT record_count FROM sys.m_tables WHERE schema_name = ? AND table_name = ?",
+		sampleQuery:   limitSample,
+		quote:         '"',
+		pingQuery:     "SELECT 1 FROM dummy",
+	})
+}
+
+// hanaColumnsQuery covers tables and views with a single pair of binds.
+const hanaColumnsQuery = `SELECT column_name, data_type_name, CASE is_nullable WHEN 'TRUE' THEN 'YES' ELSE 'NO' END
+FROM (
+	SELECT schema_name, table_name, column_name, data_type_name, is_nullable, position FROM sys.table_columns
+	UNION ALL
+	SELECT schema_name, view_name, column_name, data_type_name, is_nullable, position FROM sys.view_columns
+) WHERE schema_name = ? AND table_name = ? ORDER BY position`
+
+func hanaDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("hana host is required")
+	}
+	query := url.Values{}
+	if cfg.DBName != "" {
+		query.Set("databaseName", cfg.DBName)
+	}
+	if cfg.Schema != "" {
+		query.Set("defaultSchema", cfg.Schema)
+	}
+	switch cfg.SSLMode {
+	case "", "disable":
+	case "require":
+		query.Set("TLSServerName", cfg.Host)
+		query.Set("TLSInsecureSkipVerify", "true")
+	default:
+		query.Set("TLSServerName", cfg.Host)
+	}
+	dsn := url.URL{
+		Scheme:   "hdb",
+		User:     url.UserPassword(cfg.User, secret),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(portOrDefault(cfg.Port, 30015))),
+		RawQuery: query.Encode(),
+	}
+	return dsn.String(), nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// IncrementalQuery wraps a user query so only rows at or past the watermark
+// are returned. Rows at the watermark itself are extracted again, since rows
+// committed later can share its value; appending the delta drops the ones
+// already in the log. The watermark is bound as parameter n, after any the
+// query already uses.
+func IncrementalQuery(driver string, query string, column string, n int) (string, error) {
+	if !identPattern.MatchString(column) {
+		return "", fmt.Errorf("invalid watermark column: %q", column)
+	}
+	placeholder, err := Placeholder(driver, n)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SELECT * FROM %s pm_delta WHERE %s >= %s ORDER BY %s", subquery(query), column, placeholder, column), nil
+}
+
+// subquery parenthesises a user query for wrapping, with the parentheses on
+// lines of their own so a trailing -- comment cannot swallow the closing one
+// and the predicate after it.
+func subquery(query string) string {
+	return "(\n" + strings.TrimRight(strings.TrimSpace(query), "; \t\r\n") + "\n)"
+}
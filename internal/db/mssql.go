@@ -1,35 +1,50 @@
 package db
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
-	"time"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
 
 	_ "github.com/denisenkom/go-mssqldb"
 )
 
-// TestMSSQLReadOnly validates connectivity and executes a read-only query.
-func TestMSSQLReadOnly(dsn string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	db, err := sql.Open("sqlserver", dsn)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
+// SQL Server has no read-only transaction mode, so extraction relies on the
+// query guard and the account's permissions.
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:        "mssql",
+			Label:       "SQL Server",
+			Dialect:     sqlguard.DialectTSQL,
+			DefaultPort: 1433,
+		},
+		sqlName:       "sqlserver",
+		dsn:           mssqlDSN,
+		placeholder:   atPPlaceholder,
+		schemasQuery:  "SELECT name FROM sys.schemas ORDER BY name",
+		tablesQuery:   "SELECT table_name FROM information_schema.tables WHERE table_schema=@p1 ORDER BY table_name",
+		columnsQuery:  "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema=@p1 AND table_name=@p2 ORDER BY ordinal_position",
+		estimateQuery: "SELECT SUM(p.rows) FROM sys.partitions p JOIN sys.tables t ON p.object_id = t.object_id JOIN sys.schemas s ON t.schema_id = s.schema_id WHERE s.name=@p1 AND t.name=@p2 AND p.index_id IN (0, 1)",
+		sampleQuery:   topSample,
+		quote:         '[',
+	})
+}
 
-	if err := db.PingContext(ctx); err != nil {
-		return err
+func mssqlDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("sql server host is required")
 	}
-
-	var one int
-	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
-		return err
+	dsn := url.URL{
+		Scheme: "sqlserver",
+		User:   url.UserPassword(cfg.User, secret),
+		Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(portOrDefault(cfg.Port, 1433))),
 	}
-	if one != 1 {
-		return fmt.Errorf("unexpected response from read-only check")
+	if cfg.DBName != "" {
+		dsn.RawQuery = url.Values{"database": {cfg.DBName}}.Encode()
 	}
-	return nil
+	return dsn.String(), nil
 }
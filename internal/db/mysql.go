@@ -1,41 +1,45 @@
 package db
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
-	"time"
+	"net"
+	"strconv"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
 )
 
-// TestMySQLReadOnly validates connectivity and attempts a read-only transaction.
-func TestMySQLReadOnly(dsn string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	if err := db.PingContext(ctx); err != nil {
-		return err
-	}
-
-	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:        "mysql",
+			Label:       "MySQL",
+			Dialect:     sqlguard.DialectMySQL,
+			DefaultPort: 3306,
+			ReadOnlyTx:  true,
+		},
+		sqlName:       "mysql",
+		dsn:           mysqlDSN,
+		placeholder:   questionPlaceholder,
+		schemasQuery:  "SELECT schema_name FROM information_schema.schemata ORDER BY schema_name",
+		tablesQuery:   "SELECT table_name FROM information_schema.tables WHERE table_schema=? ORDER BY table_name",
+		columnsQuery:  "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema=? AND table_name=? ORDER BY ordinal_position",
+		estimateQuery: "SELECT table_rows FROM information_schema.tables WHERE table_schema=? AND table_name=?",
+		sampleQuery:   limitSample,
+		quote:         '`',
+	})
+}
 
-	var one int
-	if err := tx.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
-		return err
-	}
-	if one != 1 {
-		return fmt.Errorf("unexpected response from read-only check")
+func mysqlDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("mysql host is required")
 	}
-	return tx.Commit()
+	mc := mysql.NewConfig()
+	mc.User = cfg.User
+	mc.Passwd = secret
+	mc.Net = "tcp"
+	mc.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(portOrDefault(cfg.Port, 3306)))
+	mc.DBName = cfg.DBName
+	return mc.FormatDSN(), nil
 }
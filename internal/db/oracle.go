@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+// go-ora rejects sql.TxOptions.ReadOnly, so the read-only transaction is
+// opened with SET TRANSACTION READ ONLY. The catalog uses the ALL_* views, so
+// only objects the account can read are listed.
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:        "oracle",
+			Label:       "Oracle",
+			Dialect:     sqlguard.DialectOracle,
+			DefaultPort: 1521,
+			ReadOnlyTx:  true,
+			Prompts:     Prompts{DBName: "Service name (e.g., ORCLPDB1)"},
+		},
+		sqlName:       "oracle",
+		dsn:           oracleDSN,
+		placeholder:   colonPlaceholder,
+		schemasQuery:  "SELECT username FROM all_users WHERE oracle_maintained = 'N' ORDER BY username",
+		tablesQuery:   "SELECT object_name FROM all_objects WHERE owner = :1 AND object_type IN ('TABLE', 'VIEW') ORDER BY object_name",
+		columnsQuery:  "SELECT column_name, data_type, CASE nullable WHEN 'Y' THEN 'YES' ELSE 'NO' END FROM all_tab_columns WHERE owner = :1 AND table_name = :2 ORDER BY column_id",
+		estimateQuery: "SELECT num_rows FROM all_tables WHERE owner = :1 AND table_name = :2",
+		sampleQuery:   fetchFirstSample,
+		quote:         '"',
+		pingQuery:     "SELECT 1 FROM dual",
+		readOnlyStmt:  "SET TRANSACTION READ ONLY",
+	})
+}
+
+// oracleDSN connects to a service name; DBConfig.DBName holds the service
+// (e.g. ORCLPDB1 or FREEPDB1).
+func oracleDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("oracle host is required")
+	}
+	if cfg.DBName == "" {
+		return "", fmt.Errorf("oracle service name is required")
+	}
+	options := map[string]string{}
+	switch cfg.SSLMode {
+	case "", "disable":
+	case "require":
+		options["SSL"] = "true"
+		options["SSL VERIFY"] = "false"
+	default:
+		options["SSL"] = "true"
+	}
+	if len(options) == 0 {
+		options = nil
+	}
+	return go_ora.BuildUrl(cfg.Host, portOrDefault(cfg.Port, 1521), cfg.DBName, cfg.User, secret, options), nil
+}
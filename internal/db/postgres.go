@@ -1,41 +1,50 @@
 package db
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
-	"time"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
 
 	_ "github.com/lib/pq"
 )
 
-// TestPostgresReadOnly validates connectivity and read-only access.
-func TestPostgresReadOnly(dsn string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	if err := db.PingContext(ctx); err != nil {
-		return err
-	}
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:        "postgres",
+			Label:       "Postgres",
+			Dialect:     sqlguard.DialectPostgres,
+			DefaultPort: 5432,
+			ReadOnlyTx:  true,
+		},
+		sqlName:       "postgres",
+		dsn:           postgresDSN,
+		placeholder:   dollarPlaceholder,
+		schemasQuery:  "SELECT schema_name FROM information_schema.schemata ORDER BY schema_name",
+		tablesQuery:   "SELECT table_name FROM information_schema.tables WHERE table_schema=$1 ORDER BY table_name",
+		columnsQuery:  "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema=$1 AND table_name=$2 ORDER BY ordinal_position",
+		estimateQuery: "SELECT c.reltuples::bigint FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname=$1 AND c.relname=$2",
+		sampleQuery:   limitSample,
+		quote:         '"',
+	})
+}
 
-	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
-	if err != nil {
-		return err
+func postgresDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("postgres host is required")
 	}
-	defer tx.Rollback()
-
-	var one int
-	if err := tx.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
-		return err
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.User, secret),
+		Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(portOrDefault(cfg.Port, 5432))),
+		Path:   "/" + cfg.DBName,
 	}
-	if one != 1 {
-		return fmt.Errorf("unexpected response from read-only check")
+	if cfg.SSLMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {cfg.SSLMode}}.Encode()
 	}
-	return tx.Commit()
+	return dsn.String(), nil
 }
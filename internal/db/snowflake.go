@@ -1,62 +1,47 @@
 package db
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
-	"time"
 
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
 	"github.com/snowflakedb/gosnowflake"
 )
 
-// SnowflakeDSN builds a DSN using the minimal required fields.
-func SnowflakeDSN(account string, user string, password string, database string, schema string) (string, error) {
-	if account == "" {
-		return "", fmt.Errorf("snowflake account is required")
-	}
-	if user == "" {
-		return "", fmt.Errorf("snowflake user is required")
-	}
-	cfg := &gosnowflake.Config{
-		Account:  account,
-		User:     user,
-		Password: password,
-		Database: database,
-		Schema:   schema,
-	}
-	return gosnowflake.DSN(cfg)
+// Snowflake's Go driver does not support read-only transactions.
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:    "snowflake",
+			Label:   "Snowflake",
+			Dialect: sqlguard.DialectSnowflake,
+		},
+		sqlName:       "snowflake",
+		dsn:           snowflakeDSN,
+		placeholder:   questionPlaceholder,
+		schemasQuery:  "SELECT schema_name FROM information_schema.schemata ORDER BY schema_name",
+		tablesQuery:   "SELECT table_name FROM information_schema.tables WHERE table_schema=? ORDER BY table_name",
+		columnsQuery:  "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema=? AND table_name=? ORDER BY ordinal_position",
+		estimateQuery: "SELECT row_count FROM information_schema.tables WHERE table_schema=? AND table_name=?",
+		sampleQuery:   limitSample,
+		quote:         '"',
+	})
 }
 
-// TestSnowflakeReadOnly validates connectivity by running a simple query.
-func TestSnowflakeReadOnly(dsn string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	db, err := sql.Open("snowflake", dsn)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	if err := db.PingContext(ctx); err != nil {
-		return err
-	}
-	var one int
-	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
-		return err
+// snowflakeDSN builds a DSN using the minimal required fields; the host field
+// holds the account identifier.
+func snowflakeDSN(cfg config.DBConfig, secret string) (string, error) {
+	if cfg.Host == "" {
+		return "", fmt.Errorf("snowflake account is required")
 	}
-	if one != 1 {
-		return fmt.Errorf("unexpected response from read-only check")
+	if cfg.User == "" {
+		return "", fmt.Errorf("snowflake user is required")
 	}
-	return nil
-}
-
-// ListSchemasSnowflake returns schemas in the current database.
-func ListSchemasSnowflake(dsn string) ([]string, error) {
-	return listSchemas("snowflake", dsn, "SELECT schema_name FROM information_schema.schemata ORDER BY schema_name")
-}
-
-// ListTablesSnowflake returns tables for a schema.
-func ListTablesSnowflake(dsn string, schema string) ([]string, error) {
-	return listTables("snowflake", dsn, "SELECT table_name FROM information_schema.tables WHERE table_schema=? ORDER BY table_name", schema)
+	return gosnowflake.DSN(&gosnowflake.Config{
+		Account:  cfg.Host,
+		User:     cfg.User,
+		Password: secret,
+		Database: cfg.DBName,
+		Schema:   cfg.Schema,
+	})
 }
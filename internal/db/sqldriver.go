@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// sqlDriver implements Driver for database/sql backed connectors whose
+// catalog is reachable through plain queries.
+type sqlDriver struct {
+	info         Info
+	sqlName      string
+	dsn          func(cfg config.DBConfig, secret string) (string, error)
+	placeholder  func(n int) string
+	schemasQuery string
+	tablesQuery  string
+	columnsQuery string
+	// estimateQuery returns a statistics-based row count for (schema, table).
+	estimateQuery string
+	sampleQuery   func(target string, n int) string
+	// quote is the opening identifier quote: '"', '`', or '['.
+	quote rune
+	// pingQuery must return the single integer 1; empty means "SELECT 1".
+	pingQuery string
+	// readOnlyStmt starts a read-only transaction for Go drivers that reject
+	// sql.TxOptions.ReadOnly; empty means the option is passed instead.
+	readOnlyStmt string
+}
+
+func (d *sqlDriver) Info() Info {
+	return d.info
+}
+
+func (d *sqlDriver) BuildDSN(cfg config.DBConfig, secret string) (string, error) {
+	return d.dsn(cfg, secret)
+}
+
+func (d *sqlDriver) Placeholder(n int) string {
+	return d.placeholder(n)
+}
+
+// TestReadOnly validates connectivity and runs a trivial query, inside a
+// read-only transaction when the driver supports one.
+func (d *sqlDriver) TestReadOnly(ctx context.Context, dsn string) error {
+	db, err := sql.Open(d.sqlName, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+	ping := d.pingQuery
+	if ping == "" {
+		ping = "SELECT 1"
+	}
+	var one int
+	if d.info.ReadOnlyTx {
+		tx, err := d.beginReadOnly(ctx, db)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := tx.QueryRowContext(ctx, ping).Scan(&one); err != nil {
+			return err
+		}
+	} else if err := db.QueryRowContext(ctx, ping).Scan(&one); err != nil {
+		return err
+	}
+	if one != 1 {
+		return fmt.Errorf("unexpected response from read-only check")
+	}
+	return nil
+}
+
+// beginReadOnly opens a transaction in which the database refuses writes.
+func (d *sqlDriver) beginReadOnly(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	if d.readOnlyStmt == "" {
+		return db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, d.readOnlyStmt); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (d *sqlDriver) ListSchemas(ctx context.Context, dsn string) ([]string, error) {
+	return listNames(ctx, d.sqlName, dsn, d.schemasQuery)
+}
+
+func (d *sqlDriver) ListTables(ctx context.Context, dsn string, schema string) ([]string, error) {
+	return listNames(ctx, d.sqlName, dsn, d.tablesQuery, schema)
+}
+
+func (d *sqlDriver) ListColumns(ctx context.Context, dsn string, schema string, table string) ([]Column, error) {
+	return listColumns(ctx, d.sqlName, dsn, d.columnsQuery, schema, table)
+}
+
+func (d *sqlDriver) Extract(ctx context.Context, dsn string, query string, outputPath string, args ...any) (int64, error) {
+	return extractQueryToCSV(ctx, d, dsn, query, outputPath, args...)
+}
+
+func dollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func questionPlaceholder(int) string {
+	return "?"
+}
+
+func atPPlaceholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+
+func colonPlaceholder(n int) string {
+	return fmt.Sprintf(":%d", n)
+}
+
+func portOrDefault(port int, fallback int) int {
+	if port == 0 {
+		return fallback
+	}
+	return port
+}
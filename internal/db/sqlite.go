@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	Register(&sqlDriver{
+		info: Info{
+			Name:           "sqlite",
+			Label:          "SQLite",
+			Dialect:        sqlguard.DialectSQLite,
+			ReadOnlyTx:     true,
+			SecretOptional: true,
+			Local:          true,
+		},
+		sqlName:      "sqlite",
+		dsn:          sqliteDSN,
+		placeholder:  questionPlaceholder,
+		schemasQuery: "SELECT name FROM pragma_database_list ORDER BY seq",
+		tablesQuery:  "SELECT name FROM pragma_table_list WHERE schema = ? AND type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name",
+		columnsQuery: `SELECT name, type, CASE WHEN "notnull" = 1 OR pk > 0 THEN 'NO' ELSE 'YES' END FROM pragma_table_info(?2, ?1) ORDER BY cid`,
+		sampleQuery:  limitSample,
+		quote:        '"',
+	})
+}
+
+// sqliteDSN opens the file read-only with writes also refused per connection.
+func sqliteDSN(cfg config.DBConfig, _ string) (string, error) {
+	path, err := localDatabasePath(cfg)
+	if err != nil {
+		return "", err
+	}
+	dsn := url.URL{Scheme: "file", Path: filepath.ToSlash(path), RawQuery: "mode=ro&_pragma=query_only(1)"}
+	return dsn.String(), nil
+}
+
+// localDatabasePath resolves and checks DBConfig.Path for file-backed drivers.
+func localDatabasePath(cfg config.DBConfig) (string, error) {
+	if cfg.Path == "" {
+		return "", fmt.Errorf("%s database file path is required", cfg.Driver)
+	}
+	path, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("database file not accessible: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("database path is a directory: %s", path)
+	}
+	return path, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+)
+
+// Candidate is a column proposed for an event log role.
+type Candidate struct {
+	Column string `json:"column"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// MappingSuggestion ranks columns for each event log role, best first.
+type MappingSuggestion struct {
+	CaseID    []Candidate `json:"case_id"`
+	Activity  []Candidate `json:"activity"`
+	Timestamp []Candidate `json:"timestamp"`
+	Resource  []Candidate `json:"resource"`
+}
+
+// Best returns the top candidate column or "".
+func Best(candidates []Candidate) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].Column
+}
+
+var (
+	caseNames      = []string{"case_id", "caseid", "case", "case:concept:name"}
+	caseHints      = []string{"case", "order", "document", "doc", "ticket", "incident", "invoice", "request", "vbeln", "ebeln", "belnr", "objectid"}
+	activityNames  = []string{"activity", "concept:name", "event", "event_name", "task"}
+	activityHints  = []string{"activity", "event", "status", "step", "action", "task", "operation", "tcode", "stage", "state"}
+	timestampNames = []string{"timestamp", "time:timestamp", "event_time", "start_time"}
+	timestampHints = []string{"time", "date", "_at", "_ts", "created", "changed", "updated", "udate", "erdat", "aedat"}
+	resourceNames  = []string{"resource", "org:resource", "user", "user_id"}
+	resourceHints  = []string{"user", "resource", "owner", "agent", "assignee", "usnam", "ernam", "by"}
+	idHints        = []string{"id", "_no", "number", "key", "nr"}
+)
+
+// SuggestMapping scores catalog columns as case, activity, timestamp, and
+// resource candidates from their names, types, and sample values.
+func SuggestMapping(columns []ColumnProfile) MappingSuggestion {
+	var out MappingSuggestion
+	for _, column := range columns {
+		name := strings.ToLower(column.Name)
+		temporal := isTemporalType(column.Type) || samplesParseAsTimestamps(column.Samples)
+		textual := isTextType(column.Type)
+
+		if score, reason := scoreName(name, timestampNames, timestampHints); score > 0 || temporal {
+			if isTemporalType(column.Type) {
+				score += 3
+				reason = join(reason, "temporal type "+column.Type)
+			} else if temporal {
+				score += 2
+				reason = join(reason, "samples parse as timestamps")
+			}
+			out.Timestamp = append(out.Timestamp, Candidate{Column: column.Name, Score: score, Reason: reason})
+		}
+		if temporal {
+			continue
+		}
+		if score, reason := scoreName(name, caseNames, caseHints); score > 0 {
+			if containsAny(name, idHints) {
+				score++
+				reason = join(reason, "identifier-like name")
+			}
+			out.CaseID = append(out.CaseID, Candidate{Column: column.Name, Score: score, Reason: reason})
+		}
+		if score, reason := scoreName(name, activityNames, activityHints); score > 0 {
+			if textual {
+				score++
+				reason = join(reason, "text type")
+			}
+			out.Activity = append(out.Activity, Candidate{Column: column.Name, Score: score, Reason: reason})
+		}
+		if score, reason := scoreName(name, resourceNames, resourceHints); score > 0 {
+			out.Resource = append(out.Resource, Candidate{Column: column.Name, Score: score, Reason: reason})
+		}
+	}
+	for _, list := range []*[]Candidate{&out.CaseID, &out.Activity, &out.Timestamp, &out.Resource} {
+		sort.SliceStable(*list, func(i, j int) bool { return (*list)[i].Score > (*list)[j].Score })
+	}
+	return out
+}
+
+func scoreName(name string, exact []string, hints []string) (int, string) {
+	for _, candidate := range exact {
+		if name == candidate {
+			return 5, "conventional name"
+		}
+	}
+	if containsAny(name, hints) {
+		return 2, "name hint"
+	}
+	return 0, ""
+}
+
+func containsAny(name string, hints []string) bool {
+	for _, hint := range hints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTemporalType(dataType string) bool {
+	t := strings.ToLower(dataType)
+	return strings.Contains(t, "timestamp") || strings.Contains(t, "date") || strings.Contains(t, "time")
+}
+
+func isTextType(dataType string) bool {
+	t := strings.ToLower(dataType)
+	return strings.Contains(t, "char") || strings.Contains(t, "text") || strings.Contains(t, "string")
+}
+
+func samplesParseAsTimestamps(samples []string) bool {
+	if len(samples) == 0 {
+		return false
+	}
+	for _, sample := range samples {
+		if _, err := eventlog.ParseTimestamp(sample); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func join(a string, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + ", " + b
+}
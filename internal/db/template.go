@@ -0,0 +1,165 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+)
+
+var paramRef = regexp.MustCompile(`\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}`)
+
+// RenderedQuery is a query template with its parameters replaced by driver
+// placeholders and the matching bind arguments.
+type RenderedQuery struct {
+	SQL    string
+	Args   []any
+	Values map[string]string
+}
+
+// Hash returns the SHA-256 of the rendered SQL followed by its bound
+// arguments, so runs with different parameter values hash differently.
+func (r RenderedQuery) Hash() string {
+	hasher := sha256.New()
+	hasher.Write([]byte(r.SQL))
+	for _, arg := range r.Args {
+		if t, ok := arg.(time.Time); ok {
+			arg = t.UTC().Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(hasher, "\n%T:%v", arg, arg)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// RenderQuery binds template parameters for a driver. Values are taken from
+// values, falling back to each parameter's default; they are never spliced
+// into the SQL text.
+func RenderQuery(driver string, tmpl config.QueryTemplate, values map[string]string) (RenderedQuery, error) {
+	declared := map[string]config.QueryParam{}
+	for _, param := range tmpl.Params {
+		declared[param.Name] = param
+	}
+	for name := range values {
+		if _, ok := declared[name]; !ok {
+			return RenderedQuery{}, fmt.Errorf("query %s has no parameter %q", tmpl.Name, name)
+		}
+	}
+	resolved := map[string]string{}
+	bound := map[string][]any{}
+	for _, param := range tmpl.Params {
+		raw, ok := values[param.Name]
+		if !ok {
+			raw = param.Default
+		}
+		if raw == "" && param.Required {
+			return RenderedQuery{}, fmt.Errorf("query %s: parameter %s is required", tmpl.Name, param.Name)
+		}
+		args, err := convertParam(param, raw)
+		if err != nil {
+			return RenderedQuery{}, fmt.Errorf("query %s: parameter %s: %w", tmpl.Name, param.Name, err)
+		}
+		resolved[param.Name] = raw
+		bound[param.Name] = args
+	}
+
+	impl, err := Lookup(driver)
+	if err != nil {
+		return RenderedQuery{}, err
+	}
+	// References inside string literals, quoted identifiers, and comments
+	// are text, not parameters.
+	quoted, err := sqlguard.Quoted(impl.Info().Dialect, tmpl.SQL)
+	if err != nil {
+		return RenderedQuery{}, fmt.Errorf("query %s: %w", tmpl.Name, err)
+	}
+	rendered := RenderedQuery{Values: resolved}
+	var sql strings.Builder
+	last := 0
+	for _, match := range paramRef.FindAllStringSubmatchIndex(tmpl.SQL, -1) {
+		if inSpan(quoted, match[0]) {
+			continue
+		}
+		name := tmpl.SQL[match[2]:match[3]]
+		args, ok := bound[name]
+		if !ok {
+			return RenderedQuery{}, fmt.Errorf("query %s references undeclared parameter %s", tmpl.Name, name)
+		}
+		if len(args) == 0 {
+			return RenderedQuery{}, fmt.Errorf("query %s: list parameter %s is empty", tmpl.Name, name)
+		}
+		placeholders := make([]string, len(args))
+		for i, arg := range args {
+			placeholders[i] = impl.Placeholder(len(rendered.Args) + 1)
+			rendered.Args = append(rendered.Args, arg)
+		}
+		sql.WriteString(tmpl.SQL[last:match[0]])
+		sql.WriteString(strings.Join(placeholders, ", "))
+		last = match[1]
+	}
+	sql.WriteString(tmpl.SQL[last:])
+	rendered.SQL = sql.String()
+	return rendered, nil
+}
+
+// inSpan reports whether offset falls inside one of spans.
+func inSpan(spans []sqlguard.Span, offset int) bool {
+	for _, span := range spans {
+		if offset >= span.Start && offset < span.End {
+			return true
+		}
+	}
+	return false
+}
+
+func convertParam(param config.QueryParam, raw string) ([]any, error) {
+	if strings.HasSuffix(param.Type, "_list") {
+		itemType := strings.TrimSuffix(param.Type, "_list")
+		out := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			value, err := convertScalar(itemType, item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, value)
+		}
+		return out, nil
+	}
+	if raw == "" {
+		return []any{nil}, nil
+	}
+	value, err := convertScalar(param.Type, raw)
+	if err != nil {
+		return nil, err
+	}
+	return []any{value}, nil
+}
+
+func convertScalar(kind string, raw string) (any, error) {
+	switch kind {
+	case "string":
+		return raw, nil
+	case "int":
+		return strconv.ParseInt(raw, 10, 64)
+	case "float":
+		return strconv.ParseFloat(raw, 64)
+	case "bool":
+		return strconv.ParseBool(raw)
+	case "date":
+		return time.Parse("2006-01-02", raw)
+	case "timestamp":
+		return eventlog.ParseTimestamp(raw)
+	default:
+		return nil, fmt.Errorf("unsupported parameter type: %s", kind)
+	}
+}
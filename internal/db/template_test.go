@@ -0,0 +1,63 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+func TestRenderQueryBindsTypedParams(t *testing.T) {
+	tmpl := config.QueryTemplate{
+		Name: "orders",
+		SQL:  "SELECT * FROM orders WHERE created_at >= ${from} AND company_code IN (${companies})",
+		Params: []config.QueryParam{
+			{Name: "from", Type: "date", Required: true},
+			{Name: "companies", Type: "string_list", Default: "1000,2000"},
+		},
+	}
+	rendered, err := RenderQuery("postgres", tmpl, map[string]string{"from": "2024-01-01"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := "SELECT * FROM orders WHERE created_at >= $1 AND company_code IN ($2, $3)"
+	if rendered.SQL != want {
+		t.Fatalf("unexpected sql: %s", rendered.SQL)
+	}
+	if len(rendered.Args) != 3 || rendered.Args[0] != time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) || rendered.Args[2] != "2000" {
+		t.Fatalf("unexpected args: %v", rendered.Args)
+	}
+
+	mssql, err := RenderQuery("mssql", tmpl, map[string]string{"from": "2024-01-01", "companies": "1000"})
+	if err != nil {
+		t.Fatalf("render mssql: %v", err)
+	}
+	if !strings.Contains(mssql.SQL, ">= @p1") || !strings.Contains(mssql.SQL, "IN (@p2)") {
+		t.Fatalf("unexpected mssql sql: %s", mssql.SQL)
+	}
+	if mssql.Hash() == rendered.Hash() {
+		t.Fatalf("expected different hashes for different bindings")
+	}
+
+	if _, err := RenderQuery("postgres", tmpl, map[string]string{}); err == nil {
+		t.Fatalf("expected error for missing required parameter")
+	}
+	if _, err := RenderQuery("postgres", tmpl, map[string]string{"from": "2024-01-01'; DROP TABLE x; --"}); err == nil {
+		t.Fatalf("expected error for non-date value")
+	}
+
+	// References in literals and comments are left alone, even undeclared.
+	literal := config.QueryTemplate{
+		Name:   "costs",
+		SQL:    "SELECT 'cost ${x}' AS label, \"${y}\" -- ${z}\nFROM costs /* ${from} */ WHERE booked_at >= ${from}",
+		Params: []config.QueryParam{{Name: "from", Type: "date", Required: true}},
+	}
+	rendered, err = RenderQuery("postgres", literal, map[string]string{"from": "2024-01-01"})
+	if err != nil {
+		t.Fatalf("render literal: %v", err)
+	}
+	if want := "SELECT 'cost ${x}' AS label, \"${y}\" -- ${z}\nFROM costs /* ${from} */ WHERE booked_at >= $1"; rendered.SQL != want || len(rendered.Args) != 1 {
+		t.Fatalf("unexpected sql: %s (%v)", rendered.SQL, rendered.Args)
+	}
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+)
+
+// logicalType maps a database type name to an eventlog schema type.
+func logicalType(dbType string) string {
+	t := strings.ToUpper(strings.TrimSpace(dbType))
+	switch {
+	case t == "DATE":
+		return eventlog.TypeDate
+	case strings.Contains(t, "TIMESTAMP"), strings.Contains(t, "DATETIME"):
+		return eventlog.TypeTimestamp
+	case strings.Contains(t, "INTERVAL"), strings.HasPrefix(t, "TIME"):
+		return eventlog.TypeString
+	case strings.Contains(t, "INT"), strings.Contains(t, "SERIAL"):
+		return eventlog.TypeInteger
+	case strings.Contains(t, "DECIMAL"), strings.Contains(t, "NUMERIC"), strings.Contains(t, "NUMBER"),
+		strings.Contains(t, "MONEY"), t == "FIXED":
+		return eventlog.TypeDecimal
+	case strings.Contains(t, "FLOAT"), strings.Contains(t, "DOUBLE"), t == "REAL":
+		return eventlog.TypeFloat
+	case strings.HasPrefix(t, "BOOL"), t == "BIT":
+		return eventlog.TypeBoolean
+	case strings.Contains(t, "BINARY"), strings.Contains(t, "BLOB"), t == "BYTEA", t == "BYTES", t == "IMAGE":
+		return eventlog.TypeBinary
+	default:
+		return eventlog.TypeString
+	}
+}
+
+// valueFormatters handle driver-specific value types that fmt.Sprint would
+// render lossily; build-tagged drivers register theirs in init.
+var valueFormatters []func(value any) (string, bool)
+
+// formatValue renders a scanned value for CSV without losing type
+// information; null is true for SQL NULL.
+func formatValue(value any, kind string) (text string, null bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case time.Time:
+		return formatTime(v, kind), false
+	case []byte:
+		if kind == eventlog.TypeBinary {
+			return hex.EncodeToString(v), false
+		}
+		return normaliseText(string(v), kind), false
+	case string:
+		return normaliseText(v, kind), false
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), false
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), false
+	case bool:
+		return strconv.FormatBool(v), false
+	case *big.Rat:
+		return ratString(v), false
+	default:
+		for _, format := range valueFormatters {
+			if text, ok := format(v); ok {
+				return text, false
+			}
+		}
+		return fmt.Sprint(v), false
+	}
+}
+
+// ratString renders an exact decimal, such as a HANA DECIMAL, without the
+// fraction notation big.Rat prints by default.
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	// A terminating decimal has a denominator of 2^a * 5^b and needs
+	// max(a, b) fractional digits.
+	denom := new(big.Int).Set(r.Denom())
+	digits := 0
+	for _, factor := range []int64{2, 5} {
+		f, rem := big.NewInt(factor), new(big.Int)
+		count := 0
+		for {
+			quo, _ := new(big.Int).QuoRem(denom, f, rem)
+			if rem.Sign() != 0 {
+				break
+			}
+			denom, count = quo, count+1
+		}
+		digits = max(digits, count)
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		digits = 38
+	}
+	return r.FloatString(digits)
+}
+
+func formatTime(t time.Time, kind string) string {
+	if kind == eventlog.TypeDate {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// normaliseText rewrites temporal values that drivers return as text (MySQL
+// DATETIME, for example) to RFC 3339; other text, including decimals, is kept
+// verbatim so no precision is lost.
+func normaliseText(value string, kind string) string {
+	if kind != eventlog.TypeTimestamp && kind != eventlog.TypeDate {
+		return value
+	}
+	parsed, err := eventlog.ParseTimestamp(value)
+	if err != nil {
+		return value
+	}
+	return formatTime(parsed, kind)
+}
+
+// recordWriter writes CSV rows using eventlog.NullEncodingUnquotedEmpty:
+// NULL is an empty field and an empty string is written as "".
+type recordWriter struct {
+	w *bufio.Writer
+}
+
+func newRecordWriter(w io.Writer) *recordWriter {
+	return &recordWriter{w: bufio.NewWriter(w)}
+}
+
+func (r *recordWriter) Write(fields []string, nulls []bool) error {
+	for i, field := range fields {
+		if i > 0 {
+			if err := r.w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		null := nulls != nil && nulls[i]
+		if null {
+			continue
+		}
+		if field != "" && !strings.ContainsAny(field, ",\"\r\n") && field[0] != ' ' && field[0] != '\t' {
+			if _, err := r.w.WriteString(field); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := r.w.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`); err != nil {
+			return err
+		}
+	}
+	return r.w.WriteByte('\n')
+}
+
+func (r *recordWriter) Flush() error {
+	return r.w.Flush()
+}
@@ -0,0 +1,141 @@
+package eventlog
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AppendStats summarises an incremental append.
+type AppendStats struct {
+	BaseRows   int64 `json:"base_rows"`
+	DeltaRows  int64 `json:"delta_rows"`
+	Appended   int64 `json:"appended_rows"`
+	Duplicates int64 `json:"duplicate_rows"`
+}
+
+// AppendDedup writes basePath followed by the rows of deltaPath that are not
+// already present (compared on the full record) to outputPath. Delta columns
+// are reordered to the base header; both files must hold the same columns.
+// outputPath may be the same file as either input.
+func AppendDedup(basePath string, deltaPath string, outputPath string) (AppendStats, error) {
+	var stats AppendStats
+	base, err := Open(basePath, Options{})
+	if err != nil {
+		return stats, err
+	}
+	defer base.Close()
+	delta, err := Open(deltaPath, Options{})
+	if err != nil {
+		return stats, err
+	}
+	defer delta.Close()
+
+	header := base.Header()
+	order, err := alignHeader(header, delta.Header())
+	if err != nil {
+		return stats, err
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".tmp")
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return stats, err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return stats, err
+	}
+
+	seen := map[[sha256.Size]byte]struct{}{}
+	for {
+		record, err := base.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.BaseRows++
+		seen[recordKey(record)] = struct{}{}
+		if err := writer.Write(record); err != nil {
+			return stats, err
+		}
+	}
+	for {
+		record, err := delta.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.DeltaRows++
+		aligned := make([]string, len(order))
+		for i, idx := range order {
+			if idx < len(record) {
+				aligned[i] = record[idx]
+			}
+		}
+		key := recordKey(aligned)
+		if _, dup := seen[key]; dup {
+			stats.Duplicates++
+			continue
+		}
+		seen[key] = struct{}{}
+		if err := writer.Write(aligned); err != nil {
+			return stats, err
+		}
+		stats.Appended++
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return stats, err
+	}
+	if err := file.Close(); err != nil {
+		return stats, err
+	}
+	base.Close()
+	delta.Close()
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return stats, err
+	}
+	ok = true
+	return stats, nil
+}
+
+// alignHeader maps each base column to its index in the delta header.
+func alignHeader(base []string, delta []string) ([]int, error) {
+	if len(base) != len(delta) {
+		return nil, fmt.Errorf("delta columns do not match existing log (%d vs %d columns)", len(delta), len(base))
+	}
+	index := make(map[string]int, len(delta))
+	for i, name := range delta {
+		index[name] = i
+	}
+	order := make([]int, len(base))
+	for i, name := range base {
+		idx, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("delta is missing column %q from existing log", name)
+		}
+		order[i] = idx
+	}
+	return order, nil
+}
+
+func recordKey(record []string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.Join(record, "\x1f")))
+}
@@ -0,0 +1,230 @@
+package eventlog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SourceFileColumn is the provenance column added by Concat.
+const SourceFileColumn = "source_file"
+
+// ConcatOptions controls how Concat reconciles the input files.
+type ConcatOptions struct {
+	Options
+	// Aliases maps alternative column names to the canonical name; matching
+	// ignores case and surrounding spaces. Columns named like an earlier
+	// column apart from case are matched to it without an alias.
+	Aliases map[string]string
+}
+
+// ConcatReport describes the files combined by Concat.
+type ConcatReport struct {
+	Columns []string     `json:"columns"`
+	Rows    int64        `json:"rows"`
+	Files   []FileSchema `json:"files"`
+}
+
+// FileSchema is one input of Concat compared with the first input.
+type FileSchema struct {
+	Path    string   `json:"path"`
+	Source  string   `json:"source_file"`
+	Rows    int64    `json:"rows"`
+	Columns []string `json:"columns"`
+	// Renamed maps source column names to the canonical names they were
+	// matched to.
+	Renamed   map[string]string `json:"renamed,omitempty"`
+	Missing   []string          `json:"missing,omitempty"`
+	Extra     []string          `json:"extra,omitempty"`
+	Reordered bool              `json:"reordered,omitempty"`
+}
+
+// Drifted reports whether the file's columns differ from the first file's,
+// beyond renames and order.
+func (f FileSchema) Drifted() bool {
+	return len(f.Missing) > 0 || len(f.Extra) > 0
+}
+
+// Drifted returns the files whose columns differ from the first file's.
+func (r ConcatReport) Drifted() []FileSchema {
+	var drifted []FileSchema
+	for _, file := range r.Files {
+		if file.Drifted() {
+			drifted = append(drifted, file)
+		}
+	}
+	return drifted
+}
+
+// Concat writes the rows of paths, in order, to outputPath as one UTF-8 CSV.
+// Column names are mapped through the aliases and aligned by name; the
+// output holds every column seen, in order of first appearance, followed by
+// a source_file column naming the input of each row. Columns absent from a
+// file are left empty.
+func Concat(paths []string, outputPath string, opts ConcatOptions) (ConcatReport, error) {
+	var report ConcatReport
+	if len(paths) == 0 {
+		return report, fmt.Errorf("no files to combine")
+	}
+	aliases := make(map[string]string, len(opts.Aliases))
+	for alias, canonical := range opts.Aliases {
+		aliases[aliasKey(alias)] = strings.TrimSpace(canonical)
+	}
+	sources := sourceNames(paths)
+
+	// Read every header first so the output header is known up front.
+	var first []string
+	for i, path := range paths {
+		reader, err := Open(path, opts.Options)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", path, err)
+		}
+		header := reader.Header()
+		reader.Close()
+		file := FileSchema{Path: path, Source: sources[i]}
+		seen := map[string]bool{}
+		for _, name := range header {
+			canonical := strings.TrimSpace(name)
+			target, ok := aliases[aliasKey(name)]
+			if !ok {
+				// Names differing only in case or spacing match a column
+				// already seen.
+				if j := slices.IndexFunc(report.Columns, func(c string) bool { return strings.EqualFold(c, canonical) }); j >= 0 {
+					target, ok = report.Columns[j], true
+				}
+			}
+			if ok && target != name {
+				if file.Renamed == nil {
+					file.Renamed = map[string]string{}
+				}
+				file.Renamed[name] = target
+				canonical = target
+			}
+			if seen[canonical] {
+				return report, fmt.Errorf("%s: column %q appears more than once after applying aliases", path, canonical)
+			}
+			seen[canonical] = true
+			file.Columns = append(file.Columns, canonical)
+			if !slices.Contains(report.Columns, canonical) {
+				report.Columns = append(report.Columns, canonical)
+			}
+		}
+		if i == 0 {
+			first = file.Columns
+		} else {
+			for _, name := range first {
+				if !seen[name] {
+					file.Missing = append(file.Missing, name)
+				}
+			}
+			for _, name := range file.Columns {
+				if !slices.Contains(first, name) {
+					file.Extra = append(file.Extra, name)
+				}
+			}
+			file.Reordered = !file.Drifted() && !slices.Equal(first, file.Columns)
+		}
+		report.Files = append(report.Files, file)
+	}
+	if slices.Contains(report.Columns, SourceFileColumn) {
+		return report, fmt.Errorf("input already has a %s column", SourceFileColumn)
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".tmp")
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return report, err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			out.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	writer := csv.NewWriter(out)
+	if err := writer.Write(append(slices.Clone(report.Columns), SourceFileColumn)); err != nil {
+		return report, err
+	}
+	for i := range report.Files {
+		rows, err := concatFile(writer, &report.Files[i], report.Columns, opts.Options)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", report.Files[i].Path, err)
+		}
+		report.Files[i].Rows = rows
+		report.Rows += rows
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return report, err
+	}
+	if err := out.Close(); err != nil {
+		return report, err
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return report, err
+	}
+	ok = true
+	return report, nil
+}
+
+// concatFile writes the rows of one input aligned to columns.
+func concatFile(writer *csv.Writer, file *FileSchema, columns []string, opts Options) (int64, error) {
+	reader, err := Open(file.Path, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	order := make([]int, len(columns))
+	for i, name := range columns {
+		order[i] = slices.Index(file.Columns, name)
+	}
+	var rows int64
+	aligned := make([]string, len(columns)+1)
+	aligned[len(columns)] = file.Source
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		for i, idx := range order {
+			aligned[i] = ""
+			if idx >= 0 && idx < len(record) {
+				aligned[i] = record[idx]
+			}
+		}
+		if err := writer.Write(aligned); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+}
+
+// sourceNames names each input by its base name, or by its full path when
+// base names repeat.
+func sourceNames(paths []string) []string {
+	counts := map[string]int{}
+	for _, path := range paths {
+		counts[filepath.Base(path)]++
+	}
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+		if counts[names[i]] > 1 {
+			names[i] = filepath.ToSlash(path)
+		}
+	}
+	return names
+}
+
+func aliasKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,254 @@
+package eventlog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertToParquetRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "log.csv")
+	content := "case_id,activity,timestamp\n1,A,2024-01-01 10:00:00\n1,B,\n"
+	if err := os.WriteFile(csvPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	parquetPath := StagePath(csvPath, FormatParquet)
+	rows, err := ConvertToParquet(csvPath, parquetPath, []string{"timestamp"}, Options{})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if rows != 2 {
+		t.Fatalf("expected 2 rows, got %d", rows)
+	}
+
+	reader, err := Open(parquetPath, Options{})
+	if err != nil {
+		t.Fatalf("open parquet: %v", err)
+	}
+	defer reader.Close()
+	if got := reader.Header(); len(got) != 3 || got[2] != "timestamp" {
+		t.Fatalf("unexpected header: %v", got)
+	}
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0][2] != "2024-01-01T10:00:00Z" {
+		t.Fatalf("unexpected timestamp: %q", records[0][2])
+	}
+	if records[1][2] != "" {
+		t.Fatalf("expected null timestamp to read as empty, got %q", records[1][2])
+	}
+}
+
+func TestConvertToParquetRejectsBadTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "log.csv")
+	if err := os.WriteFile(csvPath, []byte("case_id,timestamp\n1,not-a-date\n"), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	parquetPath := StagePath(csvPath, FormatParquet)
+	if _, err := ConvertToParquet(csvPath, parquetPath, []string{"timestamp"}, Options{}); err == nil {
+		t.Fatalf("expected error for unparseable timestamp")
+	}
+	if _, err := os.Stat(parquetPath); err == nil {
+		t.Fatalf("expected partial parquet file to be removed")
+	}
+}
+
+func TestAppendDedup(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.csv")
+	deltaPath := filepath.Join(dir, "delta.csv")
+	if err := os.WriteFile(basePath, []byte("case_id,activity\n1,Create\n1,Approve\n"), 0o644); err != nil {
+		t.Fatalf("write base: %v", err)
+	}
+	if err := os.WriteFile(deltaPath, []byte("activity,case_id\nApprove,1\nCreate,2\n"), 0o644); err != nil {
+		t.Fatalf("write delta: %v", err)
+	}
+	stats, err := AppendDedup(basePath, deltaPath, deltaPath)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if stats.BaseRows != 2 || stats.DeltaRows != 2 || stats.Appended != 1 || stats.Duplicates != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	data, err := os.ReadFile(deltaPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if string(data) != "case_id,activity\n1,Create\n1,Approve\n2,Create\n" {
+		t.Fatalf("unexpected output:\n%s", data)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	jan := write("events_2025-01.csv", "case_id,activity,timestamp\n1,Create,2025-01-02\n")
+	feb := write("events_2025-02.csv", "Activity,Case ID,timestamp\nShip,1,2025-02-03\n")
+	mar := write("events_2025-03.csv", "case_id,activity,timestamp,channel\n2,Create,2025-03-01,web\n")
+	apr := write("events_2025-04.csv", "case_id,timestamp\n3,2025-04-01\n")
+	output := filepath.Join(dir, "combined.csv")
+
+	report, err := Concat([]string{jan, feb, mar, apr}, output, ConcatOptions{Aliases: map[string]string{"case id": "case_id"}})
+	if err != nil {
+		t.Fatalf("concat: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	want := "case_id,activity,timestamp,channel,source_file\n" +
+		"1,Create,2025-01-02,,events_2025-01.csv\n" +
+		"1,Ship,2025-02-03,,events_2025-02.csv\n" +
+		"2,Create,2025-03-01,web,events_2025-03.csv\n" +
+		"3,,2025-04-01,,events_2025-04.csv\n"
+	if string(data) != want {
+		t.Fatalf("unexpected output:\n%s", data)
+	}
+	files := report.Files
+	if report.Rows != 4 || !files[1].Reordered || files[1].Renamed["Case ID"] != "case_id" || files[1].Renamed["Activity"] != "activity" || files[1].Drifted() {
+		t.Fatalf("unexpected report for renamed file: %+v", files[1])
+	}
+	if len(report.Drifted()) != 2 || files[2].Extra[0] != "channel" || files[3].Missing[0] != "activity" {
+		t.Fatalf("unexpected drift: %+v", report.Drifted())
+	}
+}
+
+func TestUnpivot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tickets.csv")
+	content := "ticket,priority,created_at,firstResponseTime,resolved_at\n" +
+		"T1,high,2024-01-01 09:00,2024-01-01 09:30,2024-01-02 10:00\n" +
+		"T2,low,2024-01-03 08:00,NULL,\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	var columns []WideColumn
+	for _, column := range []string{"created_at", "firstResponseTime", "resolved_at"} {
+		columns = append(columns, WideColumn{Column: column, Activity: ActivityName(column, NamingHumanize)})
+	}
+	output := filepath.Join(dir, "events.csv")
+	stats, err := Unpivot(path, output, UnpivotOptions{CaseColumn: "ticket", Columns: columns, Keep: []string{"priority"}})
+	if err != nil {
+		t.Fatalf("unpivot: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	want := "ticket,activity,timestamp,priority\n" +
+		"T1,Created,2024-01-01 09:00,high\n" +
+		"T1,First response,2024-01-01 09:30,high\n" +
+		"T1,Resolved,2024-01-02 10:00,high\n" +
+		"T2,Created,2024-01-03 08:00,low\n"
+	if string(data) != want {
+		t.Fatalf("unexpected output:\n%s", data)
+	}
+	if stats.Rows != 2 || stats.Events != 4 || stats.Skipped != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestUnpivotRenamesClashingKeptColumns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "orders.csv")
+	content := "order,timestamp,activity,created_at,created_date\n" +
+		"O1,2024-01-01 08:00,web,2024-01-01 09:00,2024-01-01\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	var columns []WideColumn
+	for _, column := range []string{"created_at", "created_date"} {
+		columns = append(columns, WideColumn{Column: column, Activity: ActivityName(column, NamingHumanize)})
+	}
+	output := filepath.Join(dir, "events.csv")
+	stats, err := Unpivot(path, output, UnpivotOptions{CaseColumn: "order", Columns: columns[:1], Keep: []string{"timestamp", "activity"}})
+	if err != nil {
+		t.Fatalf("unpivot: %v", err)
+	}
+	data, _ := os.ReadFile(output)
+	want := "order,activity,timestamp,source_timestamp,source_activity\n" +
+		"O1,Created,2024-01-01 09:00,2024-01-01 08:00,web\n"
+	if string(data) != want || stats.Renamed["timestamp"] != "source_timestamp" {
+		t.Fatalf("unexpected output (renamed %v):\n%s", stats.Renamed, data)
+	}
+	shared := SharedActivities(columns)
+	if len(shared) != 1 || len(shared[0]) != 2 || shared[0][0] != "created_at" || shared[0][1] != "created_date" {
+		t.Fatalf("shared activities = %v", shared)
+	}
+}
+
+func TestDeriveSchema(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source_extract.csv")
+	dst := filepath.Join(dir, "normalised_log.csv")
+	if err := os.WriteFile(src, []byte("id,step,at,booked,note\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The note column was dropped and booked mixes layouts after the rewrite.
+	content := "case:concept:name,concept:name,time:timestamp,booked\n1,A,2024-01-01 09:00:00+00:00,2024-01-01\n1,B,2024-01-02 09:00:00+00:00,02.01.2024\n"
+	if err := os.WriteFile(dst, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schema := Schema{
+		Columns: []SchemaColumn{
+			{Name: "id", SourceType: "INT8", Type: TypeInteger},
+			{Name: "step", SourceType: "TEXT", Type: TypeString},
+			{Name: "at", SourceType: "TIMESTAMPTZ", Type: TypeTimestamp},
+			{Name: "booked", SourceType: "DATE", Type: TypeDate},
+			{Name: "note", SourceType: "TEXT", Type: TypeString},
+		},
+		Mapping: &SchemaMapping{CaseID: "id", Activity: "step", Timestamp: "at"},
+	}
+	if err := WriteSchema(src, schema); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeriveSchema(src, dst, map[string]string{"id": "case:concept:name", "step": "concept:name", "at": "time:timestamp"}); err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	derived, err := ReadSchema(dst)
+	if err != nil || derived == nil {
+		t.Fatalf("read derived schema: %v", err)
+	}
+	if len(derived.Columns) != 4 || derived.Columns[0].Name != "case:concept:name" || derived.Columns[0].SourceType != "INT8" {
+		t.Fatalf("columns = %+v", derived.Columns)
+	}
+	if at := derived.Columns[2]; at.Format() != "2006-01-02 15:04:05Z07:00" {
+		t.Fatalf("timestamp column = %+v", at)
+	}
+	if booked := derived.Columns[3]; booked.Temporal() {
+		t.Fatalf("mixed layouts should fall back to string: %+v", booked)
+	}
+	if derived.Mapping == nil || derived.Mapping.Timestamp != "time:timestamp" || derived.Mapping.TimestampFormat != "2006-01-02 15:04:05Z07:00" {
+		t.Fatalf("mapping = %+v", derived.Mapping)
+	}
+
+	// Without a source sidecar a stale derived one is removed.
+	if err := DeriveSchema(filepath.Join(dir, "plain.csv"), dst, nil); err != nil {
+		t.Fatalf("derive without sidecar: %v", err)
+	}
+	if _, err := os.Stat(SchemaPath(dst)); !os.IsNotExist(err) {
+		t.Fatalf("stale sidecar kept: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package eventlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+const parquetBatchRows = 65536
+
+var timestampType = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+type parquetReader struct {
+	file    *file.Reader
+	records pqarrow.RecordReader
+	header  []string
+	current arrow.Record
+	row     int
+}
+
+func openParquet(path string) (*parquetReader, error) {
+	rdr, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		return nil, err
+	}
+	fileReader, err := pqarrow.NewFileReader(rdr, pqarrow.ArrowReadProperties{BatchSize: parquetBatchRows}, memory.DefaultAllocator)
+	if err != nil {
+		rdr.Close()
+		return nil, err
+	}
+	records, err := fileReader.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		rdr.Close()
+		return nil, err
+	}
+	schema := records.Schema()
+	header := make([]string, schema.NumFields())
+	for i, field := range schema.Fields() {
+		header[i] = field.Name
+	}
+	return &parquetReader{file: rdr, records: records, header: header}, nil
+}
+
+func (r *parquetReader) Header() []string {
+	return r.header
+}
+
+func (r *parquetReader) Read() ([]string, error) {
+	for r.current == nil || r.row >= int(r.current.NumRows()) {
+		if !r.records.Next() {
+			if err := r.records.Err(); err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		r.current = r.records.Record()
+		r.row = 0
+	}
+	record := make([]string, len(r.header))
+	for i := range record {
+		record[i] = formatValue(r.current.Column(i), r.row)
+	}
+	r.row++
+	return record, nil
+}
+
+func (r *parquetReader) Close() error {
+	r.records.Release()
+	return r.file.Close()
+}
+
+func formatValue(column arrow.Array, row int) string {
+	if column.IsNull(row) {
+		return ""
+	}
+	switch values := column.(type) {
+	case *array.String:
+		return values.Value(row)
+	case *array.LargeString:
+		return values.Value(row)
+	case *array.Timestamp:
+		unit := values.DataType().(*arrow.TimestampType).Unit
+		return values.Value(row).ToTime(unit).UTC().Format(time.RFC3339Nano)
+	default:
+		return column.ValueStr(row)
+	}
+}
+
+// ParquetWriter writes string records to Parquet, storing timestamp columns as
+// UTC microsecond timestamps and all other columns as UTF-8 strings.
+type ParquetWriter struct {
+	writer     *pqarrow.FileWriter
+	builder    *array.RecordBuilder
+	timestamps []bool
+	header     []string
+	pending    int
+	rows       int64
+}
+
+// NewParquetWriter creates a Parquet file at path with the given header.
+func NewParquetWriter(path string, header []string, timestampCols []string) (*ParquetWriter, error) {
+	typed := map[string]struct{}{}
+	for _, col := range timestampCols {
+		typed[col] = struct{}{}
+	}
+	fields := make([]arrow.Field, len(header))
+	timestamps := make([]bool, len(header))
+	for i, name := range header {
+		fields[i] = arrow.Field{Name: name, Type: arrow.BinaryTypes.String, Nullable: true}
+		if _, ok := typed[name]; ok {
+			fields[i].Type = timestampType
+			timestamps[i] = true
+		}
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	writer, err := pqarrow.NewFileWriter(schema, out, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	return &ParquetWriter{
+		writer:     writer,
+		builder:    array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		timestamps: timestamps,
+		header:     header,
+	}, nil
+}
+
+// Write appends a record. Timestamp values that cannot be parsed are rejected.
+func (w *ParquetWriter) Write(record []string) error {
+	for i := range w.header {
+		value := ""
+		if i < len(record) {
+			value = record[i]
+		}
+		field := w.builder.Field(i)
+		if w.timestamps[i] {
+			if strings.TrimSpace(value) == "" {
+				field.AppendNull()
+				continue
+			}
+			parsed, err := ParseTimestamp(value)
+			if err != nil {
+				return fmt.Errorf("column %s row %d: %w", w.header[i], w.rows+1, err)
+			}
+			field.(*array.TimestampBuilder).Append(arrow.Timestamp(parsed.UnixMicro()))
+			continue
+		}
+		field.(*array.StringBuilder).Append(value)
+	}
+	w.rows++
+	w.pending++
+	if w.pending >= parquetBatchRows {
+		return w.flush()
+	}
+	return nil
+}
+
+// Rows returns the number of records written so far.
+func (w *ParquetWriter) Rows() int64 {
+	return w.rows
+}
+
+// Close flushes pending rows and finalizes the file.
+func (w *ParquetWriter) Close() error {
+	flushErr := w.flush()
+	w.builder.Release()
+	closeErr := w.writer.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+func (w *ParquetWriter) flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+	record := w.builder.NewRecord()
+	defer record.Release()
+	w.pending = 0
+	return w.writer.Write(record)
+}
+
+// ConvertToParquet copies an event log into a Parquet file with typed timestamp columns.
+// Columns listed in timestampCols that are absent from the source are ignored.
+func ConvertToParquet(sourcePath string, parquetPath string, timestampCols []string, opts Options) (int64, error) {
+	source, err := Open(sourcePath, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	writer, err := NewParquetWriter(parquetPath, source.Header(), timestampCols)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		record, err := source.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			writer.Close()
+			os.Remove(parquetPath)
+			return writer.Rows(), err
+		}
+		if err := writer.Write(record); err != nil {
+			writer.Close()
+			os.Remove(parquetPath)
+			return writer.Rows(), err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return writer.Rows(), err
+	}
+	return writer.Rows(), nil
+}
+
+// ParseTimestamp parses the ISO-style timestamps written by the ingest stage, returning UTC.
+// Values without a zone are taken to be UTC.
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timestampLayouts {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
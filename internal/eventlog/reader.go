@@ -0,0 +1,141 @@
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Reader streams event log records as strings regardless of the storage format.
+type Reader interface {
+	Header() []string
+	// Read returns the next record or io.EOF when the log is exhausted.
+	Read() ([]string, error)
+	Close() error
+}
+
+// Chunked is implemented by readers that can hand out the raw text of their
+// records, so the records can be decoded in parallel.
+type Chunked interface {
+	// ReadChunk returns the text of the next whole records, at least size
+	// bytes unless the log ends first, or io.EOF after the last record.
+	ReadChunk(size int) ([]byte, error)
+	// ParseChunk decodes a chunk; it is safe for concurrent use.
+	ParseChunk(chunk []byte) ([][]string, error)
+}
+
+// AsChunked returns reader as a Chunked if its log can be read in chunks:
+// CSV in a dialect with single-byte delimiter, quote, and escape.
+func AsChunked(reader Reader) (Chunked, bool) {
+	r, ok := reader.(*csvReader)
+	if !ok || !r.reader.CanChunk() {
+		return nil, false
+	}
+	return r.reader, true
+}
+
+// Options controls how a log is opened.
+type Options struct {
+	// Dialect applies to CSV logs; the zero value is UTF-8 RFC 4180 CSV.
+	Dialect csvdialect.Dialect
+}
+
+// FormatOf returns the storage format implied by the path extension.
+func FormatOf(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".parquet") {
+		return FormatParquet
+	}
+	return FormatCSV
+}
+
+// Open opens a CSV or Parquet event log based on its extension.
+func Open(path string, opts Options) (Reader, error) {
+	if FormatOf(path) == FormatParquet {
+		return openParquet(path)
+	}
+	return openCSV(path, opts.Dialect)
+}
+
+// SampleColumn returns up to n non-empty values of the named column, in
+// file order.
+func SampleColumn(path string, opts Options, column string, n int) ([]string, error) {
+	reader, err := Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	idx := slices.Index(reader.Header(), column)
+	if idx < 0 {
+		return nil, fmt.Errorf("column %s not found in %s", column, filepath.Base(path))
+	}
+	var values []string
+	for len(values) < n {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if idx < len(record) && strings.TrimSpace(record[idx]) != "" {
+			values = append(values, strings.TrimSpace(record[idx]))
+		}
+	}
+	return values, nil
+}
+
+// StagePath swaps the extension of a stage log path to match the storage format.
+func StagePath(path string, format string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	if strings.EqualFold(format, FormatParquet) {
+		return base + ".parquet"
+	}
+	return base + ".csv"
+}
+
+type csvReader struct {
+	file   *os.File
+	reader *csvdialect.Reader
+	header []string
+}
+
+func openCSV(path string, dialect csvdialect.Dialect) (*csvReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := csvdialect.NewReader(file, dialect)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	header, err := reader.Header()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	return &csvReader{file: file, reader: reader, header: header}, nil
+}
+
+func (r *csvReader) Header() []string {
+	return r.header
+}
+
+func (r *csvReader) Read() ([]string, error) {
+	return r.reader.Read()
+}
+
+func (r *csvReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,203 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/timeformat"
+)
+
+// Logical column types recorded in a schema sidecar.
+const (
+	TypeString    = "string"
+	TypeInteger   = "integer"
+	TypeDecimal   = "decimal"
+	TypeFloat     = "float"
+	TypeBoolean   = "boolean"
+	TypeDate      = "date"
+	TypeTimestamp = "timestamp"
+	TypeBinary    = "binary"
+)
+
+// NullEncodingUnquotedEmpty marks CSVs where NULL is an unquoted empty field
+// and an empty string is written as "" (the PostgreSQL COPY convention).
+const NullEncodingUnquotedEmpty = "unquoted-empty"
+
+// Schema describes the columns of a CSV extract. It is written next to the CSV
+// as <name>.schema.json so later stages can rely on source types instead of
+// guessing from values.
+type Schema struct {
+	SchemaVersion int            `json:"schema_version"`
+	Source        string         `json:"source,omitempty"`
+	NullEncoding  string         `json:"null_encoding"`
+	Columns       []SchemaColumn `json:"columns"`
+	// Mapping is the event log layout declared by the query that produced
+	// the extract, such as a built-in process template.
+	Mapping *SchemaMapping `json:"mapping,omitempty"`
+}
+
+// SchemaMapping names the columns holding each event log field.
+type SchemaMapping struct {
+	CaseID          string `json:"case_id"`
+	Activity        string `json:"activity"`
+	Timestamp       string `json:"timestamp"`
+	Resource        string `json:"resource,omitempty"`
+	TimestampFormat string `json:"timestamp_format,omitempty"`
+}
+
+// SchemaColumn is one column of an extract. Timestamps are written as RFC 3339
+// with an offset, dates as YYYY-MM-DD, decimals exactly as the source returned
+// them, and binary values as hex.
+type SchemaColumn struct {
+	Name       string `json:"name"`
+	SourceType string `json:"source_type"`
+	Type       string `json:"type"`
+	Nullable   *bool  `json:"nullable,omitempty"`
+	Precision  int64  `json:"precision,omitempty"`
+	Scale      int64  `json:"scale,omitempty"`
+	// Layout is the Go layout of a temporal column whose values a pipeline
+	// stage rewrote, such as ingest normalising timestamps.
+	Layout string `json:"layout,omitempty"`
+}
+
+// Column returns the named column, matched case-insensitively.
+func (s *Schema) Column(name string) (SchemaColumn, bool) {
+	if s == nil {
+		return SchemaColumn{}, false
+	}
+	for _, column := range s.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return SchemaColumn{}, false
+}
+
+// Temporal reports whether the column holds dates or timestamps.
+func (c SchemaColumn) Temporal() bool {
+	return c.Type == TypeTimestamp || c.Type == TypeDate
+}
+
+// Format returns the layout of a temporal column's values in CSV: Layout when
+// set, else RFC 3339 for timestamps and YYYY-MM-DD for dates. Other columns
+// have none. Parquet files hold typed values and ignore it.
+func (c SchemaColumn) Format() string {
+	switch {
+	case !c.Temporal():
+		return ""
+	case c.Layout != "":
+		return c.Layout
+	case c.Type == TypeDate:
+		return "2006-01-02"
+	}
+	return time.RFC3339
+}
+
+// DeriveSchema writes the sidecar of a stage log that a step produced from
+// src, renaming columns (old name to new) and possibly rewriting values.
+// Columns missing from dst are dropped. Temporal columns record the layout
+// their values now have, and become strings when no single layout reads
+// them all. Without a sidecar for src, any stale sidecar of dst is removed.
+func DeriveSchema(src string, dst string, renames map[string]string) error {
+	schema, err := ReadSchema(src)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		if err := os.Remove(SchemaPath(dst)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	reader, err := Open(dst, Options{})
+	if err != nil {
+		return err
+	}
+	header := reader.Header()
+	reader.Close()
+
+	rename := func(name string) string {
+		for from, to := range renames {
+			if from != "" && strings.EqualFold(from, name) {
+				return to
+			}
+		}
+		return name
+	}
+	derived := Schema{Source: schema.Source, NullEncoding: schema.NullEncoding}
+	for _, column := range schema.Columns {
+		column.Name = rename(column.Name)
+		if !slices.Contains(header, column.Name) {
+			continue
+		}
+		if column.Temporal() && FormatOf(dst) == FormatCSV {
+			sample, err := SampleColumn(dst, Options{}, column.Name, schemaSample)
+			if err != nil {
+				return err
+			}
+			if inference := timeformat.Infer(sample); inference.Samples > 0 {
+				if inference.Layout == "" || inference.Ambiguous || inference.Confidence < 1 {
+					column.Type, column.Layout = TypeString, ""
+				} else {
+					column.Layout = inference.Layout
+				}
+			}
+		}
+		derived.Columns = append(derived.Columns, column)
+	}
+	if mapping := schema.Mapping; mapping != nil {
+		derived.Mapping = &SchemaMapping{
+			CaseID:          rename(mapping.CaseID),
+			Activity:        rename(mapping.Activity),
+			Timestamp:       rename(mapping.Timestamp),
+			Resource:        rename(mapping.Resource),
+			TimestampFormat: mapping.TimestampFormat,
+		}
+		if column, ok := derived.Column(derived.Mapping.Timestamp); ok && column.Layout != "" {
+			derived.Mapping.TimestampFormat = column.Layout
+		}
+	}
+	return WriteSchema(dst, derived)
+}
+
+// schemaSample bounds the values read to find a temporal column's layout.
+const schemaSample = 1000
+
+// SchemaPath returns the sidecar path for a CSV or Parquet file.
+func SchemaPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".schema.json"
+}
+
+// WriteSchema writes the sidecar for path.
+func WriteSchema(path string, schema Schema) error {
+	schema.SchemaVersion = 1
+	if schema.NullEncoding == "" {
+		schema.NullEncoding = NullEncodingUnquotedEmpty
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(SchemaPath(path), data, 0o644)
+}
+
+// ReadSchema loads the sidecar for path, returning nil when there is none.
+func ReadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(SchemaPath(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
@@ -0,0 +1,256 @@
+package eventlog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Columns written by Unpivot next to the case column.
+const (
+	ActivityColumn  = "activity"
+	TimestampColumn = "timestamp"
+)
+
+// Activity naming rules for wide timestamp columns.
+const (
+	// NamingColumn uses the column name unchanged.
+	NamingColumn = "column"
+	// NamingHumanize drops date suffixes and separators: assigned_at
+	// becomes "Assigned", firstResponseTime becomes "First response".
+	NamingHumanize = "humanize"
+)
+
+// nullValues are skipped like empty timestamps.
+var nullValues = []string{"null", "nan", "nat", "none", "n/a", "na", "-"}
+
+// IsNull reports whether a value is empty or a null marker such as NULL,
+// NaN, or n/a.
+func IsNull(value string) bool {
+	value = strings.TrimSpace(value)
+	return value == "" || slices.Contains(nullValues, strings.ToLower(value))
+}
+
+// timestampAffixes are dropped by NamingHumanize.
+var timestampAffixes = []string{"timestamp", "datetime", "date", "time", "at", "on", "ts", "dt"}
+
+// WideColumn is a timestamp column of a wide table and the activity it
+// records.
+type WideColumn struct {
+	Column   string
+	Activity string
+}
+
+// UnpivotOptions describes a wide table with one row per case.
+type UnpivotOptions struct {
+	Options
+	CaseColumn string
+	Columns    []WideColumn
+	// Keep lists columns copied onto every event of their row.
+	Keep []string
+}
+
+// UnpivotStats summarises an unpivot.
+type UnpivotStats struct {
+	Rows    int64 `json:"rows"`
+	Events  int64 `json:"events"`
+	Skipped int64 `json:"skipped_nulls"`
+	// Renamed maps kept columns that clash with an event log column to the
+	// name they are written under.
+	Renamed map[string]string `json:"renamed,omitempty"`
+}
+
+// ActivityName names the activity recorded by a timestamp column.
+func ActivityName(column string, rule string) string {
+	if rule != NamingHumanize {
+		return column
+	}
+	words := splitWords(column)
+	trimmed := slices.DeleteFunc(slices.Clone(words), func(word string) bool {
+		return slices.Contains(timestampAffixes, word)
+	})
+	if len(trimmed) > 0 {
+		words = trimmed
+	}
+	if len(words) == 0 {
+		return column
+	}
+	name := strings.Join(words, " ")
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// SharedActivities lists, in column order, the groups of columns whose
+// activity names collide; their events cannot be told apart in the log.
+func SharedActivities(columns []WideColumn) [][]string {
+	var groups [][]string
+	seen := map[string]int{}
+	for _, column := range columns {
+		i, ok := seen[column.Activity]
+		if !ok {
+			seen[column.Activity] = len(groups)
+			groups = append(groups, []string{column.Column})
+			continue
+		}
+		groups[i] = append(groups[i], column.Column)
+	}
+	return slices.DeleteFunc(groups, func(group []string) bool { return len(group) < 2 })
+}
+
+// splitWords splits snake_case, kebab-case, and camelCase names into
+// lower-case words.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.' || unicode.IsSpace(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// Unpivot turns a wide table into an event log at outputPath: one event per
+// non-empty timestamp column of each row, with columns case, activity,
+// timestamp, and the kept columns. Empty and null-like timestamps are
+// skipped; kept columns that clash with case, activity, or timestamp are
+// renamed (see UnpivotStats.Renamed).
+func Unpivot(path string, outputPath string, opts UnpivotOptions) (UnpivotStats, error) {
+	var stats UnpivotStats
+	if len(opts.Columns) == 0 {
+		return stats, fmt.Errorf("no timestamp columns to unpivot")
+	}
+	reader, err := Open(path, opts.Options)
+	if err != nil {
+		return stats, err
+	}
+	defer reader.Close()
+	header := reader.Header()
+	index := func(column string) (int, error) {
+		if i := slices.Index(header, column); i >= 0 {
+			return i, nil
+		}
+		return 0, fmt.Errorf("column %s not found in %s", column, filepath.Base(path))
+	}
+	caseIdx, err := index(opts.CaseColumn)
+	if err != nil {
+		return stats, err
+	}
+	timestampIdx := make([]int, len(opts.Columns))
+	for i, column := range opts.Columns {
+		if timestampIdx[i], err = index(column.Column); err != nil {
+			return stats, err
+		}
+	}
+	if opts.CaseColumn == ActivityColumn || opts.CaseColumn == TimestampColumn {
+		return stats, fmt.Errorf("case column cannot be named %s", opts.CaseColumn)
+	}
+	// Kept columns named like an event log column are renamed source_<name>
+	// (with more prefixes while that is taken too).
+	taken := append(slices.Clone(header), opts.CaseColumn, ActivityColumn, TimestampColumn)
+	keepIdx := make([]int, len(opts.Keep))
+	keepNames := make([]string, len(opts.Keep))
+	for i, column := range opts.Keep {
+		if keepIdx[i], err = index(column); err != nil {
+			return stats, err
+		}
+		keepNames[i] = column
+		if column == ActivityColumn || column == TimestampColumn || column == opts.CaseColumn {
+			name := "source_" + column
+			for slices.Contains(taken, name) {
+				name = "source_" + name
+			}
+			taken = append(taken, name)
+			keepNames[i] = name
+			if stats.Renamed == nil {
+				stats.Renamed = map[string]string{}
+			}
+			stats.Renamed[column] = name
+		}
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".tmp")
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return stats, err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	writer := csv.NewWriter(file)
+	outHeader := append([]string{opts.CaseColumn, ActivityColumn, TimestampColumn}, keepNames...)
+	if err := writer.Write(outHeader); err != nil {
+		return stats, err
+	}
+	event := make([]string, len(outHeader))
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.Rows++
+		event[0] = getField(record, caseIdx)
+		for i, idx := range keepIdx {
+			event[3+i] = getField(record, idx)
+		}
+		for i, column := range opts.Columns {
+			value := strings.TrimSpace(getField(record, timestampIdx[i]))
+			if IsNull(value) {
+				stats.Skipped++
+				continue
+			}
+			event[1], event[2] = column.Activity, value
+			if err := writer.Write(event); err != nil {
+				return stats, err
+			}
+			stats.Events++
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return stats, err
+	}
+	if err := file.Close(); err != nil {
+		return stats, err
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return stats, err
+	}
+	ok = true
+	return stats, nil
+}
+
+func getField(record []string, idx int) string {
+	if idx < len(record) {
+		return record[idx]
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package extract
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const checkpointFile = "extract_checkpoint.json"
+
+// checkpoint lists the chunks completed so far, per partition. Chunk paths are
+// relative to the extract directory.
+type checkpoint struct {
+	PlanSHA256 string                        `json:"plan_sha256"`
+	Partitions map[string]*partitionProgress `json:"partitions"`
+}
+
+type partitionProgress struct {
+	Done   bool    `json:"done"`
+	Chunks []Chunk `json:"chunks"`
+}
+
+// HasCheckpoint reports whether an earlier attempt left a checkpoint beside
+// outputPath.
+func HasCheckpoint(outputPath string) bool {
+	_, err := os.Stat(filepath.Join(filepath.Dir(outputPath), checkpointFile))
+	return err == nil
+}
+
+// loadCheckpoint returns nil when no checkpoint exists.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ckpt := &checkpoint{}
+	if err := json.Unmarshal(data, ckpt); err != nil {
+		return nil, err
+	}
+	if ckpt.Partitions == nil {
+		ckpt.Partitions = map[string]*partitionProgress{}
+	}
+	return ckpt, nil
+}
+
+// save writes the checkpoint atomically.
+func (c *checkpoint) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,437 @@
+// Package extract pulls database query results in keyset-paginated chunks and
+// date partitions, checkpointing each part file so a failed run can resume.
+package extract
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/state"
+)
+
+// DefaultParallel is the number of partitions extracted at once when the plan
+// does not set one.
+const DefaultParallel = 4
+
+// Plan describes one extraction. Chunking is enabled when ChunkSize > 0;
+// partitioning when Partitions is non-empty.
+type Plan struct {
+	Driver  db.Driver
+	DSN     string
+	Query   string
+	Args    []any
+	Timeout time.Duration
+
+	ChunkColumn string
+	// ChunkType is state.KindID or state.KindTimestamp.
+	ChunkType string
+	ChunkSize int
+
+	PartitionColumn string
+	Partitions      []db.Partition
+	Parallel        int
+}
+
+// Chunk is one completed part file.
+type Chunk struct {
+	Partition string `json:"partition,omitempty"`
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	Rows      int64  `json:"rows"`
+	SHA256    string `json:"sha256"`
+	LastKey   string `json:"last_key,omitempty"`
+}
+
+// Result summarises a finished extraction.
+type Result struct {
+	Rows int64
+	// Chunks lists every part file in merge order, with absolute paths.
+	Chunks []Chunk
+	// Resumed counts chunks carried over from an earlier attempt.
+	Resumed int
+}
+
+// Run extracts the plan into part files beside outputPath, then concatenates
+// them into outputPath. With resume, chunks recorded in the checkpoint of an
+// earlier attempt with the same plan are kept and extraction continues after
+// the last completed chunk of each partition.
+func Run(ctx context.Context, plan Plan, outputPath string, resume bool, progress func(string)) (Result, error) {
+	if plan.ChunkSize > 0 && plan.ChunkColumn == "" {
+		return Result{}, errors.New("chunk column is required for chunked extraction")
+	}
+	if progress == nil {
+		progress = func(string) {}
+	}
+	dir := filepath.Dir(outputPath)
+	r := &run{
+		plan:     plan,
+		dir:      dir,
+		partsDir: filepath.Join(dir, "parts"),
+		ckptPath: filepath.Join(dir, checkpointFile),
+		progress: progress,
+	}
+	hash := planHash(plan)
+	if resume {
+		ckpt, err := loadCheckpoint(r.ckptPath)
+		if err != nil {
+			return Result{}, err
+		}
+		if ckpt == nil {
+			progress("No checkpoint found; starting a fresh extract.")
+		} else if ckpt.PlanSHA256 != hash {
+			return Result{}, errors.New("checkpoint was written for a different query or chunk plan; rerun without --resume")
+		} else {
+			r.ckpt = ckpt
+		}
+	}
+	if r.ckpt == nil {
+		if err := os.RemoveAll(r.partsDir); err != nil {
+			return Result{}, err
+		}
+		r.ckpt = &checkpoint{PlanSHA256: hash, Partitions: map[string]*partitionProgress{}}
+	}
+	resumed := 0
+	for _, done := range r.ckpt.Partitions {
+		resumed += len(done.Chunks)
+	}
+
+	partitions := plan.Partitions
+	if len(partitions) == 0 {
+		partitions = []db.Partition{{}}
+	}
+	if err := r.runAll(ctx, partitions); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Resumed: resumed}
+	for _, partition := range partitions {
+		for _, chunk := range r.ckpt.Partitions[partitionKey(partition)].Chunks {
+			chunk.Path = filepath.Join(dir, filepath.FromSlash(chunk.Path))
+			result.Chunks = append(result.Chunks, chunk)
+		}
+	}
+	rows, err := mergeParts(result.Chunks, outputPath)
+	if err != nil {
+		return result, err
+	}
+	result.Rows = rows
+	// Part files stay for the manifest; the checkpoint only matters for a retry.
+	return result, os.Remove(r.ckptPath)
+}
+
+type run struct {
+	plan     Plan
+	dir      string
+	partsDir string
+	ckptPath string
+	progress func(string)
+	rows     atomic.Int64
+
+	mu   sync.Mutex
+	ckpt *checkpoint
+}
+
+func (r *run) runAll(ctx context.Context, partitions []db.Partition) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := r.plan.Parallel
+	if workers <= 0 {
+		workers = DefaultParallel
+	}
+	if workers > len(partitions) {
+		workers = len(partitions)
+	}
+	jobs := make(chan db.Partition)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partition := range jobs {
+				if err := r.extractPartition(ctx, partition); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for _, partition := range partitions {
+		select {
+		case jobs <- partition:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (r *run) extractPartition(ctx context.Context, partition db.Partition) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	key := partitionKey(partition)
+	r.mu.Lock()
+	done := r.ckpt.Partitions[key]
+	var completed []Chunk
+	if done != nil {
+		if done.Done {
+			r.mu.Unlock()
+			return nil
+		}
+		completed = append(completed, done.Chunks...)
+	}
+	r.mu.Unlock()
+
+	driver := r.plan.Driver.Info().Name
+	query := r.plan.Query
+	args := append([]any{}, r.plan.Args...)
+	if r.plan.PartitionColumn != "" && !partition.From.IsZero() {
+		var err error
+		query, err = db.PartitionQuery(driver, query, r.plan.PartitionColumn, len(args)+1)
+		if err != nil {
+			return err
+		}
+		args = append(args, partition.From, partition.To)
+	}
+	chunked := r.plan.ChunkSize > 0
+	lastKey := ""
+	if len(completed) > 0 {
+		lastKey = completed[len(completed)-1].LastKey
+	}
+
+	for index := len(completed) + 1; ; index++ {
+		chunkQuery, chunkArgs := query, args
+		if chunked {
+			var err error
+			chunkQuery, err = db.ChunkQuery(driver, query, r.plan.ChunkColumn, lastKey != "", len(args)+1, r.plan.ChunkSize)
+			if err != nil {
+				return err
+			}
+			if lastKey != "" {
+				arg, err := state.Watermark{Kind: r.plan.ChunkType, Value: lastKey}.Arg()
+				if err != nil {
+					return fmt.Errorf("chunk key: %w", err)
+				}
+				chunkArgs = append(append([]any{}, args...), arg)
+			}
+		}
+		rel := filepath.Join("parts", key, fmt.Sprintf("chunk_%05d.csv", index))
+		path := filepath.Join(r.dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		queryCtx, cancel := ctx, context.CancelFunc(func() {})
+		if r.plan.Timeout > 0 {
+			queryCtx, cancel = context.WithTimeout(ctx, r.plan.Timeout)
+		}
+		rows, err := r.plan.Driver.Extract(queryCtx, r.plan.DSN, chunkQuery, path, chunkArgs...)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("partition %s chunk %d: %w", key, index, err)
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		chunk := Chunk{Partition: partition.Name, Index: index, Path: filepath.ToSlash(rel), Rows: rows, SHA256: sum}
+		if chunked && rows > 0 {
+			value, err := lastValue(path, r.plan.ChunkColumn)
+			if err != nil {
+				return fmt.Errorf("partition %s chunk %d: %w", key, index, err)
+			}
+			chunk.LastKey = value
+			lastKey = value
+		}
+		finished := !chunked || rows < int64(r.plan.ChunkSize)
+		if err := r.record(key, chunk, finished); err != nil {
+			return err
+		}
+		total := r.rows.Add(rows)
+		r.progress(fmt.Sprintf("Extracted %s chunk %d (%d rows; %d total)", key, index, rows, total))
+		if finished {
+			return nil
+		}
+	}
+}
+
+// record appends a completed chunk and persists the checkpoint.
+func (r *run) record(key string, chunk Chunk, finished bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	progress := r.ckpt.Partitions[key]
+	if progress == nil {
+		progress = &partitionProgress{}
+		r.ckpt.Partitions[key] = progress
+	}
+	progress.Chunks = append(progress.Chunks, chunk)
+	progress.Done = finished
+	return r.ckpt.save(r.ckptPath)
+}
+
+func partitionKey(partition db.Partition) string {
+	if partition.Name == "" {
+		return "all"
+	}
+	return partition.Name
+}
+
+// planHash identifies the query, arguments, and chunk layout so a checkpoint
+// is only resumed by the plan that wrote it.
+func planHash(plan Plan) string {
+	var b strings.Builder
+	b.WriteString(db.RenderedQuery{SQL: plan.Query, Args: plan.Args}.Hash())
+	fmt.Fprintf(&b, "|%s|%s|%d|%s", plan.ChunkColumn, plan.ChunkType, plan.ChunkSize, plan.PartitionColumn)
+	for _, partition := range plan.Partitions {
+		fmt.Fprintf(&b, "|%s:%s:%s", partition.Name, partition.From.UTC().Format(time.RFC3339), partition.To.UTC().Format(time.RFC3339))
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// lastValue returns column's value in the final row of a CSV part file.
+func lastValue(path string, column string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return "", err
+	}
+	idx := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return "", fmt.Errorf("chunk column not found in extract: %s", column)
+	}
+	last := ""
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if idx < len(record) {
+			last = record[idx]
+		}
+	}
+	if last == "" {
+		return "", fmt.Errorf("chunk column %s is empty in the last row; keyset chunking needs a non-null unique key", column)
+	}
+	return last, nil
+}
+
+// mergeParts concatenates part files into outputPath, keeping one header. Rows
+// are copied byte for byte so the NULL/empty-string quoting the drivers write
+// survives, and the first part's schema sidecar is copied alongside.
+func mergeParts(chunks []Chunk, outputPath string) (int64, error) {
+	tmp := outputPath + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	var (
+		header string
+		rows   int64
+	)
+	for _, chunk := range chunks {
+		if err := appendPart(out, chunk.Path, &header); err != nil {
+			out.Close()
+			return rows, err
+		}
+		rows += chunk.Rows
+	}
+	if err := out.Close(); err != nil {
+		return rows, err
+	}
+	if err := os.Rename(tmp, outputPath); err != nil {
+		return rows, err
+	}
+	if len(chunks) == 0 {
+		return rows, nil
+	}
+	schema, err := eventlog.ReadSchema(chunks[0].Path)
+	if err != nil || schema == nil {
+		return rows, err
+	}
+	return rows, eventlog.WriteSchema(outputPath, *schema)
+}
+
+func appendPart(out io.Writer, path string, header *string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	columns, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	joined := strings.Join(columns, "\x00")
+	if *header != "" && joined != *header {
+		return fmt.Errorf("part file %s has different columns than earlier parts", path)
+	}
+	offset := reader.InputOffset()
+	if *header != "" {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	} else if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	*header = joined
+	_, err = io.Copy(out, file)
+	return err
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
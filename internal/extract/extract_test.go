@@ -0,0 +1,99 @@
+package extract
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/state"
+)
+
+// fakeDriver serves ids 1..rows, honouring the keyset bound and LIMIT, and
+// fails once on the chunk listed in failAfter.
+type fakeDriver struct {
+	db.Driver
+	rows      int
+	failAfter int64
+	calls     []string
+}
+
+func (f *fakeDriver) Info() db.Info {
+	return db.Info{Name: "fake-extract", Label: "Fake", Dialect: "postgres"}
+}
+
+func (f *fakeDriver) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (f *fakeDriver) Extract(ctx context.Context, dsn string, query string, outputPath string, args ...any) (int64, error) {
+	var after int64
+	if strings.Contains(query, "pm_chunk WHERE") {
+		after = args[len(args)-1].(int64)
+	}
+	f.calls = append(f.calls, fmt.Sprint(after))
+	if f.failAfter != 0 && after == f.failAfter {
+		f.failAfter = 0
+		return 0, errors.New("connection reset")
+	}
+	var limit int
+	fmt.Sscanf(query[strings.LastIndex(query, "LIMIT"):], "LIMIT %d", &limit)
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"id", "activity"})
+	var n int64
+	for id := after + 1; id <= int64(f.rows) && int(n) < limit; id++ {
+		writer.Write([]string{fmt.Sprint(id), "step"})
+		n++
+	}
+	writer.Flush()
+	return n, writer.Error()
+}
+
+func TestRunChunksAndResumes(t *testing.T) {
+	fake := &fakeDriver{rows: 7, failAfter: 3}
+	db.Register(fake)
+	output := filepath.Join(t.TempDir(), "source_extract.csv")
+	plan := Plan{Driver: fake, Query: "SELECT id, activity FROM events", ChunkColumn: "id", ChunkType: state.KindID, ChunkSize: 3}
+
+	if _, err := Run(context.Background(), plan, output, false, nil); err == nil {
+		t.Fatal("expected the second chunk to fail")
+	}
+	changed := plan
+	changed.ChunkSize = 4
+	if _, err := Run(context.Background(), changed, output, true, nil); err == nil {
+		t.Fatal("expected resume with a different plan to fail")
+	}
+	result, err := Run(context.Background(), plan, output, true, nil)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if result.Rows != 7 || len(result.Chunks) != 3 || result.Resumed != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if got := strings.Join(fake.calls, ","); got != "0,3,3,6" {
+		t.Fatalf("keys requested = %s", got)
+	}
+	for _, chunk := range result.Chunks {
+		if len(chunk.SHA256) != 64 {
+			t.Fatalf("chunk %d has no checksum", chunk.Index)
+		}
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 8 {
+		t.Fatalf("merged extract has %d lines:\n%s", lines, data)
+	}
+}
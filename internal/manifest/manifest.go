@@ -17,15 +17,21 @@ import (
 const SchemaVersion = 1
 
 type Manifest struct {
-	SchemaVersion  int         `json:"schema_version"`
-	RunID          string      `json:"run_id"`
-	StartedAt      string      `json:"started_at"`
-	CompletedAt    string      `json:"completed_at,omitempty"`
-	Status         string      `json:"status"`
-	ConfigSnapshot string      `json:"config_snapshot,omitempty"`
-	Steps          []Step      `json:"steps,omitempty"`
-	Inputs         []FileEntry `json:"inputs,omitempty"`
-	Outputs        []FileEntry `json:"outputs,omitempty"`
+	SchemaVersion  int           `json:"schema_version"`
+	RunID          string        `json:"run_id"`
+	StartedAt      string        `json:"started_at"`
+	CompletedAt    string        `json:"completed_at,omitempty"`
+	Status         string        `json:"status"`
+	ConfigSnapshot string        `json:"config_snapshot,omitempty"`
+	Steps          []Step        `json:"steps,omitempty"`
+	Inputs         []FileEntry   `json:"inputs,omitempty"`
+	Outputs        []FileEntry   `json:"outputs,omitempty"`
+	Deltas         []Delta       `json:"deltas,omitempty"`
+	Queries        []QueryEntry  `json:"queries,omitempty"`
+	Chunks         []Chunk       `json:"chunks,omitempty"`
+	Objects        []Object      `json:"objects,omitempty"`
+	Streams        []StreamRange `json:"streams,omitempty"`
+	SourceFiles    []SourceFile  `json:"source_files,omitempty"`
 }
 
 type Step struct {
@@ -43,6 +49,77 @@ type FileEntry struct {
 	ModifiedAt string `json:"modified_at"`
 }
 
+// Delta records an incremental extract appended to an earlier log.
+type Delta struct {
+	Connector       string `json:"connector"`
+	WatermarkColumn string `json:"watermark_column"`
+	WatermarkKind   string `json:"watermark_kind"`
+	From            string `json:"from,omitempty"`
+	To              string `json:"to,omitempty"`
+	BaseLog         string `json:"base_log,omitempty"`
+	RowsExtracted   int64  `json:"rows_extracted"`
+	RowsAppended    int64  `json:"rows_appended"`
+	DuplicateRows   int64  `json:"duplicate_rows"`
+	RecordedAt      string `json:"recorded_at"`
+}
+
+// QueryEntry records the extraction query behind a run's inputs.
+type QueryEntry struct {
+	Connector  string            `json:"connector"`
+	Name       string            `json:"name,omitempty"`
+	SHA256     string            `json:"sha256"`
+	Params     map[string]string `json:"params,omitempty"`
+	RecordedAt string            `json:"recorded_at"`
+}
+
+// Chunk records one part file of a chunked or partitioned extract.
+type Chunk struct {
+	Connector string `json:"connector"`
+	Partition string `json:"partition,omitempty"`
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	Rows      int64  `json:"rows"`
+	SHA256    string `json:"sha256"`
+	LastKey   string `json:"last_key,omitempty"`
+}
+
+// Object records a file downloaded from object storage into the run.
+type Object struct {
+	Connector   string `json:"connector"`
+	URI         string `json:"uri"`
+	Path        string `json:"path"`
+	SizeBytes   int64  `json:"size_bytes"`
+	ETag        string `json:"etag,omitempty"`
+	SHA256      string `json:"sha256"`
+	MD5Verified bool   `json:"md5_verified"`
+	RecordedAt  string `json:"recorded_at"`
+}
+
+// StreamRange records the offsets consumed from one topic partition; the
+// end offset is exclusive.
+type StreamRange struct {
+	Connector   string `json:"connector"`
+	Topic       string `json:"topic"`
+	Partition   int    `json:"partition"`
+	StartOffset int64  `json:"start_offset"`
+	EndOffset   int64  `json:"end_offset"`
+	Messages    int64  `json:"messages"`
+	RecordedAt  string `json:"recorded_at"`
+}
+
+// SourceFile records one file combined into a multi-file ingest and how its
+// columns differed from the first file's.
+type SourceFile struct {
+	Connector  string            `json:"connector"`
+	Path       string            `json:"path"`
+	SourceFile string            `json:"source_file"`
+	Rows       int64             `json:"rows"`
+	Renamed    map[string]string `json:"renamed,omitempty"`
+	Missing    []string          `json:"missing,omitempty"`
+	Extra      []string          `json:"extra,omitempty"`
+	RecordedAt string            `json:"recorded_at"`
+}
+
 type Manager struct {
 	path    string
 	baseDir string
@@ -102,6 +179,110 @@ func (m *Manager) SetConfigSnapshot(path string) error {
 	return m.save(manifest)
 }
 
+func (m *Manager) AddDelta(delta Delta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	manifest, err := m.load()
+	if err != nil {
+		return err
+	}
+	if delta.RecordedAt == "" {
+		delta.RecordedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	manifest.Deltas = append(manifest.Deltas, delta)
+	return m.save(manifest)
+}
+
+func (m *Manager) AddQuery(entry QueryEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	manifest, err := m.load()
+	if err != nil {
+		return err
+	}
+	if entry.RecordedAt == "" {
+		entry.RecordedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	manifest.Queries = append(manifest.Queries, entry)
+	return m.save(manifest)
+}
+
+// AddChunks records extract part files; paths are stored relative to the run
+// output directory.
+func (m *Manager) AddChunks(chunks []Chunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	manifest, err := m.load()
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if rel, err := filepath.Rel(m.baseDir, chunk.Path); err == nil {
+			chunk.Path = filepath.ToSlash(rel)
+		}
+		manifest.Chunks = append(manifest.Chunks, chunk)
+	}
+	return m.save(manifest)
+}
+
+// AddObjects records downloaded objects; paths are stored relative to the
+// run output directory.
+func (m *Manager) AddObjects(objects []Object) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	manifest, err := m.load()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, object := range objects {
+		if rel, err := filepath.Rel(m.baseDir, object.Path); err == nil {
+			object.Path = filepath.ToSlash(rel)
+		}
+		if object.RecordedAt == "" {
+			object.RecordedAt = now
+		}
+		manifest.Objects = append(manifest.Objects, object)
+	}
+	return m.save(manifest)
+}
+
+// AddStreams records consumed partition offset ranges.
+func (m *Manager) AddStreams(ranges []StreamRange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	manifest, err := m.load()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, streamRange := range ranges {
+		if streamRange.RecordedAt == "" {
+			streamRange.RecordedAt = now
+		}
+		manifest.Streams = append(manifest.Streams, streamRange)
+	}
+	return m.save(manifest)
+}
+
+// AddSourceFiles records the files combined into the ingested log.
+func (m *Manager) AddSourceFiles(files []SourceFile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	manifest, err := m.load()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, file := range files {
+		if file.RecordedAt == "" {
+			file.RecordedAt = now
+		}
+		manifest.SourceFiles = append(manifest.SourceFiles, file)
+	}
+	return m.save(manifest)
+}
+
 func (m *Manager) AddInputs(paths []string) error {
 	return m.addFiles(paths, true)
 }
@@ -0,0 +1,106 @@
+package object
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+type azureStore struct {
+	client     *azblob.Client
+	serviceURL string
+	container  string
+}
+
+// newAzureStore treats a secret containing "sig=" as a SAS token and any
+// other secret as the account key; without a secret the container must allow
+// anonymous reads. The endpoint defaults to the public cloud; Azurite uses
+// http://127.0.0.1:10000/<account>.
+func newAzureStore(cfg config.ObjectConfig, secret string) (Store, error) {
+	serviceURL := strings.TrimSuffix(cfg.Endpoint, "/")
+	if serviceURL == "" {
+		if cfg.Account == "" {
+			return nil, fmt.Errorf("azure object connector requires a storage account or endpoint")
+		}
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net", cfg.Account)
+	}
+	var (
+		client *azblob.Client
+		err    error
+	)
+	switch {
+	case strings.Contains(secret, "sig="):
+		client, err = azblob.NewClientWithNoCredential(serviceURL+"/?"+strings.TrimPrefix(secret, "?"), nil)
+	case secret != "":
+		if cfg.Account == "" {
+			return nil, fmt.Errorf("azure account key requires the storage account name")
+		}
+		cred, credErr := azblob.NewSharedKeyCredential(cfg.Account, secret)
+		if credErr != nil {
+			return nil, credErr
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(serviceURL+"/", cred, nil)
+	default:
+		client, err = azblob.NewClientWithNoCredential(serviceURL+"/", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &azureStore{client: client, serviceURL: serviceURL, container: cfg.Bucket}, nil
+}
+
+func (s *azureStore) URI(key string) string {
+	return s.serviceURL + "/" + s.container + "/" + key
+}
+
+func (s *azureStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	options := &azblob.ListBlobsFlatOptions{}
+	if prefix != "" {
+		options.Prefix = &prefix
+	}
+	out := []Object{}
+	pages := s.client.NewListBlobsFlatPager(s.container, options)
+	for pages.More() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			obj := Object{Key: *item.Name}
+			if props := item.Properties; props != nil {
+				if props.ContentLength != nil {
+					obj.Size = *props.ContentLength
+				}
+				if props.ETag != nil {
+					obj.ETag = strings.Trim(string(*props.ETag), `"`)
+				}
+				if props.LastModified != nil {
+					obj.LastModified = *props.LastModified
+				}
+			}
+			out = append(out, obj)
+		}
+	}
+	return out, nil
+}
+
+// Open downloads the whole blob, so the service returns the Content-MD5 set
+// at upload time, if any.
+func (s *azureStore) Open(ctx context.Context, key string) (*Body, error) {
+	resp, err := s.client.DownloadStream(ctx, s.container, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	body := &Body{ReadCloser: resp.NewRetryReader(ctx, nil)}
+	if len(resp.ContentMD5) > 0 {
+		body.MD5 = hex.EncodeToString(resp.ContentMD5)
+	}
+	return body, nil
+}
@@ -0,0 +1,179 @@
+// Package object lists and downloads source files from object storage: S3
+// and S3-compatible buckets (MinIO, for example) and Azure Blob containers.
+// Downloads are read-only and checksummed as they are written.
+package object
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// Supported providers.
+const (
+	ProviderS3    = "s3"
+	ProviderAzure = "azure"
+)
+
+// Providers returns the supported provider names.
+func Providers() []string {
+	return []string{ProviderS3, ProviderAzure}
+}
+
+// Object is one listed file.
+type Object struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// Body is an object's content together with the MD5 digest (hex) the
+// service vouches for, when it reports one.
+type Body struct {
+	io.ReadCloser
+	MD5 string
+}
+
+// Store is a read-only view of one bucket or container.
+type Store interface {
+	// URI names a key for messages and the manifest, e.g. s3://bucket/key.
+	URI(key string) string
+	// List returns the objects under prefix, including nested keys.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Open(ctx context.Context, key string) (*Body, error)
+}
+
+// New returns the store for cfg. The secret is the S3 secret access key, or
+// the Azure account key or SAS token; an empty secret falls back to the
+// default AWS credential chain or anonymous Azure access.
+func New(ctx context.Context, cfg config.ObjectConfig, secret string) (Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("object connector requires a bucket or container")
+	}
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderS3:
+		return newS3Store(ctx, cfg, secret)
+	case ProviderAzure:
+		return newAzureStore(cfg, secret)
+	default:
+		return nil, fmt.Errorf("unsupported object storage provider: %s", cfg.Provider)
+	}
+}
+
+// Match returns the objects under prefix whose key matches glob. A pattern
+// containing "/" is matched against the key relative to prefix, otherwise
+// against the base name; an empty glob matches every object. Folder markers
+// (keys ending in "/") are skipped.
+func Match(objects []Object, prefix string, glob string) ([]Object, error) {
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+	out := []Object{}
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, "/") || !strings.HasPrefix(obj.Key, prefix) {
+			continue
+		}
+		if glob != "" {
+			name := RelativeKey(obj.Key, prefix)
+			if !strings.Contains(glob, "/") {
+				name = path.Base(obj.Key)
+			}
+			if ok, _ := path.Match(glob, name); !ok {
+				continue
+			}
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// RelativeKey strips prefix and any leading slash from key.
+func RelativeKey(key string, prefix string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(key, prefix), "/")
+}
+
+// Downloaded is an object written to local disk.
+type Downloaded struct {
+	Object
+	URI    string
+	Path   string
+	SHA256 string
+	// MD5Verified is true when the service reported an MD5 digest and the
+	// downloaded bytes matched it.
+	MD5Verified bool
+}
+
+// Download writes each object below dir, keeping its key relative to prefix
+// as the local path. Each file is hashed while it is written and checked
+// against the service's MD5 digest when there is one; a mismatch fails the
+// download and leaves no file behind.
+func Download(ctx context.Context, store Store, objects []Object, prefix string, dir string) ([]Downloaded, error) {
+	out := make([]Downloaded, 0, len(objects))
+	for _, obj := range objects {
+		rel := filepath.FromSlash(RelativeKey(obj.Key, prefix))
+		if rel == "" || !filepath.IsLocal(rel) {
+			return out, fmt.Errorf("object key %s cannot be stored locally", obj.Key)
+		}
+		target := filepath.Join(dir, rel)
+		item, err := download(ctx, store, obj, target)
+		if err != nil {
+			return out, fmt.Errorf("download %s: %w", store.URI(obj.Key), err)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func download(ctx context.Context, store Store, obj Object, target string) (Downloaded, error) {
+	body, err := store.Open(ctx, obj.Key)
+	if err != nil {
+		return Downloaded{}, err
+	}
+	defer body.Close()
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return Downloaded{}, err
+	}
+	tmp := target + ".partial"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return Downloaded{}, err
+	}
+	defer os.Remove(tmp)
+	sum, md5sum := sha256.New(), md5.New()
+	size, err := io.Copy(io.MultiWriter(file, sum, md5sum), body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Downloaded{}, err
+	}
+	verified := false
+	if body.MD5 != "" {
+		if got := hex.EncodeToString(md5sum.Sum(nil)); !strings.EqualFold(got, body.MD5) {
+			return Downloaded{}, fmt.Errorf("MD5 mismatch: service reported %s, downloaded %s", body.MD5, got)
+		}
+		verified = true
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return Downloaded{}, err
+	}
+	obj.Size = size
+	return Downloaded{
+		Object:      obj,
+		URI:         store.URI(obj.Key),
+		Path:        target,
+		SHA256:      hex.EncodeToString(sum.Sum(nil)),
+		MD5Verified: verified,
+	}, nil
+}
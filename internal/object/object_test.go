@@ -0,0 +1,233 @@
+package object
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// fakeS3 serves path-style ListObjectsV2 and GetObject for one bucket, like a
+// local MinIO. Keys listed in badETag report a digest that does not match.
+type fakeS3 struct {
+	objects map[string]string
+	badETag map[string]bool
+}
+
+func (f *fakeS3) etag(key string) string {
+	if f.badETag[key] {
+		return strings.Repeat("0", 32)
+	}
+	sum := md5.Sum([]byte(f.objects[key]))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/landing")
+	if key == "" || key == "/" {
+		prefix := r.URL.Query().Get("prefix")
+		var b strings.Builder
+		b.WriteString(`<ListBucketResult><Name>landing</Name><IsTruncated>false</IsTruncated>`)
+		for name, body := range f.objects {
+			if strings.HasPrefix(name, prefix) {
+				fmt.Fprintf(&b, `<Contents><Key>%s</Key><Size>%d</Size><ETag>"%s"</ETag></Contents>`, name, len(body), f.etag(name))
+			}
+		}
+		b.WriteString(`</ListBucketResult>`)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(b.String()))
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+	body, ok := f.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+		return
+	}
+	w.Header().Set("ETag", `"`+f.etag(key)+`"`)
+	w.Write([]byte(body))
+}
+
+func TestS3ListMatchAndDownload(t *testing.T) {
+	fake := &fakeS3{
+		objects: map[string]string{
+			"exports/2024/01/events.csv": "case_id,activity\n1,Create\n",
+			"exports/2024/02/events.csv": "case_id,activity\n2,Approve\n",
+			"exports/2024/02/readme.txt": "notes",
+			"exports/broken.csv":         "case_id\n",
+			"other/events.csv":           "ignored",
+		},
+		badETag: map[string]bool{"exports/broken.csv": true},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	store, err := New(ctx, config.ObjectConfig{
+		Provider: ProviderS3, Bucket: "landing", Endpoint: server.URL, Region: "eu-west-1", Account: "minio",
+	}, "minio-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := store.List(ctx, "exports/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 4 {
+		t.Fatalf("listed %d objects, want 4", len(listed))
+	}
+	matched, err := Match(listed, "exports/", "2024/*/events.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched) != 2 {
+		t.Fatalf("matched %+v", matched)
+	}
+	if byName, _ := Match(listed, "exports/", "*.csv"); len(byName) != 3 {
+		t.Fatalf("base-name glob matched %d objects, want 3", len(byName))
+	}
+
+	dir := t.TempDir()
+	downloaded, err := Download(ctx, store, matched, "exports/", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range downloaded {
+		data, err := os.ReadFile(item.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		if item.SHA256 != hex.EncodeToString(sum[:]) || !item.MD5Verified || string(data) != fake.objects[item.Key] {
+			t.Fatalf("download %+v has content %q", item, data)
+		}
+	}
+	if want := filepath.Join(dir, "2024", "01", "events.csv"); downloaded[0].Path != want && downloaded[1].Path != want {
+		t.Fatalf("paths %s, %s do not keep the key layout", downloaded[0].Path, downloaded[1].Path)
+	}
+
+	broken := []Object{{Key: "exports/broken.csv"}}
+	if _, err := Download(ctx, store, broken, "exports/", dir); err == nil || !strings.Contains(err.Error(), "MD5 mismatch") {
+		t.Fatalf("expected an MD5 mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "broken.csv")); !os.IsNotExist(err) {
+		t.Fatalf("mismatched download left a file behind: %v", err)
+	}
+	escaping := []Object{{Key: "exports/../../etc/passwd"}}
+	if _, err := Download(ctx, store, escaping, "exports/", dir); err == nil {
+		t.Fatal("expected a key outside the download folder to fail")
+	}
+}
+
+// fakeAzure serves List Blobs and Get Blob for one container of an
+// Azurite-style account. Blobs listed in badMD5 report a digest that does
+// not match.
+type fakeAzure struct {
+	blobs  map[string]string
+	badMD5 map[string]bool
+}
+
+func (f *fakeAzure) md5(name string) string {
+	sum := md5.Sum([]byte(f.blobs[name]))
+	if f.badMD5[name] {
+		sum = [16]byte{}
+	}
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const modified = "Mon, 01 Jan 2024 08:00:00 GMT"
+	name := strings.TrimPrefix(r.URL.Path, "/devstoreaccount1/landing")
+	if r.URL.Query().Get("comp") == "list" && (name == "" || name == "/") {
+		prefix := r.URL.Query().Get("prefix")
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="landing"><Blobs>`)
+		for blob, body := range f.blobs {
+			if strings.HasPrefix(blob, prefix) {
+				fmt.Fprintf(&b, `<Blob><Name>%s</Name><Properties><Last-Modified>%s</Last-Modified><Etag>"0x1"</Etag><Content-Length>%d</Content-Length><Content-MD5>%s</Content-MD5><BlobType>BlockBlob</BlobType></Properties></Blob>`, blob, modified, len(body), f.md5(blob))
+			}
+		}
+		b.WriteString(`</Blobs><NextMarker /></EnumerationResults>`)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(b.String()))
+		return
+	}
+	name = strings.TrimPrefix(name, "/")
+	body, ok := f.blobs[name]
+	if !ok {
+		w.Header().Set("x-ms-error-code", "BlobNotFound")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-MD5", f.md5(name))
+	w.Header().Set("ETag", `"0x1"`)
+	w.Header().Set("Last-Modified", modified)
+	w.Header().Set("x-ms-blob-type", "BlockBlob")
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	w.Write([]byte(body))
+}
+
+func TestAzureListMatchAndDownload(t *testing.T) {
+	fake := &fakeAzure{
+		blobs: map[string]string{
+			"exports/2024/01/events.csv": "case_id,activity\n1,Create\n",
+			"exports/2024/02/events.csv": "case_id,activity\n2,Approve\n",
+			"exports/broken.csv":         "case_id\n",
+			"other/events.csv":           "ignored",
+		},
+		badMD5: map[string]bool{"exports/broken.csv": true},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	// Azurite's published development account key.
+	key := "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	store, err := New(ctx, config.ObjectConfig{
+		Provider: ProviderAzure, Bucket: "landing", Endpoint: server.URL + "/devstoreaccount1", Account: "devstoreaccount1",
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := store.List(ctx, "exports/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 3 || listed[0].ETag != "0x1" || listed[0].LastModified.IsZero() {
+		t.Fatalf("listed %+v", listed)
+	}
+	matched, err := Match(listed, "exports/", "2024/*/events.csv")
+	if err != nil || len(matched) != 2 {
+		t.Fatalf("matched %+v, %v", matched, err)
+	}
+
+	dir := t.TempDir()
+	downloaded, err := Download(ctx, store, matched, "exports/", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range downloaded {
+		data, err := os.ReadFile(item.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !item.MD5Verified || string(data) != fake.blobs[item.Key] || item.URI != server.URL+"/devstoreaccount1/landing/"+item.Key {
+			t.Fatalf("download %+v has content %q", item, data)
+		}
+	}
+	broken := []Object{{Key: "exports/broken.csv"}}
+	if _, err := Download(ctx, store, broken, "exports/", dir); err == nil || !strings.Contains(err.Error(), "MD5 mismatch") {
+		t.Fatalf("expected an MD5 mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package object
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// newS3Store uses static keys when both the access key ID and secret are
+// given and the default AWS credential chain (env, profile, instance role)
+// otherwise. A custom endpoint switches to path-style addressing, which MinIO
+// and most S3-compatible services expect.
+func newS3Store(ctx context.Context, cfg config.ObjectConfig, secret string) (Store, error) {
+	opts := []func(*awsconfig.LoadOptions) error{}
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	if cfg.Account != "" && secret != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.Account, secret, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if awsCfg.Region == "" {
+		awsCfg.Region = "us-east-1"
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *s3Store) URI(key string) string {
+	return "s3://" + s.bucket + "/" + key
+}
+
+func (s *s3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(s.bucket)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	out := []Object{}
+	pages := s3.NewListObjectsV2Paginator(s.client, input)
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Contents {
+			obj := Object{
+				Key:  aws.ToString(item.Key),
+				Size: aws.ToInt64(item.Size),
+				ETag: strings.Trim(aws.ToString(item.ETag), `"`),
+			}
+			if item.LastModified != nil {
+				obj.LastModified = *item.LastModified
+			}
+			out = append(out, obj)
+		}
+	}
+	return out, nil
+}
+
+func (s *s3Store) Open(ctx context.Context, key string) (*Body, error) {
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	body := &Body{ReadCloser: resp.Body}
+	// The ETag is the content MD5 only for single-part uploads that are
+	// unencrypted or use SSE-S3; multipart ETags carry a "-N" suffix.
+	etag := strings.Trim(aws.ToString(resp.ETag), `"`)
+	kms := resp.ServerSideEncryption == types.ServerSideEncryptionAwsKms || resp.ServerSideEncryption == types.ServerSideEncryptionAwsKmsDsse
+	if len(etag) == 32 && !kms && resp.SSECustomerAlgorithm == nil {
+		if _, err := hex.DecodeString(etag); err == nil {
+			body.MD5 = etag
+		}
+	}
+	return body, nil
+}
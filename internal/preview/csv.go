@@ -1,12 +1,12 @@
 package preview
 
 import (
-	"bufio"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
 )
 
 // CSVPreview contains headers and sample rows.
@@ -14,26 +14,35 @@ type CSVPreview struct {
 	Headers []string
 	Samples [][]string
 	Rows    int
+	// Parts lists the sheets of a workbook or the CSV members of an archive;
+	// Part is the one previewed.
+	Parts []string
+	Part  string
 }
 
 // PreviewCSV reads headers and up to sampleRows rows. If countAll is true, it counts all rows.
 func PreviewCSV(path string, delimiter string, sampleRows int, countAll bool) (CSVPreview, error) {
+	return previewCSVFile(path, csvdialect.Dialect{Delimiter: delimiter}, sampleRows, countAll)
+}
+
+func previewCSVFile(path string, dialect csvdialect.Dialect, sampleRows int, countAll bool) (CSVPreview, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return CSVPreview{}, err
 	}
 	defer file.Close()
+	return previewCSVReader(file, dialect, sampleRows, countAll)
+}
 
-	reader := csv.NewReader(bufio.NewReader(file))
-	reader.FieldsPerRecord = -1
-	if delimiter != "" {
-		runes := []rune(delimiter)
-		if len(runes) > 0 {
-			reader.Comma = runes[0]
-		}
+// previewCSVReader previews r as delimited text in the dialect; files
+// without a header row get generated column names.
+func previewCSVReader(r io.Reader, dialect csvdialect.Dialect, sampleRows int, countAll bool) (CSVPreview, error) {
+	reader, err := csvdialect.NewReader(r, dialect)
+	if err != nil {
+		return CSVPreview{}, err
 	}
 
-	headers, err := reader.Read()
+	headers, err := reader.Header()
 	if err != nil {
 		return CSVPreview{}, err
 	}
@@ -62,13 +71,17 @@ func PreviewCSV(path string, delimiter string, sampleRows int, countAll bool) (C
 
 // FormatSample renders a sample block for CLI output.
 func FormatSample(preview CSVPreview) string {
+	lines := []string{}
+	if len(preview.Parts) > 1 {
+		lines = append(lines, fmt.Sprintf("[INFO] Contains: %s (previewing %s)", strings.Join(preview.Parts, ", "), preview.Part))
+	}
 	if len(preview.Headers) == 0 {
-		return "[INFO] No headers found."
+		return strings.Join(append(lines, "[INFO] No headers found."), "\n")
 	}
-	lines := []string{
+	lines = append(lines,
 		"[INFO] Columns:",
-		"  " + strings.Join(preview.Headers, ", "),
-	}
+		"  "+strings.Join(preview.Headers, ", "),
+	)
 	if len(preview.Samples) > 0 {
 		lines = append(lines, "[INFO] Sample rows:")
 		for _, row := range preview.Samples {
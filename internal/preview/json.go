@@ -0,0 +1,178 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
+)
+
+// PreviewJSON previews a JSON array of records, or a sequence of records
+// (JSON lines). Nested objects are flattened to dotted column names in the
+// order their keys appear; arrays are kept as compact JSON. Columns are the
+// union of the sampled records' fields.
+func PreviewJSON(path string, jsonLines bool, encoding string, sampleRows int, countAll bool) (CSVPreview, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer file.Close()
+	decoded, err := csvdialect.Decode(file, encoding)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	reader := bufio.NewReader(decoded)
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	array := false
+	if !jsonLines {
+		first, err := firstNonSpace(reader)
+		if err != nil {
+			return CSVPreview{}, err
+		}
+		if first == '[' {
+			if _, err := decoder.Token(); err != nil {
+				return CSVPreview{}, err
+			}
+			array = true
+		}
+	}
+
+	var (
+		headers []string
+		seen    = map[string]bool{}
+		records []map[string]string
+		rows    int
+	)
+	for countAll || len(records) < sampleRows {
+		if array && !decoder.More() {
+			break
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) && !array {
+				break
+			}
+			return CSVPreview{}, fmt.Errorf("record %d: %w", rows+1, err)
+		}
+		rows++
+		if len(records) >= sampleRows {
+			continue
+		}
+		record := map[string]string{}
+		err := flattenRecord(raw, func(key string, value string) {
+			if !seen[key] {
+				seen[key] = true
+				headers = append(headers, key)
+			}
+			record[key] = value
+		})
+		if err != nil {
+			return CSVPreview{}, fmt.Errorf("record %d: %w", rows, err)
+		}
+		records = append(records, record)
+	}
+
+	return CSVPreview{Headers: headers, Samples: recordRows(headers, records), Rows: rows}, nil
+}
+
+func firstNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, reader.UnreadByte()
+	}
+}
+
+// flattenRecord calls emit for each leaf of a JSON object; a value that is
+// not an object becomes a single "value" column.
+func flattenRecord(raw []byte, emit func(key string, value string)) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == json.Delim('{') {
+		return flattenObject(decoder, "", emit)
+	}
+	value, err := leafValue(decoder, token)
+	if err != nil {
+		return err
+	}
+	emit("value", value)
+	return nil
+}
+
+// flattenObject walks the object after its opening brace.
+func flattenObject(decoder *json.Decoder, prefix string, emit func(key string, value string)) error {
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + keyToken.(string)
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if token == json.Delim('{') {
+			if err := flattenObject(decoder, key+".", emit); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := leafValue(decoder, token)
+		if err != nil {
+			return err
+		}
+		emit(key, value)
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+// leafValue renders a scalar token, or the array it opens as compact JSON.
+func leafValue(decoder *json.Decoder, token json.Token) (string, error) {
+	switch value := token.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case json.Delim:
+		items := []any{}
+		for decoder.More() {
+			var item any
+			if err := decoder.Decode(&item); err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(items)
+		return string(encoded), err
+	}
+	return "", fmt.Errorf("unexpected JSON token %v", token)
+}
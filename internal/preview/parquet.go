@@ -0,0 +1,45 @@
+package preview
+
+import (
+	"errors"
+	"io"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+)
+
+// PreviewParquet reads the column names and up to sampleRows rows from a Parquet log.
+func PreviewParquet(path string, sampleRows int, countAll bool) (CSVPreview, error) {
+	reader, err := eventlog.Open(path, eventlog.Options{})
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer reader.Close()
+
+	samples := make([][]string, 0, sampleRows)
+	rowCount := 0
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return CSVPreview{}, err
+		}
+		rowCount++
+		if len(samples) < sampleRows {
+			samples = append(samples, record)
+		}
+		if !countAll && len(samples) >= sampleRows {
+			break
+		}
+	}
+	return CSVPreview{Headers: reader.Header(), Samples: samples, Rows: rowCount}, nil
+}
+
+// PreviewLog previews a stage log in either CSV or Parquet format.
+func PreviewLog(path string, delimiter string, sampleRows int, countAll bool) (CSVPreview, error) {
+	if eventlog.FormatOf(path) == eventlog.FormatParquet {
+		return PreviewParquet(path, sampleRows, countAll)
+	}
+	return PreviewCSV(path, delimiter, sampleRows, countAll)
+}
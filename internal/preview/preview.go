@@ -0,0 +1,109 @@
+package preview
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
+)
+
+// File formats accepted by file connectors.
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+	FormatXLSX    = "xlsx"
+	FormatJSON    = "json"
+	FormatZipCSV  = "zip-csv"
+	FormatXES     = "xes"
+)
+
+// Options describes how to read a file for preview; they mirror the file
+// connector settings.
+type Options struct {
+	Format string
+	// Dialect applies to CSV and ZIP-CSV files; JSON uses its encoding.
+	Dialect   csvdialect.Dialect
+	Sheet     string
+	JSONLines bool
+	ZipMember string
+	// SampleRows is the number of rows kept; CountAll reads to the end to
+	// count every row.
+	SampleRows int
+	CountAll   bool
+}
+
+// FormatOf guesses the file format from the path extension, defaulting to
+// CSV. JSON lines files (.jsonl, .ndjson) report JSON with jsonLines set.
+func FormatOf(path string) (format string, jsonLines bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		return FormatParquet, false
+	case ".xlsx", ".xlsm":
+		return FormatXLSX, false
+	case ".json":
+		return FormatJSON, false
+	case ".jsonl", ".ndjson":
+		return FormatJSON, true
+	case ".zip":
+		return FormatZipCSV, false
+	case ".xes":
+		return FormatXES, false
+	}
+	return FormatCSV, false
+}
+
+// Preview reads the headers and sample rows of a file in any connector
+// format. Nested JSON fields are flattened to dotted column names.
+func Preview(path string, opts Options) (CSVPreview, error) {
+	switch strings.ToLower(opts.Format) {
+	case "", FormatCSV:
+		return previewCSVFile(path, opts.Dialect, opts.SampleRows, opts.CountAll)
+	case FormatParquet:
+		return PreviewParquet(path, opts.SampleRows, opts.CountAll)
+	case FormatXLSX:
+		return PreviewXLSX(path, opts.Sheet, opts.SampleRows, opts.CountAll)
+	case FormatJSON:
+		return PreviewJSON(path, opts.JSONLines, opts.Dialect.Encoding, opts.SampleRows, opts.CountAll)
+	case FormatZipCSV:
+		return PreviewZipCSV(path, opts.ZipMember, opts.Dialect, opts.SampleRows, opts.CountAll)
+	case FormatXES:
+		return PreviewXES(path, opts.SampleRows, opts.CountAll)
+	}
+	return CSVPreview{}, fmt.Errorf("unsupported preview format: %s", opts.Format)
+}
+
+// sample pulls rows from next until it reports done, keeping the first
+// sampleRows and, with countAll, counting the rest.
+func sample(next func() ([]string, bool, error), sampleRows int, countAll bool) ([][]string, int, error) {
+	samples := make([][]string, 0, sampleRows)
+	rowCount := 0
+	for countAll || len(samples) < sampleRows {
+		row, ok, err := next()
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			break
+		}
+		rowCount++
+		if len(samples) < sampleRows {
+			samples = append(samples, row)
+		}
+	}
+	return samples, rowCount, nil
+}
+
+// recordRows lays out keyed records as rows in header order; missing fields
+// are empty.
+func recordRows(headers []string, records []map[string]string) [][]string {
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		row := make([]string, len(headers))
+		for j, header := range headers {
+			row[j] = record[header]
+		}
+		rows[i] = row
+	}
+	return rows
+}
@@ -0,0 +1,91 @@
+package preview
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestPreviewFormats(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// "Café;Zoë" in Windows-1252, and UTF-16LE with a byte order mark.
+	cp1252 := write("cp1252.csv", []byte("case;activity\n1;Caf\xe9\n2;Zo\xeb\n"))
+	utf16 := []byte{0xff, 0xfe}
+	for _, r := range "case,activity\n1,Café\n" {
+		utf16 = append(utf16, byte(r), byte(r>>8))
+	}
+	utf16Path := write("utf16.csv", utf16)
+
+	jsonPath := write("events.json", []byte(`[{"id":1,"order":{"no":"A","lines":[1,2]},"ok":true},{"id":2,"order":null,"note":"x"}]`))
+	jsonlPath := write("events.jsonl", []byte("{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n"))
+	xesPath := write("log.xes", []byte(`<log><trace><string key="concept:name" value="c1"/>
+		<event><string key="concept:name" value="Create"/><date key="time:timestamp" value="2024-01-01T00:00:00Z"/></event>
+		<event><string key="concept:name" value="Ship"/><string key="org:resource" value="bob"/></event>
+	</trace></log>`))
+
+	zipPath := filepath.Join(dir, "export.zip")
+	zipFile, _ := os.Create(zipPath)
+	archive := zip.NewWriter(zipFile)
+	member, _ := archive.Create("data/orders.csv")
+	member.Write([]byte("id,status\n7,open\n"))
+	archive.Close()
+	zipFile.Close()
+
+	xlsxPath := filepath.Join(dir, "book.xlsx")
+	book := excelize.NewFile()
+	book.SetSheetRow("Sheet1", "A1", &[]any{"ignored"})
+	book.NewSheet("Events")
+	book.SetSheetRow("Events", "A1", &[]any{"case", "activity", "note"})
+	book.SetSheetRow("Events", "A2", &[]any{"c1", "Create"})
+	if err := book.SaveAs(xlsxPath); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		path    string
+		opts    Options
+		headers []string
+		first   []string
+		rows    int
+	}{
+		{"cp1252", cp1252, Options{Dialect: csvdialect.Dialect{Delimiter: ";", Encoding: "cp1252"}}, []string{"case", "activity"}, []string{"1", "Café"}, 2},
+		{"utf-16", utf16Path, Options{Dialect: csvdialect.Dialect{Encoding: "UTF-16"}}, []string{"case", "activity"}, []string{"1", "Café"}, 1},
+		{"json", jsonPath, Options{Format: FormatJSON}, []string{"id", "order.no", "order.lines", "ok", "order", "note"}, []string{"1", "A", "[1,2]", "true", "", ""}, 2},
+		{"jsonl", jsonlPath, Options{Format: FormatJSON, JSONLines: true, CountAll: true}, []string{"id"}, []string{"1"}, 3},
+		{"xes", xesPath, Options{Format: FormatXES}, []string{"case:concept:name", "concept:name", "time:timestamp", "org:resource"}, []string{"c1", "Create", "2024-01-01T00:00:00Z", ""}, 2},
+		{"zip", zipPath, Options{Format: FormatZipCSV, ZipMember: "orders.csv"}, []string{"id", "status"}, []string{"7", "open"}, 1},
+		{"xlsx", xlsxPath, Options{Format: FormatXLSX, Sheet: "events"}, []string{"case", "activity", "note"}, []string{"c1", "Create", ""}, 1},
+	}
+	for _, tc := range cases {
+		tc.opts.SampleRows = 5
+		got, err := Preview(tc.path, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got.Headers, tc.headers) || len(got.Samples) == 0 || !reflect.DeepEqual(got.Samples[0], tc.first) || got.Rows != tc.rows {
+			t.Errorf("%s: got headers %q, samples %q, rows %d", tc.name, got.Headers, got.Samples, got.Rows)
+		}
+	}
+
+	sheets, _ := Preview(xlsxPath, Options{Format: FormatXLSX})
+	if !reflect.DeepEqual(sheets.Parts, []string{"Sheet1", "Events"}) || sheets.Part != "Sheet1" {
+		t.Errorf("sheets = %q, previewed %q", sheets.Parts, sheets.Part)
+	}
+	if _, err := Preview(cp1252, Options{Dialect: csvdialect.Dialect{Encoding: "klingon"}}); err == nil {
+		t.Error("expected an unknown encoding to fail")
+	}
+}
@@ -0,0 +1,104 @@
+package preview
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+)
+
+// xesAttribute is one key/value pair read from a trace or event.
+type xesAttribute struct {
+	key   string
+	value string
+}
+
+// PreviewXES previews an XES event log with one row per event. Trace
+// attributes come first with a "case:" prefix (case:concept:name is the
+// case ID), followed by the event attributes; nested attributes are skipped.
+func PreviewXES(path string, sampleRows int, countAll bool) (CSVPreview, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer file.Close()
+	decoder := xml.NewDecoder(file)
+
+	var (
+		headers []string
+		seen    = map[string]bool{}
+		records []map[string]string
+		events  int
+		stack   []string
+		trace   []xesAttribute
+		event   []xesAttribute
+	)
+	for countAll || len(records) < sampleRows {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return CSVPreview{}, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, element.Name.Local)
+			switch element.Name.Local {
+			case "trace":
+				trace = nil
+			case "event":
+				event = nil
+			default:
+				key, value := xesKeyValue(element)
+				if key == "" {
+					continue
+				}
+				if parent == "event" {
+					event = append(event, xesAttribute{key, value})
+				} else if parent == "trace" {
+					trace = append(trace, xesAttribute{"case:" + key, value})
+				}
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if element.Name.Local != "event" {
+				continue
+			}
+			events++
+			if len(records) >= sampleRows {
+				continue
+			}
+			record := map[string]string{}
+			for _, attribute := range append(append([]xesAttribute{}, trace...), event...) {
+				if !seen[attribute.key] {
+					seen[attribute.key] = true
+					headers = append(headers, attribute.key)
+				}
+				record[attribute.key] = attribute.value
+			}
+			records = append(records, record)
+		}
+	}
+
+	return CSVPreview{Headers: headers, Samples: recordRows(headers, records), Rows: events}, nil
+}
+
+func xesKeyValue(element xml.StartElement) (string, string) {
+	key, value := "", ""
+	for _, attr := range element.Attr {
+		switch attr.Name.Local {
+		case "key":
+			key = attr.Value
+		case "value":
+			value = attr.Value
+		}
+	}
+	return key, value
+}
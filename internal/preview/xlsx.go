@@ -0,0 +1,74 @@
+package preview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// PreviewXLSX lists the workbook's sheets and previews one: the named sheet,
+// or the first. The first row is the header; cells are the formatted values
+// Excel displays.
+func PreviewXLSX(path string, sheet string, sampleRows int, countAll bool) (CSVPreview, error) {
+	book, err := excelize.OpenFile(path)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer book.Close()
+
+	sheets := book.GetSheetList()
+	if len(sheets) == 0 {
+		return CSVPreview{}, fmt.Errorf("workbook has no sheets")
+	}
+	if sheet == "" {
+		sheet = sheets[0]
+	} else if !containsFold(sheets, &sheet) {
+		return CSVPreview{}, fmt.Errorf("sheet %q not found (sheets: %s)", sheet, strings.Join(sheets, ", "))
+	}
+	rows, err := book.Rows(sheet)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer rows.Close()
+
+	next := func() ([]string, bool, error) {
+		if !rows.Next() {
+			return nil, false, rows.Error()
+		}
+		row, err := rows.Columns()
+		return row, err == nil, err
+	}
+	headers, ok, err := next()
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	result := CSVPreview{Parts: sheets, Part: sheet}
+	if !ok {
+		return result, nil
+	}
+	result.Headers = headers
+	if result.Samples, result.Rows, err = sample(next, sampleRows, countAll); err != nil {
+		return CSVPreview{}, err
+	}
+	// Trailing empty cells are omitted per row; pad to the header width.
+	for i, row := range result.Samples {
+		for len(row) < len(headers) {
+			row = append(row, "")
+		}
+		result.Samples[i] = row
+	}
+	return result, nil
+}
+
+// containsFold reports whether names holds *name ignoring case, and sets
+// *name to the matching entry.
+func containsFold(names []string, name *string) bool {
+	for _, candidate := range names {
+		if strings.EqualFold(candidate, *name) {
+			*name = candidate
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package preview
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/csvdialect"
+)
+
+// PreviewZipCSV previews a CSV member of a zip archive: the named member
+// (full path or base name), or the first .csv member.
+func PreviewZipCSV(archivePath string, member string, dialect csvdialect.Dialect, sampleRows int, countAll bool) (CSVPreview, error) {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer archive.Close()
+
+	var members []*zip.File
+	var names []string
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(path.Base(file.Name), ".") {
+			continue
+		}
+		if member != "" || strings.EqualFold(path.Ext(file.Name), ".csv") {
+			members = append(members, file)
+			names = append(names, file.Name)
+		}
+	}
+	var selected *zip.File
+	for _, file := range members {
+		if member == "" || file.Name == member || path.Base(file.Name) == member {
+			selected = file
+			break
+		}
+	}
+	if selected == nil {
+		if member != "" {
+			return CSVPreview{}, fmt.Errorf("zip member %s not found (members: %s)", member, strings.Join(names, ", "))
+		}
+		return CSVPreview{}, fmt.Errorf("no CSV members in %s", archivePath)
+	}
+	body, err := selected.Open()
+	if err != nil {
+		return CSVPreview{}, err
+	}
+	defer body.Close()
+	result, err := previewCSVReader(body, dialect, sampleRows, countAll)
+	if err != nil {
+		return CSVPreview{}, fmt.Errorf("%s: %w", selected.Name, err)
+	}
+	result.Parts, result.Part = names, selected.Name
+	return result, nil
+}
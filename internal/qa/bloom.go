@@ -0,0 +1,55 @@
+package qa
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomHashes is the number of hash functions; seven is optimal at about
+// ten bits per key.
+const bloomHashes = 7
+
+// bloomFilter estimates duplicate events in fixed memory. A key reported as
+// seen may be new (a false positive), so duplicates are overestimated; a
+// key reported as new never was seen.
+type bloomFilter struct {
+	bits  []uint64
+	size  uint64
+	added int64
+}
+
+func newBloomFilter(bytes int64) *bloomFilter {
+	words := bytes / 8
+	if words < 1 {
+		words = 1
+	}
+	return &bloomFilter{bits: make([]uint64, words), size: uint64(words) * 64}
+}
+
+// add inserts key and reports whether it was probably present already.
+func (b *bloomFilter) add(key string) bool {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	sum := hash.Sum64()
+	// Double hashing derives the k positions from two halves of one hash.
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	present := true
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			present = false
+			b.bits[word] |= mask
+		}
+	}
+	if !present {
+		b.added++
+	}
+	return present
+}
+
+// falsePositiveRate estimates the chance that a new key was reported as
+// seen, given the keys added so far.
+func (b *bloomFilter) falsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-bloomHashes*float64(b.added)/float64(b.size)), bloomHashes)
+}
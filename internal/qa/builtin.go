@@ -0,0 +1,217 @@
+package qa
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+func init() {
+	RegisterRuleType("domain", DimensionValidity, 0, newDomainRule)
+	RegisterRuleType("regex", DimensionValidity, 0, newRegexRule)
+	RegisterRuleType("allowed_activities", DimensionConsistency, 0, newActivityRule)
+	RegisterRuleType("case_length", DimensionConsistency, 0, newCaseLengthRule)
+	RegisterRuleType("future_timestamps", DimensionTimeliness, 0, newFutureRule)
+	// Some midnight timestamps are normal; a log where nearly all are at
+	// midnight only has dates.
+	RegisterRuleType("timestamp_granularity", DimensionTimeliness, 0.95, newGranularityRule)
+	RegisterRuleType("start_activities", DimensionConsistency, 0, newBoundaryRule(true))
+	RegisterRuleType("end_activities", DimensionConsistency, 0, newBoundaryRule(false))
+}
+
+type baseRule struct {
+	spec RuleSpec
+}
+
+func (r baseRule) Spec() RuleSpec {
+	return r.spec
+}
+
+func (r baseRule) Columns() []string {
+	if r.spec.Column == "" {
+		return nil
+	}
+	return []string{r.spec.Column}
+}
+
+// domainRule checks a column against a list of values, a numeric range, or
+// both.
+type domainRule struct {
+	baseRule
+}
+
+func newDomainRule(spec RuleSpec) (Rule, error) {
+	if spec.Column == "" {
+		return nil, fmt.Errorf("column is required")
+	}
+	if len(spec.Values) == 0 && spec.Min == nil && spec.Max == nil {
+		return nil, fmt.Errorf("values, min, or max is required")
+	}
+	return domainRule{baseRule{spec}}, nil
+}
+
+func (r domainRule) CheckEvent(event Event) Outcome {
+	value := strings.TrimSpace(event.Value(r.spec.Column))
+	if value == "" {
+		return Skipped
+	}
+	if len(r.spec.Values) > 0 && !slices.Contains(r.spec.Values, value) {
+		return Violated
+	}
+	if r.spec.Min != nil || r.spec.Max != nil {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || r.spec.Min != nil && number < *r.spec.Min || r.spec.Max != nil && number > *r.spec.Max {
+			return Violated
+		}
+	}
+	return Passed
+}
+
+// regexRule checks that a column's values match a pattern in full.
+type regexRule struct {
+	baseRule
+	pattern *regexp.Regexp
+}
+
+func newRegexRule(spec RuleSpec) (Rule, error) {
+	if spec.Column == "" || spec.Pattern == "" {
+		return nil, fmt.Errorf("column and pattern are required")
+	}
+	pattern, err := regexp.Compile("^(?:" + spec.Pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return regexRule{baseRule{spec}, pattern}, nil
+}
+
+func (r regexRule) CheckEvent(event Event) Outcome {
+	value := event.Value(r.spec.Column)
+	if strings.TrimSpace(value) == "" {
+		return Skipped
+	}
+	if !r.pattern.MatchString(value) {
+		return Violated
+	}
+	return Passed
+}
+
+// activityRule checks activities against an allowed list.
+type activityRule struct {
+	baseRule
+}
+
+func newActivityRule(spec RuleSpec) (Rule, error) {
+	if len(spec.Values) == 0 {
+		return nil, fmt.Errorf("values is required")
+	}
+	return activityRule{baseRule{spec}}, nil
+}
+
+func (r activityRule) CheckEvent(event Event) Outcome {
+	if strings.TrimSpace(event.Activity) == "" {
+		return Skipped
+	}
+	if !slices.Contains(r.spec.Values, event.Activity) {
+		return Violated
+	}
+	return Passed
+}
+
+// caseLengthRule checks the number of events per case.
+type caseLengthRule struct {
+	baseRule
+}
+
+func newCaseLengthRule(spec RuleSpec) (Rule, error) {
+	if spec.Min == nil && spec.Max == nil {
+		return nil, fmt.Errorf("min or max is required")
+	}
+	return caseLengthRule{baseRule{spec}}, nil
+}
+
+func (r caseLengthRule) CheckCase(c CaseSummary) Outcome {
+	events := float64(c.Events)
+	if r.spec.Min != nil && events < *r.spec.Min || r.spec.Max != nil && events > *r.spec.Max {
+		return Violated
+	}
+	return Passed
+}
+
+// futureRule flags timestamps after the current time plus a tolerance.
+type futureRule struct {
+	baseRule
+	cutoff time.Time
+}
+
+func newFutureRule(spec RuleSpec) (Rule, error) {
+	var tolerance time.Duration
+	if spec.Tolerance != "" {
+		var err error
+		if tolerance, err = time.ParseDuration(spec.Tolerance); err != nil {
+			return nil, fmt.Errorf("tolerance: %w", err)
+		}
+	}
+	return futureRule{baseRule{spec}, now().Add(tolerance)}, nil
+}
+
+func (r futureRule) CheckEvent(event Event) Outcome {
+	if event.Timestamp.IsZero() {
+		return Skipped
+	}
+	if event.Timestamp.After(r.cutoff) {
+		return Violated
+	}
+	return Passed
+}
+
+// granularityRule flags timestamps at midnight; above the threshold the log
+// records dates only and cannot order events within a day.
+type granularityRule struct {
+	baseRule
+}
+
+func newGranularityRule(spec RuleSpec) (Rule, error) {
+	return granularityRule{baseRule{spec}}, nil
+}
+
+func (r granularityRule) CheckEvent(event Event) Outcome {
+	if event.Timestamp.IsZero() {
+		return Skipped
+	}
+	if hour, minute, second := event.Timestamp.Clock(); hour == 0 && minute == 0 && second == 0 && event.Timestamp.Nanosecond() == 0 {
+		return Violated
+	}
+	return Passed
+}
+
+// boundaryRule checks the first or last activity of each case.
+type boundaryRule struct {
+	baseRule
+	start bool
+}
+
+func newBoundaryRule(start bool) RuleBuilder {
+	return func(spec RuleSpec) (Rule, error) {
+		if len(spec.Values) == 0 {
+			return nil, fmt.Errorf("values is required")
+		}
+		return boundaryRule{baseRule{spec}, start}, nil
+	}
+}
+
+func (r boundaryRule) CheckCase(c CaseSummary) Outcome {
+	activity := c.EndActivity
+	if r.start {
+		activity = c.StartActivity
+	}
+	if !slices.Contains(r.spec.Values, activity) {
+		return Violated
+	}
+	return Passed
+}
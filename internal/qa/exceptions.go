@@ -0,0 +1,194 @@
+package qa
+
+import (
+	"container/heap"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// DefaultExceptionLimit caps the rows written per exception file.
+const DefaultExceptionLimit = 1000
+
+// Exception is one offending row of a failed check.
+type Exception struct {
+	// Row is the 1-based data row, not counting the header.
+	Row       int64
+	Case      string
+	Activity  string
+	Timestamp string
+	Detail    string
+}
+
+// ExceptionFile describes an exception file written by Run.
+type ExceptionFile struct {
+	Check string `json:"check"`
+	// Path is relative to the parent of the exception directory, e.g.
+	// exceptions/duplicates.csv.
+	Path string `json:"path"`
+	// Total counts every offending row; Written stops at the limit.
+	Total     int64 `json:"total"`
+	Written   int   `json:"written"`
+	Truncated bool  `json:"truncated"`
+}
+
+// exceptionLog keeps the limit exceptions of each check with the lowest
+// rows, whatever order they arrive in. A nil log records nothing.
+type exceptionLog struct {
+	limit  int
+	checks map[string]*exceptionSet
+}
+
+type exceptionSet struct {
+	total int64
+	// rows is a max-heap on row order, so the last row kept is on top.
+	rows exceptionHeap
+}
+
+type exceptionHeap []Exception
+
+func (h exceptionHeap) Len() int           { return len(h) }
+func (h exceptionHeap) Less(i, j int) bool { return exceptionBefore(h[j], h[i]) }
+func (h exceptionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *exceptionHeap) Push(x any)        { *h = append(*h, x.(Exception)) }
+func (h *exceptionHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// exceptionBefore orders exceptions by row, then case; case rule
+// exceptions have no row.
+func exceptionBefore(a Exception, b Exception) bool {
+	if a.Row != b.Row {
+		return a.Row < b.Row
+	}
+	return a.Case < b.Case
+}
+
+func newExceptionLog(limit int) *exceptionLog {
+	if limit <= 0 {
+		return nil
+	}
+	return &exceptionLog{limit: limit, checks: map[string]*exceptionSet{}}
+}
+
+func (l *exceptionLog) add(check string, exception Exception) {
+	if l == nil {
+		return
+	}
+	set, ok := l.checks[check]
+	if !ok {
+		set = &exceptionSet{}
+		l.checks[check] = set
+	}
+	set.total++
+	l.keep(set, exception)
+}
+
+func (l *exceptionLog) keep(set *exceptionSet, exception Exception) {
+	if len(set.rows) < l.limit {
+		heap.Push(&set.rows, exception)
+	} else if exceptionBefore(exception, set.rows[0]) {
+		set.rows[0] = exception
+		heap.Fix(&set.rows, 0)
+	}
+}
+
+// merge folds the exceptions of another log, such as a worker's, into l.
+// The rows kept do not depend on the order logs are merged in.
+func (l *exceptionLog) merge(other *exceptionLog) {
+	if l == nil || other == nil {
+		return
+	}
+	for check, from := range other.checks {
+		set, ok := l.checks[check]
+		if !ok {
+			set = &exceptionSet{}
+			l.checks[check] = set
+		}
+		set.total += from.total
+		for _, exception := range from.rows {
+			l.keep(set, exception)
+		}
+	}
+}
+
+// write replaces dir with one CSV per failed check, in check order.
+func (l *exceptionLog) write(dir string, failed []string) ([]ExceptionFile, error) {
+	if l == nil {
+		return nil, nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	sort.Strings(failed)
+	var files []ExceptionFile
+	for _, check := range failed {
+		set, ok := l.checks[check]
+		if !ok || set.total == 0 {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		name := check + ".csv"
+		rows := []Exception(set.rows)
+		sort.Slice(rows, func(i, j int) bool { return exceptionBefore(rows[i], rows[j]) })
+		if err := writeExceptions(filepath.Join(dir, name), rows); err != nil {
+			return nil, err
+		}
+		files = append(files, ExceptionFile{
+			Check:     check,
+			Path:      filepath.ToSlash(filepath.Join(filepath.Base(dir), name)),
+			Total:     set.total,
+			Written:   len(set.rows),
+			Truncated: set.total > int64(len(set.rows)),
+		})
+	}
+	return files, nil
+}
+
+func writeExceptions(path string, rows []Exception) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"row", "case_id", "activity", "timestamp", "detail"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write([]string{strconv.FormatInt(row.Row, 10), row.Case, row.Activity, row.Timestamp, row.Detail}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func exceptionCheck(prefix string, name string) string {
+	return fmt.Sprintf("%s_%s", prefix, sanitizeCheck(name))
+}
+
+// sanitizeCheck keeps check names usable as file names.
+func sanitizeCheck(name string) string {
+	out := make([]rune, 0, len(name))
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
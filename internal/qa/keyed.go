@@ -0,0 +1,243 @@
+package qa
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// keyedEvent is what the per-case checks need of a row.
+type keyedEvent struct {
+	row       int64
+	caseID    string
+	activity  string
+	timestamp string
+	// at is zero when the timestamp is empty or does not parse.
+	at time.Time
+}
+
+// keyedState runs the checks that keep state per case or per event key:
+// exact duplicates, order violations, and case rules. Partitioned runs feed
+// it one partition at a time; every partition holds whole cases.
+type keyedState struct {
+	// dedupe is false when a Bloom filter estimates duplicates instead.
+	dedupe     bool
+	caseRules  []caseRuleRun
+	exceptions *exceptionLog
+
+	seen  map[string]int64
+	last  map[string]lastEvent
+	cases map[string]*CaseSummary
+
+	duplicates      int64
+	orderViolations int64
+}
+
+func newKeyedState(dedupe bool, caseRules []caseRuleRun, exceptions *exceptionLog) *keyedState {
+	state := &keyedState{dedupe: dedupe, caseRules: caseRules, exceptions: exceptions}
+	state.reset()
+	return state
+}
+
+func (s *keyedState) reset() {
+	s.seen = map[string]int64{}
+	s.last = map[string]lastEvent{}
+	s.cases = map[string]*CaseSummary{}
+}
+
+func (s *keyedState) add(event keyedEvent) {
+	exception := Exception{Row: event.row, Case: event.caseID, Activity: event.activity, Timestamp: event.timestamp}
+	if s.dedupe {
+		key := event.caseID + "|" + event.activity + "|" + event.timestamp
+		if first, ok := s.seen[key]; ok {
+			s.duplicates++
+			s.exceptions.add(checkDuplicates, withDetail(exception, fmt.Sprintf("duplicate of row %d", first)))
+		} else {
+			s.seen[key] = event.row
+		}
+	}
+	if !event.at.IsZero() {
+		if last, ok := s.last[event.caseID]; ok && event.at.Before(last.at) {
+			s.orderViolations++
+			s.exceptions.add(checkOrderViolations, withDetail(exception, fmt.Sprintf("before row %d of the same case", last.row)))
+		}
+		s.last[event.caseID] = lastEvent{at: event.at, row: event.row}
+	}
+	if len(s.caseRules) > 0 {
+		summary, ok := s.cases[event.caseID]
+		if !ok {
+			summary = &CaseSummary{ID: event.caseID}
+			s.cases[event.caseID] = summary
+		}
+		summary.add(Event{Row: event.row, Case: event.caseID, Activity: event.activity, Timestamp: event.at})
+	}
+}
+
+// checkCases runs the case rules over the cases seen since the last reset.
+func (s *keyedState) checkCases() {
+	if len(s.caseRules) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(s.cases))
+	for id := range s.cases {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		summary := *s.cases[id]
+		for _, run := range s.caseRules {
+			outcome := run.rule.CheckCase(summary)
+			run.record(outcome)
+			if outcome == Violated {
+				detail := fmt.Sprintf("case with %d events from %s to %s violates %s", summary.Events, summary.StartActivity, summary.EndActivity, run.result.Name)
+				s.exceptions.add(exceptionCheck("rule", run.result.Name), Exception{Case: id, Detail: detail})
+			}
+		}
+	}
+}
+
+// maxPartitions bounds the spill files open at once.
+const maxPartitions = 1024
+
+// partitionCount estimates how many partitions keep the keyed state of the
+// log at path within budget bytes. The state is taken to be about the size
+// of a CSV log, and four times the size of a compressed Parquet one.
+func partitionCount(path string, parquet bool, budget int64) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	estimate := info.Size()
+	if parquet {
+		estimate *= 4
+	}
+	partitions := (estimate + budget - 1) / budget
+	switch {
+	case partitions < 1:
+		return 1, nil
+	case partitions > maxPartitions:
+		return maxPartitions, nil
+	}
+	return int(partitions), nil
+}
+
+// spill hash-partitions keyed events by case into temporary CSV files, so
+// each partition can be checked on its own.
+type spill struct {
+	dir     string
+	files   []*os.File
+	buffers []*bufio.Writer
+	writers []*csv.Writer
+}
+
+func newSpill(parent string, partitions int) (*spill, error) {
+	dir, err := os.MkdirTemp(parent, "pm-assist-qa-")
+	if err != nil {
+		return nil, err
+	}
+	s := &spill{dir: dir}
+	for i := 0; i < partitions; i++ {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("part-%04d.csv", i)))
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		buffer := bufio.NewWriterSize(file, 64*1024)
+		s.files = append(s.files, file)
+		s.buffers = append(s.buffers, buffer)
+		s.writers = append(s.writers, csv.NewWriter(buffer))
+	}
+	return s, nil
+}
+
+// partition returns the spill partition of a case.
+func (s *spill) partition(caseID string) int {
+	return int(caseHash(caseID) % uint32(len(s.writers)))
+}
+
+func (s *spill) add(event keyedEvent) error {
+	seconds, nanos := "", ""
+	if !event.at.IsZero() {
+		seconds, nanos = strconv.FormatInt(event.at.Unix(), 10), strconv.Itoa(event.at.Nanosecond())
+	}
+	record := []string{strconv.FormatInt(event.row, 10), event.caseID, event.activity, event.timestamp, seconds, nanos}
+	return s.writers[s.partition(event.caseID)].Write(record)
+}
+
+// replay feeds each partition of a shard, in file order, to state, running
+// the case rules after each one. Shard i of n owns partitions i, i+n, and
+// so on; shards write and replay their own partitions only, so they can run
+// concurrently.
+func (s *spill) replay(state *keyedState, shard int, shards int) error {
+	for i := shard; i < len(s.writers); i += shards {
+		s.writers[i].Flush()
+		if err := s.writers[i].Error(); err != nil {
+			return err
+		}
+		if err := s.buffers[i].Flush(); err != nil {
+			return err
+		}
+	}
+	for i := shard; i < len(s.files); i += shards {
+		file := s.files[i]
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		reader := csv.NewReader(bufio.NewReaderSize(file, 64*1024))
+		reader.ReuseRecord = true
+		reader.FieldsPerRecord = 6
+		state.reset()
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			row, err := strconv.ParseInt(record[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			event := keyedEvent{row: row, caseID: record[1], activity: record[2], timestamp: record[3]}
+			if record[4] != "" {
+				seconds, err := strconv.ParseInt(record[4], 10, 64)
+				if err != nil {
+					return err
+				}
+				nanos, err := strconv.ParseInt(record[5], 10, 64)
+				if err != nil {
+					return err
+				}
+				event.at = time.Unix(seconds, nanos)
+			}
+			state.add(event)
+		}
+		state.checkCases()
+	}
+	state.reset()
+	return nil
+}
+
+// caseHash spreads cases over spill partitions and worker shards.
+func caseHash(caseID string) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(caseID))
+	return hash.Sum32()
+}
+
+// close removes the spill files.
+func (s *spill) close() {
+	for _, file := range s.files {
+		file.Close()
+	}
+	os.RemoveAll(s.dir)
+}
@@ -8,8 +8,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+	"github.com/pm-assist/pm-assist/internal/timeformat"
 )
 
 type Results struct {
@@ -18,9 +22,26 @@ type Results struct {
 	DuplicateRate      float64            `json:"duplicate_rate"`
 	OrderViolationRate float64            `json:"order_violation_rate"`
 	TimestampParseRate float64            `json:"timestamp_parse_rate"`
-	Warnings           []string           `json:"warnings"`
-	BlockingIssues     []string           `json:"blocking_issues"`
-	Thresholds         Thresholds         `json:"thresholds"`
+	TimestampFormat    string             `json:"timestamp_format,omitempty"`
+	// TimestampAlternatives lists layouts that read the sample differently
+	// but fit it as well as TimestampFormat.
+	TimestampAlternatives []string `json:"timestamp_alternatives,omitempty"`
+	// TimestampsWithoutZone is set when the timestamps carry no zone or
+	// offset, so they were read as UTC.
+	TimestampsWithoutZone bool              `json:"timestamps_without_zone,omitempty"`
+	SourceTypes           map[string]string `json:"source_types,omitempty"`
+	Rules                 []RuleResult      `json:"rules,omitempty"`
+	Exceptions            []ExceptionFile   `json:"exceptions,omitempty"`
+	// DuplicateEstimate is set when DuplicateRate is a Bloom filter estimate.
+	DuplicateEstimate *DuplicateEstimate `json:"duplicate_estimate,omitempty"`
+	// Partitions counts the spill partitions used to stay within the memory
+	// budget.
+	Partitions int `json:"partitions,omitempty"`
+	// Workers is the number of goroutines the records were checked on.
+	Workers        int        `json:"workers"`
+	Warnings       []string   `json:"warnings"`
+	BlockingIssues []string   `json:"blocking_issues"`
+	Thresholds     Thresholds `json:"thresholds"`
 }
 
 type Thresholds struct {
@@ -30,26 +51,106 @@ type Thresholds struct {
 	ParseFail    float64 `json:"parse_failure"`
 }
 
+// Duplicate detection modes.
+const (
+	// DuplicatesExact remembers every event key.
+	DuplicatesExact = "exact"
+	// DuplicatesEstimate counts duplicates with a Bloom filter in fixed
+	// memory; false positives make it an overestimate.
+	DuplicatesEstimate = "estimate"
+)
+
+// defaultBloomBytes sizes the Bloom filter without a memory budget: about
+// 25 million keys at a 1% false positive rate.
+const defaultBloomBytes = 32 << 20
+
+// DuplicateEstimate describes an estimated duplicate rate.
+type DuplicateEstimate struct {
+	Method      string `json:"method"`
+	FilterBytes int64  `json:"filter_bytes"`
+	// Filters is the number of filters the keys were split across, one per
+	// worker; the estimate varies slightly with it.
+	Filters int `json:"filters"`
+	// FalsePositiveRate is the chance a distinct event was counted as a
+	// duplicate.
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+}
+
 type BacklogIssue struct {
 	Severity string `json:"severity"`
 	Issue    string `json:"issue"`
 	Fix      string `json:"suggested_fix"`
 }
 
+// Options configures Run.
+type Options struct {
+	// Options carries the CSV dialect.
+	eventlog.Options
+	CaseColumn      string
+	ActivityColumn  string
+	TimestampColumn string
+	// TimestampFormat is inferred from a sample of the column when empty.
+	TimestampFormat string
+	Thresholds      Thresholds
+	// Rules run after the built-in checks, usually from the project's rule
+	// pack.
+	Rules []Rule
+	// MemoryBudget bounds, approximately, the memory used for duplicate,
+	// order, and case checks, in bytes. When the log needs more, events are
+	// hash-partitioned by case into spill files under SpillDir (the system
+	// temp dir when empty) and checked one partition at a time. Zero keeps
+	// everything in memory.
+	MemoryBudget int64
+	SpillDir     string
+	// Duplicates is DuplicatesExact (the default) or DuplicatesEstimate.
+	Duplicates string
+	// Workers decodes and checks records on that many goroutines, with the
+	// events of each case checked on the same one; CSV in a single-byte
+	// dialect is decoded in parallel too, other logs on the reading
+	// goroutine. Results do not depend on it, except that a Bloom filter is
+	// split between workers, so Results records it. Zero or one reads and
+	// checks records on the calling goroutine.
+	Workers int
+	// ExceptionDir receives one CSV of offending rows per failed check, at
+	// most ExceptionLimit rows each. Exceptions are off when the limit is 0;
+	// files of an earlier run are removed either way.
+	ExceptionDir   string
+	ExceptionLimit int
+}
+
+// RunCSV executes the QA checks over a CSV event log with default options.
+//
+// Deprecated: use Run, which also reads Parquet and takes rules, budgets, and
+// workers.
 func RunCSV(path string, caseCol string, activityCol string, timestampCol string, timestampFormat string, thresholds Thresholds) (Results, []BacklogIssue, error) {
-	file, err := os.Open(path)
+	return Run(path, Options{CaseColumn: caseCol, ActivityColumn: activityCol, TimestampColumn: timestampCol, TimestampFormat: timestampFormat, Thresholds: thresholds})
+}
+
+// Run executes the QA checks over a CSV or Parquet event log.
+func Run(path string, opts Options) (Results, []BacklogIssue, error) {
+	caseCol, activityCol, timestampCol := opts.CaseColumn, opts.ActivityColumn, opts.TimestampColumn
+	timestampFormat, thresholds := opts.TimestampFormat, opts.Thresholds
+	// Stale exceptions would otherwise be bundled as this run's.
+	if opts.ExceptionDir != "" {
+		if err := os.RemoveAll(opts.ExceptionDir); err != nil {
+			return Results{}, nil, fmt.Errorf("remove old exceptions: %w", err)
+		}
+	}
+	reader, err := eventlog.Open(path, opts.Options)
 	if err != nil {
 		return Results{}, nil, err
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = -1
-
-	header, err := reader.Read()
+	defer reader.Close()
+	if eventlog.FormatOf(path) == eventlog.FormatParquet {
+		// Parquet timestamps are typed and always read back as RFC 3339.
+		timestampFormat = ""
+	}
+	schema, err := eventlog.ReadSchema(path)
 	if err != nil {
-		return Results{}, nil, err
+		return Results{}, nil, fmt.Errorf("read schema sidecar: %w", err)
 	}
+
+	header := reader.Header()
 	colIndex := make(map[string]int, len(header))
 	for i, col := range header {
 		colIndex[col] = i
@@ -67,66 +168,210 @@ func RunCSV(path string, caseCol string, activityCol string, timestampCol string
 		BlockingIssues: []string{},
 		Thresholds:     thresholds,
 	}
+	if schema != nil {
+		results.SourceTypes = map[string]string{}
+		for _, col := range []string{caseCol, activityCol, timestampCol} {
+			if column, ok := schema.Column(col); ok {
+				results.SourceTypes[col] = column.SourceType
+			}
+		}
+		// Typed timestamps have a known layout, RFC 3339 in extracts or the one
+		// recorded when a stage rewrote them, so parse them strictly instead of
+		// trying every known layout.
+		if column, ok := schema.Column(timestampCol); ok && timestampFormat == "" {
+			if eventlog.FormatOf(path) == eventlog.FormatParquet {
+				// Parquet keeps typed values, whatever layout the CSV had;
+				// a stage stored values without a zone as UTC.
+				results.TimestampsWithoutZone = column.Layout != "" && !timeformat.HasZone(column.Layout)
+				column.Layout = ""
+			}
+			if column.Temporal() {
+				timestampFormat = column.Format()
+			} else {
+				results.Warnings = append(results.Warnings, fmt.Sprintf("Timestamp column %s has source type %s; values are parsed heuristically", timestampCol, column.SourceType))
+			}
+		}
+	}
 	if len(missingCols) > 0 {
 		results.BlockingIssues = append(results.BlockingIssues, fmt.Sprintf("Missing required columns: %s", strings.Join(missingCols, ", ")))
 		return results, []BacklogIssue{{Severity: "blocking", Issue: "Missing required columns", Fix: "Update column mapping or re-run ingest."}}, nil
 	}
 
-	missingCounts := map[string]int{caseCol: 0, activityCol: 0, timestampCol: 0}
-	duplicateCount := 0
-	rowCount := 0
-	seen := map[string]struct{}{}
+	parseTimestamp := func(value string) (time.Time, error) {
+		return timeformat.Parse(value, timestampFormat)
+	}
+	if timestampFormat == "" {
+		sample, err := eventlog.SampleColumn(path, opts.Options, timestampCol, timestampSample)
+		if err != nil {
+			return results, nil, err
+		}
+		inference := timeformat.Infer(sample)
+		parseTimestamp = inference.Parse
+		results.TimestampFormat = inference.Layout
+		if inference.Ambiguous {
+			results.TimestampAlternatives = inference.Alternatives
+			results.Warnings = append(results.Warnings, fmt.Sprintf("Timestamp layout is ambiguous: %s and %s read the sample differently; assumed %s", inference.Layout, strings.Join(inference.Alternatives, ", "), inference.Layout))
+		}
+	} else {
+		results.TimestampFormat = timestampFormat
+	}
+	if results.TimestampFormat != "" && eventlog.FormatOf(path) != eventlog.FormatParquet {
+		results.TimestampsWithoutZone = !timeformat.HasZone(results.TimestampFormat)
+	}
 
-	caseLast := map[string]time.Time{}
-	orderViolations := 0
-	parsedTimestamps := 0
-	parseFailures := 0
+	var exceptions *exceptionLog
+	if opts.ExceptionDir != "" {
+		exceptions = newExceptionLog(opts.ExceptionLimit)
+	}
 
-	for {
-		record, err := reader.Read()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+	var runs []*ruleRun
+	for _, rule := range opts.Rules {
+		run := newRuleRun(rule)
+		runs = append(runs, run)
+		for _, col := range rule.Columns() {
+			if _, ok := colIndex[col]; !ok {
+				run.result.Error = fmt.Sprintf("column %s not found", col)
 			}
+		}
+	}
+
+	var bloomBytes int64
+	switch opts.Duplicates {
+	case "", DuplicatesExact:
+	case DuplicatesEstimate:
+		bloomBytes = defaultBloomBytes
+		if opts.MemoryBudget > 0 {
+			bloomBytes = opts.MemoryBudget / 4
+		}
+	default:
+		return results, nil, fmt.Errorf("duplicate mode must be %s or %s", DuplicatesExact, DuplicatesEstimate)
+	}
+	var spilled *spill
+	if opts.MemoryBudget > 0 {
+		partitions, err := partitionCount(path, eventlog.FormatOf(path) == eventlog.FormatParquet, opts.MemoryBudget)
+		if err != nil {
 			return results, nil, err
 		}
-		rowCount++
-		caseVal := getValue(record, colIndex[caseCol])
-		actVal := getValue(record, colIndex[activityCol])
-		tsVal := getValue(record, colIndex[timestampCol])
+		if partitions > 1 {
+			if spilled, err = newSpill(opts.SpillDir, partitions); err != nil {
+				return results, nil, fmt.Errorf("create spill files: %w", err)
+			}
+			defer spilled.close()
+			results.Partitions = partitions
+		}
+	}
 
-		if strings.TrimSpace(caseVal) == "" {
-			missingCounts[caseCol]++
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	shards := make([]*shard, workers)
+	for i := range shards {
+		s := &shard{
+			index:        i,
+			shards:       workers,
+			columns:      colIndex,
+			caseCol:      caseCol,
+			activityCol:  activityCol,
+			timestampCol: timestampCol,
+			parse:        parseTimestamp,
+			missing:      map[string]int{caseCol: 0, activityCol: 0, timestampCol: 0},
+			spilled:      spilled,
+		}
+		if opts.ExceptionDir != "" {
+			s.exceptions = newExceptionLog(opts.ExceptionLimit)
 		}
-		if strings.TrimSpace(actVal) == "" {
-			missingCounts[activityCol]++
+		if bloomBytes > 0 {
+			// Shards see disjoint cases, so each filter holds a share of the
+			// keys at the same false positive rate.
+			s.bloom = newBloomFilter(bloomBytes / int64(workers))
 		}
-		if strings.TrimSpace(tsVal) == "" {
-			missingCounts[timestampCol]++
+		var caseRules []caseRuleRun
+		for _, run := range runs {
+			run := run.shard()
+			s.runs = append(s.runs, run)
+			if run.result.Error != "" {
+				continue
+			}
+			if eventRule, ok := run.rule.(EventRule); ok {
+				s.eventRules = append(s.eventRules, eventRuleRun{eventRule, run})
+			}
+			if caseRule, ok := run.rule.(CaseRule); ok {
+				caseRules = append(caseRules, caseRuleRun{caseRule, run})
+			}
 		}
+		s.keyed = newKeyedState(s.bloom == nil, caseRules, s.exceptions)
+		shards[i] = s
+	}
 
-		key := caseVal + "|" + actVal + "|" + tsVal
-		if _, ok := seen[key]; ok {
-			duplicateCount++
+	rowCount := 0
+	if workers == 1 {
+		if err := checkSerial(reader, shards[0], &rowCount); err != nil {
+			return results, nil, err
+		}
+	} else {
+		// A spilled case's partition decides its shard, so each shard owns
+		// whole partitions.
+		route := func(caseID string) int {
+			return int(caseHash(caseID) % uint32(workers))
+		}
+		if spilled != nil {
+			route = func(caseID string) int {
+				return spilled.partition(caseID) % workers
+			}
+		}
+		workerPool := newPool(shards, route, colIndex[caseCol])
+		var readErr error
+		if chunked, ok := eventlog.AsChunked(reader); ok {
+			readErr = workerPool.decode(chunked, workers, &rowCount)
 		} else {
-			seen[key] = struct{}{}
+			readErr = readAll(reader, &rowCount, workerPool.add)
+		}
+		if err := workerPool.wait(); readErr == nil {
+			readErr = err
 		}
+		if readErr != nil {
+			return results, nil, readErr
+		}
+	}
 
-		if strings.TrimSpace(tsVal) != "" {
-			parsed, err := parseTimestamp(tsVal, timestampFormat)
-			if err != nil {
-				parseFailures++
-			} else {
-				parsedTimestamps++
-				if last, ok := caseLast[caseVal]; ok && parsed.Before(last) {
-					orderViolations++
-				}
-				caseLast[caseVal] = parsed
-			}
+	missingCounts := map[string]int{caseCol: 0, activityCol: 0, timestampCol: 0}
+	parsedTimestamps := 0
+	parseFailures := 0
+	var duplicateCount, orderViolations, bloomDuplicates, bloomAdded int64
+	var bloomFalsePositives float64
+	for _, s := range shards {
+		for col, count := range s.missing {
+			missingCounts[col] += count
+		}
+		parsedTimestamps += s.parsed
+		parseFailures += s.parseFailures
+		duplicateCount += s.keyed.duplicates
+		orderViolations += s.keyed.orderViolations
+		for i, run := range runs {
+			run.merge(s.runs[i])
+		}
+		exceptions.merge(s.exceptions)
+		if s.bloom != nil {
+			bloomDuplicates += s.bloomDuplicates
+			bloomAdded += s.bloom.added
+			bloomFalsePositives += s.bloom.falsePositiveRate() * float64(s.bloom.added)
+		}
+	}
+	if bloomBytes > 0 {
+		duplicateCount = bloomDuplicates
+		estimate := &DuplicateEstimate{Method: "bloom", Filters: len(shards)}
+		for _, s := range shards {
+			estimate.FilterBytes += int64(len(s.bloom.bits)) * 8
 		}
+		if bloomAdded > 0 {
+			estimate.FalsePositiveRate = bloomFalsePositives / float64(bloomAdded)
+		}
+		results.DuplicateEstimate = estimate
 	}
 
 	results.RowCount = rowCount
+	results.Workers = workers
 	if rowCount == 0 {
 		results.BlockingIssues = append(results.BlockingIssues, "No rows found in input log")
 	}
@@ -145,32 +390,96 @@ func RunCSV(path string, caseCol string, activityCol string, timestampCol string
 		results.OrderViolationRate = float64(orderViolations) / float64(rowCount)
 	}
 
+	// failed collects the exception checks to export.
+	var failed []string
 	backlog := []BacklogIssue{}
 	if results.RowCount == 0 {
 		backlog = append(backlog, BacklogIssue{Severity: "blocking", Issue: "Empty dataset", Fix: "Check ingestion filters or source file."})
 	}
-	for col, rate := range results.MissingRates {
-		if rate > thresholds.MissingValue {
+	missingColumns := make([]string, 0, len(results.MissingRates))
+	for col := range results.MissingRates {
+		missingColumns = append(missingColumns, col)
+	}
+	// Sorted so warnings and the backlog come out in the same order every run.
+	sort.Strings(missingColumns)
+	for _, col := range missingColumns {
+		if rate := results.MissingRates[col]; rate > thresholds.MissingValue {
+			failed = append(failed, exceptionCheck("missing", col))
 			results.Warnings = append(results.Warnings, fmt.Sprintf("High missing rate for %s: %.2f", col, rate))
 			backlog = append(backlog, BacklogIssue{Severity: "warning", Issue: fmt.Sprintf("Missing values above threshold in %s", col), Fix: "Review missingness strategy."})
 		}
 	}
 	if results.DuplicateRate > thresholds.Duplicate {
+		failed = append(failed, checkDuplicates)
 		results.Warnings = append(results.Warnings, fmt.Sprintf("Duplicate rate above threshold: %.2f", results.DuplicateRate))
 		backlog = append(backlog, BacklogIssue{Severity: "warning", Issue: "Duplicate rate above threshold", Fix: "Adjust dedupe keys or filter duplicates."})
 	}
 	if results.TimestampParseRate < 1.0-thresholds.ParseFail {
+		failed = append(failed, checkParseFailures)
 		results.Warnings = append(results.Warnings, fmt.Sprintf("Timestamp parse failures above threshold: %.2f", 1.0-results.TimestampParseRate))
 		backlog = append(backlog, BacklogIssue{Severity: "warning", Issue: "Timestamp parse failures above threshold", Fix: "Specify timestamp format or clean source data."})
 	}
 	if results.OrderViolationRate > thresholds.OrderViol {
+		failed = append(failed, checkOrderViolations)
 		results.Warnings = append(results.Warnings, fmt.Sprintf("Order violations above threshold: %.2f", results.OrderViolationRate))
 		backlog = append(backlog, BacklogIssue{Severity: "warning", Issue: "Case order violations above threshold", Fix: "Sort by case and timestamp or review logging order."})
 	}
+	for _, run := range runs {
+		result := run.finish()
+		results.Rules = append(results.Rules, result)
+		if result.Passed {
+			continue
+		}
+		message := fmt.Sprintf("Rule %s: violation rate %.2f above threshold %.2f", result.Name, result.Rate, result.Threshold)
+		if result.Error != "" {
+			message = fmt.Sprintf("Rule %s could not run: %s", result.Name, result.Error)
+		} else {
+			failed = append(failed, exceptionCheck("rule", result.Name))
+		}
+		switch result.Severity {
+		case SeverityBlocking:
+			results.BlockingIssues = append(results.BlockingIssues, message)
+		case SeverityWarning:
+			results.Warnings = append(results.Warnings, message)
+		}
+		fix := run.rule.Spec().Fix
+		if fix == "" {
+			fix = "Correct the source data or adjust the rule pack."
+		}
+		backlog = append(backlog, BacklogIssue{Severity: result.Severity, Issue: message, Fix: fix})
+	}
 
+	if results.Exceptions, err = exceptions.write(opts.ExceptionDir, failed); err != nil {
+		return results, backlog, fmt.Errorf("write exceptions: %w", err)
+	}
 	return results, backlog, nil
 }
 
+// checkSerial reads every record and checks it on s.
+func checkSerial(reader eventlog.Reader, s *shard, rowCount *int) error {
+	if err := readAll(reader, rowCount, s.check); err != nil {
+		return err
+	}
+	return s.finish()
+}
+
+// readAll passes every record to add with its 1-based row number.
+func readAll(reader eventlog.Reader, rowCount *int, add func(row int64, record []string) error) error {
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		*rowCount++
+		if err := add(int64(*rowCount), record); err != nil {
+			return err
+		}
+	}
+}
+
 func WriteOutputs(outputDir string, results Results, backlog []BacklogIssue) error {
 	qualityDir := filepath.Join(outputDir, "quality")
 	if err := os.MkdirAll(qualityDir, 0o755); err != nil {
@@ -212,6 +521,29 @@ func buildSummary(results Results, backlog []BacklogIssue) string {
 	for col, rate := range results.MissingRates {
 		lines = append(lines, fmt.Sprintf("- %s: %.2f", col, rate))
 	}
+	if len(results.Rules) > 0 {
+		lines = append(lines, "", "## Rules")
+		for _, rule := range results.Rules {
+			status := "pass"
+			switch {
+			case rule.Error != "":
+				status = "error: " + rule.Error
+			case !rule.Passed:
+				status = "fail"
+			}
+			lines = append(lines, fmt.Sprintf("- %s (%s, %s): %d of %d violated, %.2f vs threshold %.2f, %s", rule.Name, rule.Type, rule.Severity, rule.Violations, rule.Checked, rule.Rate, rule.Threshold, status))
+		}
+	}
+	if len(results.Exceptions) > 0 {
+		lines = append(lines, "", "## Exceptions")
+		for _, file := range results.Exceptions {
+			line := fmt.Sprintf("- %s: %d rows in %s", file.Check, file.Total, file.Path)
+			if file.Truncated {
+				line += fmt.Sprintf(" (first %d written)", file.Written)
+			}
+			lines = append(lines, line)
+		}
+	}
 	lines = append(lines, "", "## Warnings")
 	if len(results.Warnings) == 0 {
 		lines = append(lines, "- None")
@@ -257,24 +589,34 @@ func getValue(record []string, idx int) string {
 	return record[idx]
 }
 
-func parseTimestamp(value string, explicitFormat string) (time.Time, error) {
-	if explicitFormat != "" {
-		return time.Parse(explicitFormat, value)
-	}
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02 15:04:05",
-		"2006-01-02",
-		"01/02/2006 15:04:05",
-		"01/02/2006",
-		"02/01/2006 15:04:05",
-		"02/01/2006",
-	}
-	for _, format := range formats {
-		parsed, err := time.Parse(format, value)
-		if err == nil {
-			return parsed, nil
-		}
-	}
-	return time.Time{}, fmt.Errorf("unrecognized timestamp format")
+// Exception checks for the built-in metrics; missing values and rules are
+// named per column and per rule.
+const (
+	checkDuplicates      = "duplicates"
+	checkOrderViolations = "order_violations"
+	checkParseFailures   = "parse_failures"
+)
+
+// lastEvent is the latest timestamp seen for a case and its row.
+type lastEvent struct {
+	at  time.Time
+	row int64
 }
+
+func withDetail(exception Exception, detail string) Exception {
+	exception.Detail = detail
+	return exception
+}
+
+type eventRuleRun struct {
+	rule EventRule
+	*ruleRun
+}
+
+type caseRuleRun struct {
+	rule CaseRule
+	*ruleRun
+}
+
+// timestampSample bounds the values read to infer a timestamp layout.
+const timestampSample = 1000
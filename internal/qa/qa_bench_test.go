@@ -0,0 +1,101 @@
+package qa
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const benchRules = `rules:
+  - name: amount_format
+    type: regex
+    column: amount
+    pattern: '[0-9]+\.[0-9]{2}'
+  - type: allowed_activities
+    values: [A0, A1, A2, A3]
+  - type: case_length
+    min: 15
+  - type: end_activities
+    values: [A4]
+`
+
+// writeLog writes rows events of interleaved 15-event cases, with missing
+// activities, duplicates, unparseable timestamps, and out-of-order events
+// sprinkled in.
+func writeLog(tb testing.TB, path string, rows int) {
+	tb.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		tb.Fatalf("create log: %v", err)
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+	fmt.Fprintln(out, "case_id,activity,timestamp,amount")
+	const cases, events = 1000, 15
+	base := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < rows; i++ {
+		caseID := i/(cases*events)*cases + i%cases
+		event := i / cases % events
+		at := base.Add(time.Duration(caseID)*time.Minute + time.Duration(event)*time.Hour)
+		activity := fmt.Sprintf("A%d", event%5)
+		if i%503 == 0 {
+			activity = ""
+		}
+		if i%101 == 0 {
+			at = at.Add(-2 * time.Hour)
+		}
+		timestamp := at.Format("2006-01-02 15:04:05")
+		if i%701 == 0 {
+			timestamp = "n/a"
+		}
+		line := fmt.Sprintf("c%d,%s,%s,%d.%02d", caseID, activity, timestamp, i%977, i%100)
+		if i%89 == 0 {
+			line = fmt.Sprintf("c%d,%s,%s,%d", caseID, activity, timestamp, i%977)
+		}
+		fmt.Fprintln(out, line)
+		if i%97 == 0 {
+			fmt.Fprintln(out, line)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		tb.Fatalf("write log: %v", err)
+	}
+}
+
+// BenchmarkRun compares serial QA with worker pools of increasing size; the
+// speed-up depends on GOMAXPROCS.
+func BenchmarkRun(b *testing.B) {
+	dir := b.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	writeLog(b, path, 200000)
+	info, err := os.Stat(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pack, err := ParseRulePack([]byte(benchRules))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(info.Size())
+			for i := 0; i < b.N; i++ {
+				_, _, err := Run(path, Options{
+					CaseColumn:      "case_id",
+					ActivityColumn:  "activity",
+					TimestampColumn: "timestamp",
+					Rules:           pack.Rules,
+					Workers:         workers,
+					ExceptionDir:    filepath.Join(dir, "exceptions"),
+					ExceptionLimit:  DefaultExceptionLimit,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
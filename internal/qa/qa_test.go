@@ -1,9 +1,15 @@
 package qa
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
 )
 
 func TestRunCSVBasic(t *testing.T) {
@@ -30,3 +36,404 @@ func TestRunCSVBasic(t *testing.T) {
 		t.Fatalf("unexpected backlog for non-empty input")
 	}
 }
+
+func TestRunUsesSchemaSidecar(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "source_extract.csv")
+	content := "id,status,changed_at\n1,open,2024-01-01T10:00:00+01:00\n1,closed,01/02/2024 10:00:00\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	schema := eventlog.Schema{Columns: []eventlog.SchemaColumn{
+		{Name: "id", SourceType: "INT8", Type: eventlog.TypeInteger},
+		{Name: "status", SourceType: "TEXT", Type: eventlog.TypeString},
+		{Name: "changed_at", SourceType: "TIMESTAMPTZ", Type: eventlog.TypeTimestamp},
+	}}
+	if err := eventlog.WriteSchema(path, schema); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	results, _, err := Run(path, Options{CaseColumn: "id", ActivityColumn: "status", TimestampColumn: "changed_at", Thresholds: Thresholds{ParseFail: 1}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if results.TimestampParseRate != 0.5 {
+		t.Fatalf("expected strict RFC 3339 parsing, got parse rate %.2f", results.TimestampParseRate)
+	}
+	if results.SourceTypes["changed_at"] != "TIMESTAMPTZ" {
+		t.Fatalf("source types = %v", results.SourceTypes)
+	}
+}
+
+func TestRunOnDefaultStageLogUsesDerivedSidecar(t *testing.T) {
+	runDir := t.TempDir()
+	extract := filepath.Join(runDir, "stage_00_extract", "source_extract.csv")
+	normalised := filepath.Join(runDir, "stage_01_ingest_profile", "normalised_log.csv")
+	filtered := filepath.Join(runDir, "stage_03_clean_filter", "filtered_log.csv")
+	// The stage scripts rename the mapped columns and rewrite timestamps the
+	// way pandas writes them.
+	files := map[string]string{
+		extract:    "id,status,changed_at\n1,open,2024-01-01T10:00:00+01:00\n1,closed,2024-01-02T10:00:00.5+01:00\n",
+		normalised: "case:concept:name,concept:name,time:timestamp\n1,open,2024-01-01 09:00:00+00:00\n1,closed,2024-01-02 09:00:00.500000+00:00\n",
+		filtered:   "case:concept:name,concept:name,time:timestamp\n1,open,2024-01-01 09:00:00+00:00\n1,closed,2024-01-02 09:00:00.500000+00:00\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write csv: %v", err)
+		}
+	}
+	schema := eventlog.Schema{Columns: []eventlog.SchemaColumn{
+		{Name: "id", SourceType: "INT8", Type: eventlog.TypeInteger},
+		{Name: "status", SourceType: "TEXT", Type: eventlog.TypeString},
+		{Name: "changed_at", SourceType: "TIMESTAMPTZ", Type: eventlog.TypeTimestamp},
+	}}
+	if err := eventlog.WriteSchema(extract, schema); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	renames := map[string]string{"id": "case:concept:name", "status": "concept:name", "changed_at": "time:timestamp"}
+	if err := eventlog.DeriveSchema(extract, normalised, renames); err != nil {
+		t.Fatalf("derive normalised schema: %v", err)
+	}
+	if err := eventlog.DeriveSchema(normalised, filtered, renames); err != nil {
+		t.Fatalf("derive filtered schema: %v", err)
+	}
+
+	results, _, err := Run(filtered, Options{CaseColumn: "case:concept:name", ActivityColumn: "concept:name", TimestampColumn: "time:timestamp"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if results.SourceTypes["time:timestamp"] != "TIMESTAMPTZ" || results.SourceTypes["case:concept:name"] != "INT8" {
+		t.Fatalf("source types = %v", results.SourceTypes)
+	}
+	if results.TimestampFormat != "2006-01-02 15:04:05Z07:00" || results.TimestampParseRate != 1 || len(results.Warnings) != 0 {
+		t.Fatalf("expected strict parsing in the derived layout, got %s at %.2f (%v)", results.TimestampFormat, results.TimestampParseRate, results.Warnings)
+	}
+}
+
+func TestRunInfersDayFirstTimestamps(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	// Read month first, 02/04 would come before 28/03 and break the order.
+	content := "case_id,activity,timestamp\n1,A,28/03/2024 10:00\n1,B,02/04/2024 09:00\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	results, _, err := Run(path, Options{CaseColumn: "case_id", ActivityColumn: "activity", TimestampColumn: "timestamp"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if results.TimestampFormat != "02/01/2006 15:04" || results.TimestampParseRate != 1 || results.OrderViolationRate != 0 {
+		t.Fatalf("format %q, parse rate %.2f, order violations %.2f", results.TimestampFormat, results.TimestampParseRate, results.OrderViolationRate)
+	}
+	if !results.TimestampsWithoutZone {
+		t.Fatal("expected timestamps without a zone to be recorded")
+	}
+	found := false
+	for _, assumption := range BuildScorecard(results, ScorecardConfig{}).Assumptions {
+		found = found || strings.Contains(assumption.Statement, "read as UTC")
+	}
+	if !found {
+		t.Fatal("expected the UTC reading in the assumptions register")
+	}
+}
+
+func TestRunRules(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	content := "case_id,activity,timestamp,priority\n" +
+		"T1,Created,2024-03-01 00:00:00,high\n" +
+		"T1,Closed,2024-03-02 00:00:00,low\n" +
+		"T2,Assigned,2024-03-01 09:00:00,urgent\n" +
+		"T2,Created,2024-03-01 08:00:00,\n" +
+		"T3,Created,2025-01-01 10:00:00,high\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	pack, err := ParseRulePack([]byte(`
+rules:
+  - type: domain
+    column: priority
+    values: [high, low]
+    severity: blocking
+  - type: regex
+    column: case_id
+    pattern: 'T\d+'
+  - type: allowed_activities
+    values: [Created, Assigned, Closed]
+  - type: case_length
+    min: 2
+    threshold: 0.5
+  - type: future_timestamps
+    tolerance: 24h
+  - type: timestamp_granularity
+    threshold: 0.3
+    severity: info
+  - name: starts_created
+    type: start_activities
+    values: [Created]
+  - type: end_activities
+    values: [Closed]
+  - type: regex
+    column: region
+    pattern: '.*'
+`))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	results, backlog, err := Run(path, Options{CaseColumn: "case_id", ActivityColumn: "activity", TimestampColumn: "timestamp", Thresholds: Thresholds{MissingValue: 1, Duplicate: 1, OrderViol: 1, ParseFail: 1}, Rules: pack.Rules})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := map[string][3]int64{ // checked, violations, passed
+		"domain_priority":       {4, 1, 0},
+		"regex_case_id":         {5, 0, 1},
+		"allowed_activities":    {5, 0, 1},
+		"case_length":           {3, 1, 1},
+		"future_timestamps":     {5, 1, 0},
+		"timestamp_granularity": {5, 2, 0},
+		"starts_created":        {3, 0, 1},
+		"end_activities":        {3, 2, 0},
+	}
+	for _, rule := range results.Rules {
+		if rule.Name == "regex_region" {
+			if rule.Error == "" || rule.Passed {
+				t.Errorf("expected missing column error, got %+v", rule)
+			}
+			continue
+		}
+		expected, ok := want[rule.Name]
+		passed := int64(0)
+		if rule.Passed {
+			passed = 1
+		}
+		if !ok || rule.Checked != expected[0] || rule.Violations != expected[1] || passed != expected[2] {
+			t.Errorf("%s: got %+v, want %v", rule.Name, rule, expected)
+		}
+	}
+	if len(results.Rules) != len(want)+1 {
+		t.Fatalf("got %d rule results", len(results.Rules))
+	}
+	if len(results.BlockingIssues) != 1 || len(backlog) != 5 {
+		t.Fatalf("blocking %v, backlog %v", results.BlockingIssues, backlog)
+	}
+	if _, err := ParseRulePack([]byte("rules:\n  - type: nonsense\n")); err == nil {
+		t.Fatal("expected unknown rule type to fail")
+	}
+}
+
+func TestBuildScorecard(t *testing.T) {
+	results := Results{
+		RowCount:              100,
+		MissingRates:          map[string]float64{"case_id": 0, "activity": 0.1, "timestamp": 0},
+		DuplicateRate:         0.01,
+		OrderViolationRate:    0,
+		TimestampParseRate:    1,
+		TimestampFormat:       "01/02/2006",
+		TimestampAlternatives: []string{"02/01/2006"},
+		Thresholds:            Thresholds{MissingValue: 0.05, Duplicate: 0.02, OrderViol: 0.02, ParseFail: 0.02},
+		Rules: []RuleResult{
+			{Name: "end_activities", Dimension: DimensionConsistency, Threshold: 0, Checked: 10, Violations: 2, Rate: 0.2},
+			{Name: "regex_region", Dimension: DimensionValidity, Error: "column region not found"},
+		},
+	}
+	card := BuildScorecard(results, ScorecardConfig{})
+	scores := map[string]float64{}
+	for _, dimension := range card.Dimensions {
+		scores[dimension.Name] = dimension.Score
+	}
+	// Completeness: activity is 0.05 over its threshold, scored 1-0.05/0.95.
+	completeness := (1 + 1 + (1 - 0.05/0.95)) / 3
+	if diff := scores[DimensionCompleteness] - completeness; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("completeness %.4f, want %.4f", scores[DimensionCompleteness], completeness)
+	}
+	if scores[DimensionUniqueness] != 1 || scores[DimensionConsistency] != 0.8 || scores[DimensionValidity] != 1 {
+		t.Fatalf("scores %v", scores)
+	}
+	want := 0.25*completeness + 0.15 + 0.2 + 0.2*0.8 + 0.2
+	if diff := card.Score - want; diff > 1e-9 || diff < -1e-9 || card.Status != StatusGreen {
+		t.Fatalf("score %.4f (%s), want %.4f green", card.Score, card.Status, want)
+	}
+	var high int
+	for _, assumption := range card.Assumptions {
+		if assumption.Impact == "high" {
+			high++
+		}
+	}
+	if high != 1 || card.Assumptions[0].ID != "A01" || !strings.Contains(card.Assumptions[len(card.Assumptions)-1].Statement, "regex_region") {
+		t.Fatalf("assumptions %+v", card.Assumptions)
+	}
+	results.BlockingIssues = []string{"No rows"}
+	if card := BuildScorecard(results, ScorecardConfig{}); card.Status != StatusRed {
+		t.Fatalf("blocking issues should rate red, got %s", card.Status)
+	}
+}
+
+func TestRunExportsExceptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	content := "case_id,activity,timestamp\n" +
+		"1,A,2024-01-01 10:00:00\n" +
+		"1,A,2024-01-01 10:00:00\n" +
+		"1,B,2024-01-01 09:00:00\n" +
+		"2,,2024-01-01 09:00:00\n" +
+		"2,C,soon\n" +
+		"2,C,2024-01-01 10:00:00\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	exceptionDir := filepath.Join(dir, "quality", "exceptions")
+	results, _, err := Run(path, Options{CaseColumn: "case_id", ActivityColumn: "activity", TimestampColumn: "timestamp", TimestampFormat: "2006-01-02 15:04:05", ExceptionDir: exceptionDir, ExceptionLimit: 1})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	checks := map[string]ExceptionFile{}
+	for _, file := range results.Exceptions {
+		checks[file.Check] = file
+	}
+	if len(checks) != 4 || checks["duplicates"].Total != 1 || checks["order_violations"].Total != 1 || checks["parse_failures"].Total != 1 || checks["missing_activity"].Total != 1 {
+		t.Fatalf("exceptions %+v", results.Exceptions)
+	}
+	data, err := os.ReadFile(filepath.Join(exceptionDir, "order_violations.csv"))
+	if err != nil {
+		t.Fatalf("read exceptions: %v", err)
+	}
+	if want := "row,case_id,activity,timestamp,detail\n3,1,B,2024-01-01 09:00:00,before row 2 of the same case\n"; string(data) != want {
+		t.Fatalf("order violations:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(exceptionDir, "missing_case_id.csv")); !os.IsNotExist(err) {
+		t.Fatalf("passing checks should not be exported: %v", err)
+	}
+
+	// A later run with exceptions off must not leave the old files behind.
+	if _, _, err := Run(path, Options{CaseColumn: "case_id", ActivityColumn: "activity", TimestampColumn: "timestamp", ExceptionDir: exceptionDir}); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if _, err := os.Stat(exceptionDir); !os.IsNotExist(err) {
+		t.Fatalf("stale exceptions left behind: %v", err)
+	}
+}
+
+func TestRunWithinMemoryBudget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	var content strings.Builder
+	content.WriteString("case_id,activity,timestamp\n")
+	for i := 0; i < 400; i++ {
+		// Every eleventh event goes back an hour and every seventh is
+		// written twice.
+		hour := i % 20
+		if i%11 == 0 {
+			hour = (hour + 19) % 20
+		}
+		line := fmt.Sprintf("c%d,A%d,2024-01-01 %02d:00:00\n", i/20, i%3, hour)
+		content.WriteString(line)
+		if i%7 == 0 {
+			content.WriteString(line)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	pack, err := ParseRulePack([]byte("rules:\n  - type: end_activities\n    values: [A0]\n"))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	run := func(name string, budget int64, duplicates string) (Results, string) {
+		exceptionDir := filepath.Join(dir, name, "exceptions")
+		results, _, err := Run(path, Options{CaseColumn: "case_id", ActivityColumn: "activity", TimestampColumn: "timestamp", TimestampFormat: "2006-01-02 15:04:05", Rules: pack.Rules, MemoryBudget: budget, SpillDir: dir, Duplicates: duplicates, ExceptionDir: exceptionDir, ExceptionLimit: 5})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		data, err := os.ReadFile(filepath.Join(exceptionDir, "order_violations.csv"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return results, string(data)
+	}
+	memory, memoryExceptions := run("memory", 0, "")
+	spilled, spilledExceptions := run("spilled", 1024, DuplicatesExact)
+	if spilled.Partitions < 2 {
+		t.Fatalf("expected spill partitions, got %d", spilled.Partitions)
+	}
+	if memory.DuplicateRate == 0 || memory.OrderViolationRate == 0 {
+		t.Fatalf("test log has no duplicates or order violations: %+v", memory)
+	}
+	if spilled.DuplicateRate != memory.DuplicateRate || spilled.OrderViolationRate != memory.OrderViolationRate || spilled.Rules[0] != memory.Rules[0] {
+		t.Fatalf("spilled %+v differs from in-memory %+v", spilled, memory)
+	}
+	if spilledExceptions != memoryExceptions {
+		t.Fatalf("exceptions differ:\n%s\n%s", spilledExceptions, memoryExceptions)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "pm-assist-qa-") {
+			t.Fatalf("spill files left behind: %s", entry.Name())
+		}
+	}
+
+	estimated, _ := run("estimated", 1<<20, DuplicatesEstimate)
+	if estimated.DuplicateEstimate == nil || estimated.DuplicateRate < memory.DuplicateRate || estimated.DuplicateRate-memory.DuplicateRate > 0.01 {
+		t.Fatalf("estimated duplicate rate %.4f vs exact %.4f (%+v)", estimated.DuplicateRate, memory.DuplicateRate, estimated.DuplicateEstimate)
+	}
+}
+
+func TestRunWorkersMatchSerial(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	writeLog(t, path, 20000)
+	pack, err := ParseRulePack([]byte(benchRules))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	thresholds := Thresholds{MissingValue: 0.001, Duplicate: 0.001, OrderViol: 0.001, ParseFail: 0.001}
+	// run returns qa_results.json and every exception file, keyed by name.
+	run := func(name string, workers int, budget int64) map[string]string {
+		outputDir := filepath.Join(dir, name)
+		results, backlog, err := Run(path, Options{CaseColumn: "case_id", ActivityColumn: "activity", TimestampColumn: "timestamp", Thresholds: thresholds, Rules: pack.Rules, Workers: workers, MemoryBudget: budget, SpillDir: dir, ExceptionDir: filepath.Join(outputDir, "exceptions"), ExceptionLimit: 50})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := WriteOutputs(outputDir, results, backlog); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		files := map[string]string{}
+		for _, pattern := range []string{"quality/qa_results.json", "exceptions/*.csv"} {
+			matches, _ := filepath.Glob(filepath.Join(outputDir, pattern))
+			for _, match := range matches {
+				data, err := os.ReadFile(match)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				files[filepath.Base(match)] = string(data)
+			}
+		}
+		return files
+	}
+	compare := func(name string, got map[string]string, want map[string]string) {
+		if len(got) != len(want) {
+			t.Fatalf("%s wrote %d files, serial %d", name, len(got), len(want))
+		}
+		// Only the recorded worker count may differ.
+		workers := regexp.MustCompile(`"workers": \d+`)
+		for file, data := range want {
+			if workers.ReplaceAllString(got[file], "") != workers.ReplaceAllString(data, "") {
+				t.Fatalf("%s: %s differs from serial:\n%s\n%s", name, file, got[file], data)
+			}
+		}
+	}
+
+	serial := run("serial", 1, 0)
+	if len(serial) < 6 {
+		t.Fatalf("expected failed checks to export, got %d files", len(serial))
+	}
+	compare("parallel", run("parallel", 3, 0), serial)
+	spilledSerial := run("spilled-serial", 1, 64<<10)
+	compare("spilled-parallel", run("spilled-parallel", 3, 64<<10), spilledSerial)
+}
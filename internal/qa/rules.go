@@ -0,0 +1,338 @@
+package qa
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RulePackFile is the project file review reads rules from by default.
+const RulePackFile = "qa_rules.yaml"
+
+// Rule severities. A failed blocking rule is a blocking issue, a failed
+// warning rule a warning; info rules only reach the backlog.
+const (
+	SeverityBlocking = "blocking"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Outcome is a rule's verdict on one event or case.
+type Outcome int
+
+const (
+	// Skipped means the rule does not apply, e.g. to an empty value.
+	Skipped Outcome = iota
+	Passed
+	Violated
+)
+
+// RuleSpec is one entry of a rule pack. Which fields apply depends on Type.
+type RuleSpec struct {
+	Name     string `yaml:"name" json:"name"`
+	Type     string `yaml:"type" json:"type"`
+	Severity string `yaml:"severity,omitempty" json:"severity"`
+	// Threshold is the violation rate the rule tolerates; unset means the
+	// rule type's default.
+	Threshold *float64 `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	Column    string   `yaml:"column,omitempty" json:"column,omitempty"`
+	Values    []string `yaml:"values,omitempty" json:"values,omitempty"`
+	Pattern   string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Min       *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max       *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+	// Tolerance is a duration such as 24h added to the current time before
+	// timestamps count as in the future.
+	Tolerance string `yaml:"tolerance,omitempty" json:"tolerance,omitempty"`
+	// Dimension is the readiness dimension the rule scores; unset means the
+	// rule type's default.
+	Dimension string `yaml:"dimension,omitempty" json:"dimension,omitempty"`
+	Fix       string `yaml:"fix,omitempty" json:"fix,omitempty"`
+}
+
+// RulePack is a project's rules with its scorecard settings.
+type RulePack struct {
+	Rules     []Rule
+	Scorecard ScorecardConfig
+}
+
+// rulePackFile is the YAML layout of a rule pack.
+type rulePackFile struct {
+	Rules     []RuleSpec      `yaml:"rules"`
+	Scorecard ScorecardConfig `yaml:"scorecard"`
+}
+
+// Rule is a configurable QA check. Rules also implement EventRule,
+// CaseRule, or both.
+type Rule interface {
+	// Spec returns the rule's configuration with defaults applied.
+	Spec() RuleSpec
+	// Columns lists the log columns the rule reads besides the mapped case,
+	// activity, and timestamp columns.
+	Columns() []string
+}
+
+// EventRule checks events one at a time. CheckEvent may be called from
+// several goroutines at once.
+type EventRule interface {
+	Rule
+	CheckEvent(event Event) Outcome
+}
+
+// CaseRule checks each case once all of its events are read. Like
+// CheckEvent, CheckCase may be called concurrently.
+type CaseRule interface {
+	Rule
+	CheckCase(c CaseSummary) Outcome
+}
+
+// Event is one row of the log as seen by rules.
+type Event struct {
+	// Row is the 1-based data row, not counting the header.
+	Row      int64
+	Case     string
+	Activity string
+	// Timestamp is zero when the value is empty or does not parse.
+	Timestamp time.Time
+	record    []string
+	columns   map[string]int
+}
+
+// Value returns the event's value in a column, or "" when the column is
+// absent.
+func (e Event) Value(column string) string {
+	idx, ok := e.columns[column]
+	if !ok {
+		return ""
+	}
+	return getValue(e.record, idx)
+}
+
+// CaseSummary describes one case for case rules. Start and end follow the
+// timestamps, ties broken by row order; events without a timestamp are only
+// used when the case has none.
+type CaseSummary struct {
+	ID            string
+	Events        int
+	StartActivity string
+	EndActivity   string
+	Start         time.Time
+	End           time.Time
+}
+
+// add folds an event into the summary.
+func (c *CaseSummary) add(event Event) {
+	c.Events++
+	if event.Timestamp.IsZero() {
+		if c.Start.IsZero() {
+			if c.Events == 1 {
+				c.StartActivity = event.Activity
+			}
+			c.EndActivity = event.Activity
+		}
+		return
+	}
+	if c.Start.IsZero() {
+		c.StartActivity, c.EndActivity = event.Activity, event.Activity
+		c.Start, c.End = event.Timestamp, event.Timestamp
+		return
+	}
+	if event.Timestamp.Before(c.Start) {
+		c.StartActivity, c.Start = event.Activity, event.Timestamp
+	}
+	if !event.Timestamp.Before(c.End) {
+		c.EndActivity, c.End = event.Activity, event.Timestamp
+	}
+}
+
+// RuleResult is the outcome of one rule over the log.
+type RuleResult struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Dimension string  `json:"dimension"`
+	Severity  string  `json:"severity"`
+	Threshold float64 `json:"threshold"`
+	// Checked counts the events or cases the rule applied to.
+	Checked    int64   `json:"checked"`
+	Violations int64   `json:"violations"`
+	Rate       float64 `json:"violation_rate"`
+	Passed     bool    `json:"passed"`
+	// Error is set when the rule could not run, e.g. because its column is
+	// missing.
+	Error string `json:"error,omitempty"`
+}
+
+// RuleBuilder builds a rule from its spec. Defaults for name, severity, and
+// threshold are applied before it is called.
+type RuleBuilder func(spec RuleSpec) (Rule, error)
+
+type ruleType struct {
+	build     RuleBuilder
+	dimension string
+	// threshold is the default violation rate tolerated.
+	threshold float64
+}
+
+var (
+	ruleTypesMu sync.RWMutex
+	ruleTypes   = map[string]ruleType{}
+)
+
+// RegisterRuleType makes a rule type available to rule packs, with the
+// readiness dimension it scores and the violation rate it tolerates by
+// default. It is meant to be called from init functions.
+func RegisterRuleType(name string, dimension string, threshold float64, build RuleBuilder) {
+	ruleTypesMu.Lock()
+	defer ruleTypesMu.Unlock()
+	ruleTypes[name] = ruleType{build: build, dimension: dimension, threshold: threshold}
+}
+
+// RuleTypes returns the registered rule type names in sorted order.
+func RuleTypes() []string {
+	ruleTypesMu.RLock()
+	defer ruleTypesMu.RUnlock()
+	names := make([]string, 0, len(ruleTypes))
+	for name := range ruleTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildRule builds one rule, applying the defaults for its type.
+func BuildRule(spec RuleSpec) (Rule, error) {
+	spec.Type = strings.ToLower(strings.TrimSpace(spec.Type))
+	ruleTypesMu.RLock()
+	kind, ok := ruleTypes[spec.Type]
+	ruleTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown rule type %q (supported: %s)", spec.Type, strings.Join(RuleTypes(), ", "))
+	}
+	if spec.Name == "" {
+		spec.Name = spec.Type
+		if spec.Column != "" {
+			spec.Name += "_" + spec.Column
+		}
+	}
+	switch spec.Severity = strings.ToLower(strings.TrimSpace(spec.Severity)); spec.Severity {
+	case "":
+		spec.Severity = SeverityWarning
+	case SeverityBlocking, SeverityWarning, SeverityInfo:
+	default:
+		return nil, fmt.Errorf("rule %s: severity must be blocking, warning, or info", spec.Name)
+	}
+	if spec.Dimension = strings.ToLower(strings.TrimSpace(spec.Dimension)); spec.Dimension == "" {
+		spec.Dimension = kind.dimension
+	} else if !slices.Contains(Dimensions, spec.Dimension) {
+		return nil, fmt.Errorf("rule %s: dimension must be one of %s", spec.Name, strings.Join(Dimensions, ", "))
+	}
+	if spec.Threshold == nil {
+		threshold := kind.threshold
+		spec.Threshold = &threshold
+	} else if *spec.Threshold < 0 || *spec.Threshold > 1 {
+		return nil, fmt.Errorf("rule %s: threshold must be between 0 and 1", spec.Name)
+	}
+	rule, err := kind.build(spec)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %w", spec.Name, err)
+	}
+	return rule, nil
+}
+
+// ParseRulePack builds the rules of a YAML rule pack.
+func ParseRulePack(data []byte) (RulePack, error) {
+	var file rulePackFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return RulePack{}, err
+	}
+	if err := file.Scorecard.validate(); err != nil {
+		return RulePack{}, fmt.Errorf("scorecard: %w", err)
+	}
+	pack := RulePack{Rules: make([]Rule, 0, len(file.Rules)), Scorecard: file.Scorecard}
+	names := map[string]bool{}
+	for _, spec := range file.Rules {
+		rule, err := BuildRule(spec)
+		if err != nil {
+			return RulePack{}, err
+		}
+		name := rule.Spec().Name
+		if names[name] {
+			return RulePack{}, fmt.Errorf("duplicate rule name %s", name)
+		}
+		names[name] = true
+		pack.Rules = append(pack.Rules, rule)
+	}
+	return pack, nil
+}
+
+// LoadRulePack reads a rule pack file. A missing file yields an empty pack.
+func LoadRulePack(path string) (RulePack, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return RulePack{}, nil
+	}
+	if err != nil {
+		return RulePack{}, err
+	}
+	pack, err := ParseRulePack(data)
+	if err != nil {
+		return RulePack{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return pack, nil
+}
+
+// ruleRun tallies one rule over a log.
+type ruleRun struct {
+	rule   Rule
+	result RuleResult
+}
+
+func newRuleRun(rule Rule) *ruleRun {
+	spec := rule.Spec()
+	return &ruleRun{rule: rule, result: RuleResult{
+		Name:      spec.Name,
+		Type:      spec.Type,
+		Dimension: spec.Dimension,
+		Severity:  spec.Severity,
+		Threshold: *spec.Threshold,
+	}}
+}
+
+func (r *ruleRun) record(outcome Outcome) {
+	switch outcome {
+	case Passed:
+		r.result.Checked++
+	case Violated:
+		r.result.Checked++
+		r.result.Violations++
+	}
+}
+
+// shard returns a copy of r that tallies separately, for one worker.
+func (r *ruleRun) shard() *ruleRun {
+	return &ruleRun{rule: r.rule, result: r.result}
+}
+
+// merge adds the tallies of a shard's copy.
+func (r *ruleRun) merge(other *ruleRun) {
+	r.result.Checked += other.result.Checked
+	r.result.Violations += other.result.Violations
+}
+
+// finish computes the rate and verdict.
+func (r *ruleRun) finish() RuleResult {
+	if r.result.Error != "" {
+		return r.result
+	}
+	if r.result.Checked > 0 {
+		r.result.Rate = float64(r.result.Violations) / float64(r.result.Checked)
+	}
+	r.result.Passed = r.result.Rate <= r.result.Threshold
+	return r.result
+}
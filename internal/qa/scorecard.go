@@ -0,0 +1,284 @@
+package qa
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScorecardVersion is bumped when the scoring or the assumptions register
+// changes meaning.
+const ScorecardVersion = 1
+
+// Readiness dimensions.
+const (
+	DimensionCompleteness = "completeness"
+	DimensionUniqueness   = "uniqueness"
+	DimensionTimeliness   = "timeliness"
+	DimensionConsistency  = "consistency"
+	DimensionValidity     = "validity"
+)
+
+// Dimensions lists the readiness dimensions in report order.
+var Dimensions = []string{DimensionCompleteness, DimensionUniqueness, DimensionTimeliness, DimensionConsistency, DimensionValidity}
+
+// RAG statuses.
+const (
+	StatusGreen = "green"
+	StatusAmber = "amber"
+	StatusRed   = "red"
+)
+
+// ScorecardConfig weights the dimensions and sets the RAG cut-offs. Zero
+// values take the defaults.
+type ScorecardConfig struct {
+	Weights map[string]float64 `yaml:"weights,omitempty" json:"weights"`
+	// Green and Amber are the lowest scores rated green and amber.
+	Green float64 `yaml:"green,omitempty" json:"green"`
+	Amber float64 `yaml:"amber,omitempty" json:"amber"`
+}
+
+var defaultWeights = map[string]float64{
+	DimensionCompleteness: 0.25,
+	DimensionUniqueness:   0.15,
+	DimensionTimeliness:   0.20,
+	DimensionConsistency:  0.20,
+	DimensionValidity:     0.20,
+}
+
+func (c ScorecardConfig) validate() error {
+	for name, weight := range c.Weights {
+		if _, ok := defaultWeights[name]; !ok {
+			return fmt.Errorf("unknown dimension %s", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight for %s is negative", name)
+		}
+	}
+	if c.Green < 0 || c.Green > 1 || c.Amber < 0 || c.Amber > 1 || c.Green != 0 && c.Amber > c.Green {
+		return fmt.Errorf("green and amber must be between 0 and 1, amber below green")
+	}
+	return nil
+}
+
+func (c ScorecardConfig) withDefaults() ScorecardConfig {
+	weights := make(map[string]float64, len(defaultWeights))
+	for name, weight := range defaultWeights {
+		weights[name] = weight
+	}
+	for name, weight := range c.Weights {
+		weights[name] = weight
+	}
+	c.Weights = weights
+	if c.Green == 0 {
+		c.Green = 0.95
+	}
+	if c.Amber == 0 {
+		c.Amber = 0.8
+	}
+	return c
+}
+
+// Scorecard rates how ready a log is for process mining.
+type Scorecard struct {
+	Version int `json:"version"`
+	// Score is the weighted mean of the assessed dimensions.
+	Score       float64          `json:"score"`
+	Status      string           `json:"status"`
+	Dimensions  []DimensionScore `json:"dimensions"`
+	Assumptions []Assumption     `json:"assumptions"`
+	Config      ScorecardConfig  `json:"config"`
+}
+
+// DimensionScore is the mean of a dimension's signals.
+type DimensionScore struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	// Assessed is false when no check covered the dimension; it is then left
+	// out of the overall score.
+	Assessed bool     `json:"assessed"`
+	Score    float64  `json:"score"`
+	Status   string   `json:"status,omitempty"`
+	Signals  []Signal `json:"signals"`
+}
+
+// Signal is one check scored for a dimension: 1 while its rate is within
+// the threshold, falling linearly to 0 at a rate of 1.
+type Signal struct {
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	Threshold float64 `json:"threshold"`
+	Score     float64 `json:"score"`
+}
+
+// Assumption is an entry of the assumptions register.
+type Assumption struct {
+	ID        string `json:"id"`
+	Topic     string `json:"topic"`
+	Statement string `json:"statement"`
+	// Impact is high when a wrong assumption would change the analysis.
+	Impact string `json:"impact"`
+}
+
+func newSignal(name string, rate float64, threshold float64) Signal {
+	score := 1.0
+	if rate > threshold {
+		score = 1 - (rate-threshold)/(1-threshold)
+	}
+	return Signal{Name: name, Rate: rate, Threshold: threshold, Score: score}
+}
+
+// BuildScorecard scores QA results by dimension and lists the assumptions
+// behind them.
+func BuildScorecard(results Results, config ScorecardConfig) Scorecard {
+	config = config.withDefaults()
+	signals := map[string][]Signal{}
+	if results.RowCount > 0 {
+		var columns []string
+		for col := range results.MissingRates {
+			columns = append(columns, col)
+		}
+		sort.Strings(columns)
+		for _, col := range columns {
+			signals[DimensionCompleteness] = append(signals[DimensionCompleteness], newSignal("missing "+col, results.MissingRates[col], results.Thresholds.MissingValue))
+		}
+		signals[DimensionUniqueness] = append(signals[DimensionUniqueness], newSignal("duplicate events", results.DuplicateRate, results.Thresholds.Duplicate))
+		signals[DimensionTimeliness] = append(signals[DimensionTimeliness], newSignal("order violations", results.OrderViolationRate, results.Thresholds.OrderViol))
+		signals[DimensionValidity] = append(signals[DimensionValidity], newSignal("timestamp parse failures", 1-results.TimestampParseRate, results.Thresholds.ParseFail))
+	}
+
+	// Fixed assumptions come first so their IDs are stable across runs.
+	register := &assumptions{}
+	register.add("identity", "Events with the same case, activity, and timestamp are duplicates; other attributes are ignored.", "medium")
+	register.add("ordering", "Events of a case are expected in timestamp order in the file; an earlier timestamp after a later one is an order violation.", "low")
+	register.add("completeness", "Only empty values count as missing; placeholders such as NULL or n/a are values.", "medium")
+	register.add("thresholds", fmt.Sprintf("Rates are judged against thresholds missing %.2f, duplicates %.2f, order violations %.2f, and parse failures %.2f.", results.Thresholds.MissingValue, results.Thresholds.Duplicate, results.Thresholds.OrderViol, results.Thresholds.ParseFail), "low")
+	register.add("scoring", fmt.Sprintf("Dimensions are weighted %s; scores of at least %.2f are green and at least %.2f amber.", formatWeights(config.Weights), config.Green, config.Amber), "low")
+	switch {
+	case results.TimestampFormat == "":
+		register.add("timestamps", "No timestamp layout was recognised; timestamps are treated as unparseable.", "high")
+	case len(results.TimestampAlternatives) > 0:
+		register.add("timestamps", fmt.Sprintf("Timestamps are read as %s, although %s fit the sample equally well; day and month may be swapped.", results.TimestampFormat, strings.Join(results.TimestampAlternatives, ", ")), "high")
+	default:
+		register.add("timestamps", fmt.Sprintf("Timestamps are read as %s.", results.TimestampFormat), "low")
+	}
+	if results.TimestampsWithoutZone {
+		register.add("timestamps", "Timestamps carry no time zone and are read as UTC; durations across daylight saving changes, or between sources in different zones, may be off by hours.", "medium")
+	}
+	if len(results.SourceTypes) > 0 {
+		register.add("timestamps", "Column types come from the extract's schema sidecar; typed timestamps are parsed strictly.", "low")
+	}
+	for _, rule := range results.Rules {
+		if rule.Error != "" {
+			register.add("rules", fmt.Sprintf("Rule %s was not evaluated (%s); %s is scored without it.", rule.Name, rule.Error, rule.Dimension), "medium")
+			continue
+		}
+		if rule.Checked == 0 {
+			continue
+		}
+		signals[rule.Dimension] = append(signals[rule.Dimension], newSignal("rule "+rule.Name, rule.Rate, rule.Threshold))
+	}
+
+	card := Scorecard{Version: ScorecardVersion, Config: config, Assumptions: []Assumption{}}
+	totalWeight := 0.0
+	for _, name := range Dimensions {
+		dimension := DimensionScore{Name: name, Weight: config.Weights[name], Signals: signals[name]}
+		if len(dimension.Signals) > 0 {
+			sum := 0.0
+			for _, signal := range dimension.Signals {
+				sum += signal.Score
+			}
+			dimension.Assessed = true
+			dimension.Score = sum / float64(len(dimension.Signals))
+			dimension.Status = config.status(dimension.Score)
+			card.Score += dimension.Weight * dimension.Score
+			totalWeight += dimension.Weight
+		} else {
+			dimension.Signals = []Signal{}
+			register.add("coverage", fmt.Sprintf("%s is not assessed: no check covers it; add rules to the rule pack to score it.", strings.ToUpper(name[:1])+name[1:]), "medium")
+		}
+		card.Dimensions = append(card.Dimensions, dimension)
+	}
+	if totalWeight > 0 {
+		card.Score /= totalWeight
+	}
+	card.Status = config.status(card.Score)
+	if len(results.BlockingIssues) > 0 || totalWeight == 0 {
+		card.Status = StatusRed
+	}
+	card.Assumptions = register.items
+	return card
+}
+
+func (c ScorecardConfig) status(score float64) string {
+	switch {
+	case score >= c.Green:
+		return StatusGreen
+	case score >= c.Amber:
+		return StatusAmber
+	default:
+		return StatusRed
+	}
+}
+
+type assumptions struct {
+	items []Assumption
+}
+
+func (a *assumptions) add(topic string, statement string, impact string) {
+	a.items = append(a.items, Assumption{ID: fmt.Sprintf("A%02d", len(a.items)+1), Topic: topic, Statement: statement, Impact: impact})
+}
+
+func formatWeights(weights map[string]float64) string {
+	parts := make([]string, 0, len(Dimensions))
+	for _, name := range Dimensions {
+		parts = append(parts, fmt.Sprintf("%s %.2f", name, weights[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteScorecard writes quality/readiness_scorecard.json and .md.
+func WriteScorecard(outputDir string, card Scorecard) error {
+	qualityDir := filepath.Join(outputDir, "quality")
+	if err := os.MkdirAll(qualityDir, 0o755); err != nil {
+		return err
+	}
+	jsonData, err := json.MarshalIndent(card, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(qualityDir, "readiness_scorecard.json"), jsonData, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(qualityDir, "readiness_scorecard.md"), []byte(buildScorecardSummary(card)), 0o644)
+}
+
+func buildScorecardSummary(card Scorecard) string {
+	lines := []string{
+		"# Data Readiness Scorecard",
+		"",
+		fmt.Sprintf("- Overall: %.2f (%s)", card.Score, strings.ToUpper(card.Status)),
+		"",
+		"| Dimension | Weight | Score | Status | Signals |",
+		"| --- | --- | --- | --- | --- |",
+	}
+	for _, dimension := range card.Dimensions {
+		if !dimension.Assessed {
+			lines = append(lines, fmt.Sprintf("| %s | %.2f | - | not assessed | - |", dimension.Name, dimension.Weight))
+			continue
+		}
+		var signals []string
+		for _, signal := range dimension.Signals {
+			signals = append(signals, fmt.Sprintf("%s %.2f", signal.Name, signal.Score))
+		}
+		lines = append(lines, fmt.Sprintf("| %s | %.2f | %.2f | %s | %s |", dimension.Name, dimension.Weight, dimension.Score, dimension.Status, strings.Join(signals, "; ")))
+	}
+	lines = append(lines, "", "## Assumptions", "", "| ID | Topic | Assumption | Impact |", "| --- | --- | --- | --- |")
+	for _, assumption := range card.Assumptions {
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s |", assumption.ID, assumption.Topic, assumption.Statement, assumption.Impact))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,312 @@
+package qa
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+)
+
+// shard runs the row checks over the cases assigned to it. Every event of a
+// case goes to the same shard, in file order, so order and case checks see
+// whole cases; a serial run has a single shard.
+type shard struct {
+	index  int
+	shards int
+	// columns maps column names to record indexes and is shared read-only.
+	columns                            map[string]int
+	caseCol, activityCol, timestampCol string
+	parse                              func(string) (time.Time, error)
+
+	missing         map[string]int
+	parsed          int
+	parseFailures   int
+	bloom           *bloomFilter
+	bloomDuplicates int64
+	// runs are the shard's own tallies of every rule, in rule pack order.
+	runs       []*ruleRun
+	eventRules []eventRuleRun
+	exceptions *exceptionLog
+	keyed      *keyedState
+	spilled    *spill
+}
+
+// check runs the row checks on one record and queues it for the per-case
+// checks.
+func (s *shard) check(row int64, record []string) error {
+	caseVal := getValue(record, s.columns[s.caseCol])
+	actVal := getValue(record, s.columns[s.activityCol])
+	tsVal := getValue(record, s.columns[s.timestampCol])
+	exception := Exception{Row: row, Case: caseVal, Activity: actVal, Timestamp: tsVal}
+
+	for _, col := range []string{s.caseCol, s.activityCol, s.timestampCol} {
+		if strings.TrimSpace(getValue(record, s.columns[col])) == "" {
+			s.missing[col]++
+			s.exceptions.add(exceptionCheck("missing", col), withDetail(exception, "missing "+col))
+		}
+	}
+
+	if s.bloom != nil && s.bloom.add(caseVal+"|"+actVal+"|"+tsVal) {
+		s.bloomDuplicates++
+		s.exceptions.add(checkDuplicates, withDetail(exception, "probable duplicate"))
+	}
+
+	var parsed time.Time
+	if strings.TrimSpace(tsVal) != "" {
+		var err error
+		parsed, err = s.parse(tsVal)
+		if err != nil {
+			s.parseFailures++
+			s.exceptions.add(checkParseFailures, withDetail(exception, "timestamp does not parse"))
+		} else {
+			s.parsed++
+		}
+	}
+
+	event := keyedEvent{row: row, caseID: caseVal, activity: actVal, timestamp: tsVal, at: parsed}
+	if s.spilled != nil {
+		if err := s.spilled.add(event); err != nil {
+			return fmt.Errorf("write spill file: %w", err)
+		}
+	} else {
+		s.keyed.add(event)
+	}
+
+	if len(s.eventRules) == 0 {
+		return nil
+	}
+	ruleEvent := Event{Row: row, Case: caseVal, Activity: actVal, Timestamp: parsed, record: record, columns: s.columns}
+	for _, run := range s.eventRules {
+		outcome := run.rule.CheckEvent(ruleEvent)
+		run.record(outcome)
+		if outcome == Violated {
+			detail := "violates " + run.result.Name
+			if column := run.rule.Spec().Column; column != "" {
+				detail = fmt.Sprintf("%s=%q violates %s", column, ruleEvent.Value(column), run.result.Name)
+			}
+			s.exceptions.add(exceptionCheck("rule", run.result.Name), withDetail(exception, detail))
+		}
+	}
+	return nil
+}
+
+// finish runs the per-case checks once every record has been checked.
+func (s *shard) finish() error {
+	if s.spilled != nil {
+		if err := s.spilled.replay(s.keyed, s.index, s.shards); err != nil {
+			return fmt.Errorf("read spill file: %w", err)
+		}
+		return nil
+	}
+	s.keyed.checkCases()
+	return nil
+}
+
+// shardRow is a record queued for a worker.
+type shardRow struct {
+	row    int64
+	record []string
+}
+
+// shardBatch is the number of records handed to a worker at once; batching
+// keeps channel overhead well below the cost of checking.
+const shardBatch = 512
+
+// pool checks records on one goroutine per shard. Records reach the shards
+// routed by case and in file order, so every shard sees its cases in order:
+// from the reading goroutine through add, or decoded in parallel by decode.
+type pool struct {
+	route   func(caseID string) int
+	caseIdx int
+	queues  []chan []shardRow
+	batches [][]shardRow
+	errs    []error
+	wg      sync.WaitGroup
+}
+
+func newPool(shards []*shard, route func(caseID string) int, caseIdx int) *pool {
+	p := &pool{
+		route:   route,
+		caseIdx: caseIdx,
+		queues:  make([]chan []shardRow, len(shards)),
+		batches: make([][]shardRow, len(shards)),
+		errs:    make([]error, len(shards)),
+	}
+	for i, s := range shards {
+		p.queues[i] = make(chan []shardRow, 4)
+		p.batches[i] = make([]shardRow, 0, shardBatch)
+		p.wg.Add(1)
+		go func(i int, s *shard) {
+			defer p.wg.Done()
+			for batch := range p.queues[i] {
+				// After an error the worker drains its queue so the reader
+				// never blocks.
+				for _, queued := range batch {
+					if p.errs[i] == nil {
+						p.errs[i] = s.check(queued.row, queued.record)
+					}
+				}
+			}
+			if p.errs[i] == nil {
+				p.errs[i] = s.finish()
+			}
+		}(i, s)
+	}
+	return p
+}
+
+func (p *pool) add(row int64, record []string) error {
+	i := p.route(getValue(record, p.caseIdx))
+	p.batches[i] = append(p.batches[i], shardRow{row: row, record: record})
+	if len(p.batches[i]) == shardBatch {
+		p.queues[i] <- p.batches[i]
+		p.batches[i] = make([]shardRow, 0, shardBatch)
+	}
+	return nil
+}
+
+// wait hands over the last batches, waits for the workers to finish, and
+// returns the first error in shard order.
+func (p *pool) wait() error {
+	for i, batch := range p.batches {
+		if len(batch) > 0 {
+			p.queues[i] <- batch
+		}
+		close(p.queues[i])
+	}
+	p.wg.Wait()
+	for _, err := range p.errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkSize is the raw CSV text decoded by a worker at once.
+const chunkSize = 256 << 10
+
+type rawChunk struct {
+	seq  int
+	text []byte
+}
+
+// decodedChunk is a chunk's records split by shard, numbered from 1 within
+// the chunk.
+type decodedChunk struct {
+	seq     int
+	rows    int
+	batches [][]shardRow
+	err     error
+}
+
+// decode reads the log in raw chunks, parses and routes them on decoders
+// goroutines, and hands the records to the shards in file order, numbering
+// them from rowCount on. Only splitting the text into chunks stays serial.
+func (p *pool) decode(chunks eventlog.Chunked, decoders int, rowCount *int) error {
+	raw := make(chan rawChunk, decoders)
+	out := make(chan decodedChunk, decoders)
+	stop := make(chan struct{})
+	// inflight bounds the chunks read but not yet handed to the shards, so a
+	// slow chunk cannot let the others pile up in memory.
+	inflight := make(chan struct{}, 2*decoders)
+	var readErr error
+	go func() {
+		defer close(raw)
+		for seq := 0; ; seq++ {
+			select {
+			case inflight <- struct{}{}:
+			case <-stop:
+				return
+			}
+			text, err := chunks.ReadChunk(chunkSize)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+			select {
+			case raw <- rawChunk{seq: seq, text: text}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < decoders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range raw {
+				decoded := decodedChunk{seq: chunk.seq}
+				records, err := chunks.ParseChunk(chunk.text)
+				if err != nil {
+					decoded.err = err
+				} else {
+					decoded.rows = len(records)
+					decoded.batches = make([][]shardRow, len(p.queues))
+					for j, record := range records {
+						i := p.route(getValue(record, p.caseIdx))
+						decoded.batches[i] = append(decoded.batches[i], shardRow{row: int64(j + 1), record: record})
+					}
+				}
+				select {
+				case out <- decoded:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	// Chunks finish out of order; hold them until their turn.
+	pending := map[int]decodedChunk{}
+	next := 0
+	var err error
+	for decoded := range out {
+		if err != nil {
+			continue
+		}
+		pending[decoded.seq] = decoded
+		for err == nil {
+			decoded, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if decoded.err != nil {
+				err = decoded.err
+				close(stop)
+				break
+			}
+			base := int64(*rowCount)
+			for i, batch := range decoded.batches {
+				if len(batch) == 0 {
+					continue
+				}
+				for j := range batch {
+					batch[j].row += base
+				}
+				p.queues[i] <- batch
+			}
+			*rowCount += decoded.rows
+			<-inflight
+		}
+	}
+	if err != nil {
+		return err
+	}
+	// The reader has returned: raw was closed before out.
+	return readErr
+}
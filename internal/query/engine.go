@@ -0,0 +1,270 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+
+	_ "modernc.org/sqlite"
+)
+
+const timestampLayout = "2006-01-02 15:04:05.000"
+
+// Columns maps the event log columns onto the canonical events view.
+type Columns struct {
+	CaseID    string
+	Activity  string
+	Timestamp string
+	Resource  string
+}
+
+// Engine is an in-memory SQLite database holding one event log.
+type Engine struct {
+	db     *sql.DB
+	Events int64
+}
+
+// Result holds the column names and typed values returned by a query.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+var viewStatements = []string{
+	`CREATE VIEW cases AS
+SELECT case_id,
+       COUNT(*) AS event_count,
+       MIN(timestamp) AS start_time,
+       MAX(timestamp) AS end_time,
+       CAST(ROUND((julianday(MAX(timestamp)) - julianday(MIN(timestamp))) * 86400) AS INTEGER) AS duration_seconds,
+       group_concat(activity, ' -> ' ORDER BY timestamp, event_index) AS variant
+FROM events
+GROUP BY case_id`,
+	`CREATE VIEW variants AS
+SELECT variant,
+       COUNT(*) AS case_count,
+       ROUND(COUNT(*) * 1.0 / (SELECT COUNT(*) FROM cases), 4) AS case_share
+FROM cases
+GROUP BY variant`,
+	`CREATE VIEW dfg_edges AS
+SELECT source, target, COUNT(*) AS frequency
+FROM (
+  SELECT activity AS source,
+         LEAD(activity) OVER (PARTITION BY case_id ORDER BY timestamp, event_index) AS target
+  FROM events
+)
+WHERE target IS NOT NULL
+GROUP BY source, target`,
+}
+
+// Load reads a CSV or Parquet event log into a fresh in-memory database and
+// registers the events, cases, variants, and dfg_edges views. The raw columns
+// remain available in the log table, joined to events by event_index; a
+// source column named event_index is renamed source_event_index.
+func Load(ctx context.Context, path string, cols Columns, opts eventlog.Options) (*Engine, error) {
+	if cols.CaseID == "" || cols.Activity == "" || cols.Timestamp == "" {
+		return nil, errors.New("case, activity, and timestamp columns are required")
+	}
+	reader, err := eventlog.Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	header := reader.Header()
+	index := map[string]int{}
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, col := range []string{cols.CaseID, cols.Activity, cols.Timestamp, cols.Resource} {
+		if col == "" {
+			continue
+		}
+		if _, ok := index[col]; !ok {
+			return nil, fmt.Errorf("column not found in event log: %s", col)
+		}
+	}
+
+	db, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Each connection to :memory: is a separate database, so pin to one.
+	db.SetMaxOpenConns(1)
+	engine := &Engine{db: db}
+	if err := engine.load(ctx, reader, header, index, cols); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return engine, nil
+}
+
+func (e *Engine) load(ctx context.Context, reader eventlog.Reader, header []string, index map[string]int, cols Columns) error {
+	rawCols := make([]string, len(header))
+	for i, name := range logColumns(header) {
+		rawCols[i] = quoteIdent(name) + " TEXT"
+	}
+	statements := []string{
+		"CREATE TABLE log (event_index INTEGER PRIMARY KEY, " + strings.Join(rawCols, ", ") + ")",
+		"CREATE TABLE events (event_index INTEGER PRIMARY KEY, case_id TEXT, activity TEXT, timestamp TEXT, resource TEXT)",
+	}
+	for _, stmt := range statements {
+		if _, err := e.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(header)+1), ", ")
+	logStmt, err := tx.PrepareContext(ctx, "INSERT INTO log VALUES ("+placeholders+")")
+	if err != nil {
+		return err
+	}
+	defer logStmt.Close()
+	eventStmt, err := tx.PrepareContext(ctx, "INSERT INTO events VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer eventStmt.Close()
+
+	var row int64
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		row++
+		values := make([]any, len(header)+1)
+		values[0] = row
+		for i := range header {
+			values[i+1] = nullable(field(record, i))
+		}
+		if _, err := logStmt.ExecContext(ctx, values...); err != nil {
+			return err
+		}
+		resource := ""
+		if cols.Resource != "" {
+			resource = field(record, index[cols.Resource])
+		}
+		if _, err := eventStmt.ExecContext(ctx,
+			row,
+			nullable(field(record, index[cols.CaseID])),
+			nullable(field(record, index[cols.Activity])),
+			normalizeTimestamp(field(record, index[cols.Timestamp])),
+			nullable(resource),
+		); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, stmt := range viewStatements {
+		if _, err := e.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	e.Events = row
+	return nil
+}
+
+// Run executes a single SQL statement and collects its rows.
+func (e *Engine) Run(ctx context.Context, statement string) (Result, error) {
+	rows, err := e.db.QueryContext(ctx, statement)
+	if err != nil {
+		return Result{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return result, err
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// Close releases the in-memory database.
+func (e *Engine) Close() error {
+	return e.db.Close()
+}
+
+// logColumns names the raw columns of the log table. event_index is the
+// engine's own key, so a source column of that name becomes
+// source_event_index; other clashes, which SQLite judges case-insensitively,
+// get a numbered suffix.
+func logColumns(header []string) []string {
+	taken := map[string]bool{"event_index": true}
+	names := make([]string, len(header))
+	for i, name := range header {
+		candidate := name
+		if strings.EqualFold(candidate, "event_index") {
+			candidate = "source_" + name
+		}
+		for n := 2; taken[strings.ToLower(candidate)]; n++ {
+			candidate = fmt.Sprintf("%s_%d", name, n)
+		}
+		taken[strings.ToLower(candidate)] = true
+		names[i] = candidate
+	}
+	return names
+}
+
+func field(record []string, idx int) string {
+	if idx < 0 || idx >= len(record) {
+		return ""
+	}
+	return record[idx]
+}
+
+func nullable(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// normalizeTimestamp stores parseable timestamps in a sortable UTC form that
+// SQLite date functions understand; anything else is kept verbatim.
+func normalizeTimestamp(value string) any {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	parsed, err := eventlog.ParseTimestamp(value)
+	if err != nil {
+		return value
+	}
+	return parsed.Format(timestampLayout)
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+)
+
+func TestEngineViews(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	content := "case_id,activity,timestamp\n" +
+		"1,Create,2024-03-01 09:00:00\n" +
+		"1,Approve,2024-03-01 10:00:00\n" +
+		"1,Reject,2024-03-02 10:00:00\n" +
+		"2,Create,2024-03-05 09:00:00\n" +
+		"2,Approve,2024-03-05 09:30:00\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	ctx := context.Background()
+	engine, err := Load(ctx, path, Columns{CaseID: "case_id", Activity: "activity", Timestamp: "timestamp"}, eventlog.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	defer engine.Close()
+
+	result, err := engine.Run(ctx, "SELECT case_id, event_count, duration_seconds FROM cases ORDER BY case_id")
+	if err != nil {
+		t.Fatalf("query cases: %v", err)
+	}
+	if len(result.Rows) != 2 {
+		t.Fatalf("expected 2 cases, got %d", len(result.Rows))
+	}
+	if result.Rows[0][2] != int64(90000) {
+		t.Fatalf("unexpected duration for case 1: %v", result.Rows[0][2])
+	}
+
+	result, err = engine.Run(ctx, "SELECT frequency FROM dfg_edges WHERE source = 'Create' AND target = 'Approve'")
+	if err != nil {
+		t.Fatalf("query dfg: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0] != int64(2) {
+		t.Fatalf("unexpected dfg edge result: %v", result.Rows)
+	}
+
+	result, err = engine.Run(ctx, `SELECT COUNT(DISTINCT a.case_id) FROM events a JOIN events b
+ON a.case_id = b.case_id AND a.activity = 'Approve' AND b.activity = 'Reject' AND b.timestamp > a.timestamp
+WHERE strftime('%m', b.timestamp) = '03'`)
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+	if result.Rows[0][0] != int64(1) {
+		t.Fatalf("expected 1 case with Reject after Approve, got %v", result.Rows[0][0])
+	}
+}
+
+func TestEngineRenamesSourceEventIndex(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.csv")
+	content := "event_index,case_id,activity,timestamp,Event_Index\n" +
+		"90,1,Create,2024-03-01 09:00:00,x\n" +
+		"80,1,Approve,2024-03-01 10:00:00,y\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	ctx := context.Background()
+	engine, err := Load(ctx, path, Columns{CaseID: "case_id", Activity: "activity", Timestamp: "timestamp"}, eventlog.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	defer engine.Close()
+
+	result, err := engine.Run(ctx, "SELECT e.event_index, l.source_event_index, l.Event_Index_2, e.activity FROM events e JOIN log l USING (event_index) ORDER BY e.event_index")
+	if err != nil {
+		t.Fatalf("query log: %v", err)
+	}
+	want := [][]any{{int64(1), "90", "x", "Create"}, {int64(2), "80", "y", "Approve"}}
+	if len(result.Rows) != len(want) {
+		t.Fatalf("unexpected rows: %v", result.Rows)
+	}
+	for i, row := range want {
+		for j, value := range row {
+			if result.Rows[i][j] != value {
+				t.Fatalf("row %d: got %v, want %v", i, result.Rows[i], row)
+			}
+		}
+	}
+}
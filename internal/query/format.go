@@ -0,0 +1,66 @@
+package query
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteTable renders the result as an aligned text table.
+func WriteTable(w io.Writer, result Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(result.Columns, "\t"))
+	for _, row := range result.Rows {
+		fmt.Fprintln(tw, strings.Join(stringRow(row), "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "(%d rows)\n", len(result.Rows))
+	return err
+}
+
+// WriteCSV renders the result as CSV with a header row.
+func WriteCSV(w io.Writer, result Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(result.Columns); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		if err := writer.Write(stringRow(row)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON renders the result as an array of objects keyed by column name.
+func WriteJSON(w io.Writer, result Result) error {
+	records := make([]map[string]any, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		record := make(map[string]any, len(result.Columns))
+		for i, col := range result.Columns {
+			record[col] = row[i]
+		}
+		records = append(records, record)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+func stringRow(row []any) []string {
+	out := make([]string, len(row))
+	for i, value := range row {
+		if value == nil {
+			continue
+		}
+		out[i] = fmt.Sprint(value)
+	}
+	return out
+}
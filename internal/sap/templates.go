@@ -0,0 +1,231 @@
+// Package sap generates extraction queries that turn standard SAP ERP tables
+// into event logs. Each template unions document creation events with change
+// documents (CDHDR/CDPOS) into one case/activity/timestamp/resource result.
+package sap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+)
+
+// TimestampFormat is the layout of the generated timestamp column. SAP stores
+// dates as YYYYMMDD and times as HHMMSS text, which the queries reformat.
+const TimestampFormat = "2006-01-02 15:04:05"
+
+// Result column names shared by every template.
+const (
+	ColumnCaseID    = "case_id"
+	ColumnActivity  = "activity"
+	ColumnTimestamp = "timestamp"
+	ColumnResource  = "resource"
+)
+
+// Template is a built-in process extraction template.
+type Template struct {
+	Name        string
+	Label       string
+	Description string
+	build       func(q *sqlBuilder) string
+	params      []config.QueryParam
+}
+
+var schemaPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+var commonParams = []config.QueryParam{
+	{Name: "client", Type: "string", Required: true},
+	{Name: "from_date", Type: "string", Default: "19000101"},
+}
+
+var templates = []Template{
+	{
+		Name:        "sap-p2p",
+		Label:       "SAP Procure-to-Pay",
+		Description: "Purchase orders (EKKO) with goods and invoice receipts (EKBE) and purchasing change documents; one case per purchase order",
+		build:       buildP2P,
+		params:      commonParams,
+	},
+	{
+		Name:        "sap-o2c",
+		Label:       "SAP Order-to-Cash",
+		Description: "Sales orders (VBAK/VBAP) with deliveries (LIKP/LIPS), goods issues, invoices (VBRK/VBRP), and sales change documents; one case per sales order",
+		build:       buildO2C,
+		params:      commonParams,
+	},
+	{
+		Name:        "sap-changes",
+		Label:       "SAP change documents",
+		Description: "Field changes from CDHDR/CDPOS for one object class; one case per object ID",
+		build: func(q *sqlBuilder) string {
+			return q.changes("${object_class}")
+		},
+		params: append([]config.QueryParam{{Name: "object_class", Type: "string", Default: "EINKBELEG"}}, commonParams...),
+	},
+}
+
+// Templates returns the built-in templates.
+func Templates() []Template {
+	return append([]Template(nil), templates...)
+}
+
+// Names returns the template names in display order.
+func Names() []string {
+	names := make([]string, len(templates))
+	for i, tmpl := range templates {
+		names[i] = tmpl.Name
+	}
+	return names
+}
+
+// Lookup returns the named template.
+func Lookup(name string) (Template, bool) {
+	for _, tmpl := range templates {
+		if tmpl.Name == name {
+			return tmpl, true
+		}
+	}
+	return Template{}, false
+}
+
+// Query generates the template's SQL for a driver. Tables are read from
+// schema when it is set (e.g. SAPHANADB or SAPSR3). The query takes the SAP
+// client and a YYYYMMDD lower date bound as parameters.
+func (t Template) Query(driver db.Driver, schema string) (config.QueryTemplate, error) {
+	if schema != "" && !schemaPattern.MatchString(schema) {
+		return config.QueryTemplate{}, fmt.Errorf("invalid SAP schema name: %q", schema)
+	}
+	q := &sqlBuilder{driver: driver, dialect: driver.Info().Dialect, schema: schema}
+	return config.QueryTemplate{
+		Name:        t.Name,
+		Description: t.Description,
+		SQL:         t.build(q),
+		Params:      append([]config.QueryParam(nil), t.params...),
+		Process:     t.Name,
+		Mapping: &config.TemplateMapping{
+			CaseID:          ColumnCaseID,
+			Activity:        ColumnActivity,
+			Timestamp:       ColumnTimestamp,
+			Resource:        ColumnResource,
+			TimestampFormat: TimestampFormat,
+		},
+	}, nil
+}
+
+func buildP2P(q *sqlBuilder) string {
+	return q.union(
+		q.event("k.EBELN", "'Create Purchase Order'", q.timestamp("k.AEDAT", ""), "k.ERNAM",
+			q.table("EKKO")+" k",
+			"k.MANDT = ${client} AND k.BSTYP = 'F' AND k.AEDAT >= ${from_date}"),
+		q.event("b.EBELN", "CASE b.VGABE WHEN '1' THEN 'Record Goods Receipt' ELSE 'Record Invoice Receipt' END",
+			q.timestamp("b.CPUDT", "b.CPUTM"), "b.ERNAM",
+			q.table("EKBE")+" b",
+			"b.MANDT = ${client} AND b.VGABE IN ('1', '2') AND b.CPUDT >= ${from_date}"),
+		q.changes("'EINKBELEG'"),
+	)
+}
+
+func buildO2C(q *sqlBuilder) string {
+	deliveries := q.table("LIKP") + " l JOIN " + q.table("LIPS") + " i ON i.MANDT = l.MANDT AND i.VBELN = l.VBELN"
+	invoices := q.table("VBRK") + " k JOIN " + q.table("VBRP") + " r ON r.MANDT = k.MANDT AND r.VBELN = k.VBELN"
+	return q.union(
+		q.event("a.VBELN", "'Create Sales Order'", q.timestamp("a.ERDAT", "a.ERZET"), "a.ERNAM",
+			q.table("VBAK")+" a",
+			"a.MANDT = ${client} AND a.ERDAT >= ${from_date}"),
+		// Items entered after the order header was created.
+		q.event("p.VBELN", "'Add Sales Order Item'", q.timestamp("p.ERDAT", "p.ERZET"), "p.ERNAM",
+			q.table("VBAP")+" p JOIN "+q.table("VBAK")+" a ON a.MANDT = p.MANDT AND a.VBELN = p.VBELN",
+			"p.MANDT = ${client} AND p.ERDAT >= ${from_date} AND (p.ERDAT > a.ERDAT OR (p.ERDAT = a.ERDAT AND p.ERZET > a.ERZET))"),
+		q.distinctEvent("i.VGBEL", "'Create Delivery'", q.timestamp("l.ERDAT", "l.ERZET"), "l.ERNAM",
+			deliveries,
+			"l.MANDT = ${client} AND l.ERDAT >= ${from_date}"),
+		q.distinctEvent("i.VGBEL", "'Post Goods Issue'", q.timestamp("l.WADAT_IST", ""), "l.ERNAM",
+			deliveries,
+			"l.MANDT = ${client} AND l.WADAT_IST >= ${from_date}"),
+		q.distinctEvent("r.AUBEL", "'Create Invoice'", q.timestamp("k.ERDAT", "k.ERZET"), "k.ERNAM",
+			invoices,
+			"k.MANDT = ${client} AND k.ERDAT >= ${from_date}"),
+		q.changes("'VERKBELEG'"),
+	)
+}
+
+// sqlBuilder renders the dialect-specific pieces of a template.
+type sqlBuilder struct {
+	driver  db.Driver
+	dialect string
+	schema  string
+}
+
+func (q *sqlBuilder) table(name string) string {
+	if q.schema == "" {
+		return name
+	}
+	return q.schema + "." + name
+}
+
+// changes selects updated fields from the change documents of one object
+// class; each changed field becomes a "Change <FIELD>" event.
+func (q *sqlBuilder) changes(objectClass string) string {
+	return q.event("h.OBJECTID", q.concat("'Change '", "p.FNAME"), q.timestamp("h.UDATE", "h.UTIME"), "h.USERNAME",
+		q.table("CDHDR")+" h JOIN "+q.table("CDPOS")+" p ON p.MANDANT = h.MANDANT AND p.OBJECTCLAS = h.OBJECTCLAS AND p.OBJECTID = h.OBJECTID AND p.CHANGENR = h.CHANGENR",
+		"h.MANDANT = ${client} AND h.OBJECTCLAS = "+objectClass+" AND p.CHNGIND = 'U' AND h.UDATE >= ${from_date}")
+}
+
+func (q *sqlBuilder) event(caseID, activity, timestamp, resource, from, where string) string {
+	return q.selectEvent("SELECT", caseID, activity, timestamp, resource, from, where)
+}
+
+func (q *sqlBuilder) distinctEvent(caseID, activity, timestamp, resource, from, where string) string {
+	return q.selectEvent("SELECT DISTINCT", caseID, activity, timestamp, resource, from, where)
+}
+
+// selectEvent quotes the result aliases so every dialect returns them in
+// lower case, whatever its identifier folding.
+func (q *sqlBuilder) selectEvent(verb, caseID, activity, timestamp, resource, from, where string) string {
+	return fmt.Sprintf("%s %s AS %s, %s AS %s, %s AS %s, %s AS %s\nFROM %s\nWHERE %s",
+		verb,
+		caseID, q.driver.QuoteIdent(ColumnCaseID),
+		activity, q.driver.QuoteIdent(ColumnActivity),
+		timestamp, q.driver.QuoteIdent(ColumnTimestamp),
+		resource, q.driver.QuoteIdent(ColumnResource),
+		from, where)
+}
+
+func (q *sqlBuilder) union(parts ...string) string {
+	return strings.Join(parts, "\nUNION ALL\n")
+}
+
+// timestamp formats a DATS (and optional TIMS) column pair as
+// YYYY-MM-DD HH:MM:SS; without a time column the event is at midnight.
+func (q *sqlBuilder) timestamp(date string, clock string) string {
+	parts := []string{
+		q.substr(date, 1, 4), "'-'", q.substr(date, 5, 2), "'-'", q.substr(date, 7, 2),
+	}
+	if clock == "" {
+		parts = append(parts, "' 00:00:00'")
+	} else {
+		parts = append(parts, "' '", q.substr(clock, 1, 2), "':'", q.substr(clock, 3, 2), "':'", q.substr(clock, 5, 2))
+	}
+	return q.concat(parts...)
+}
+
+func (q *sqlBuilder) concat(parts ...string) string {
+	switch q.dialect {
+	case sqlguard.DialectMySQL:
+		return "CONCAT(" + strings.Join(parts, ", ") + ")"
+	case sqlguard.DialectTSQL:
+		return strings.Join(parts, " + ")
+	default:
+		return strings.Join(parts, " || ")
+	}
+}
+
+func (q *sqlBuilder) substr(expr string, start int, length int) string {
+	if q.dialect == sqlguard.DialectTSQL {
+		return fmt.Sprintf("SUBSTRING(%s, %d, %d)", expr, start, length)
+	}
+	return fmt.Sprintf("SUBSTR(%s, %d, %d)", expr, start, length)
+}
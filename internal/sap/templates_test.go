@@ -0,0 +1,100 @@
+package sap
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/pm-assist/pm-assist/internal/db"
+	"github.com/pm-assist/pm-assist/internal/sqlguard"
+)
+
+func TestTemplatesPassGuardForEveryDriver(t *testing.T) {
+	for _, name := range db.Drivers() {
+		driver, err := db.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tmpl := range Templates() {
+			query, err := tmpl.Query(driver, "SAPHANADB")
+			if err != nil {
+				t.Fatalf("%s/%s: %v", name, tmpl.Name, err)
+			}
+			rendered, err := db.RenderQuery(name, query, map[string]string{"client": "100"})
+			if err != nil {
+				t.Fatalf("%s/%s render: %v", name, tmpl.Name, err)
+			}
+			if err := sqlguard.Check(driver.Info().Dialect, rendered.SQL); err != nil {
+				t.Fatalf("%s/%s blocked: %v\n%s", name, tmpl.Name, err, rendered.SQL)
+			}
+		}
+	}
+	if _, err := templates[0].Query(mustLookup(t, "postgres"), "sap; drop"); err == nil {
+		t.Fatal("expected invalid schema to fail")
+	}
+}
+
+func TestP2PTemplateBuildsEventLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "erp.sqlite")
+	seed, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.Exec(`
+		CREATE TABLE EKKO (MANDT TEXT, EBELN TEXT, BSTYP TEXT, AEDAT TEXT, ERNAM TEXT);
+		CREATE TABLE EKBE (MANDT TEXT, EBELN TEXT, VGABE TEXT, CPUDT TEXT, CPUTM TEXT, ERNAM TEXT);
+		CREATE TABLE CDHDR (MANDANT TEXT, OBJECTCLAS TEXT, OBJECTID TEXT, CHANGENR TEXT, USERNAME TEXT, UDATE TEXT, UTIME TEXT);
+		CREATE TABLE CDPOS (MANDANT TEXT, OBJECTCLAS TEXT, OBJECTID TEXT, CHANGENR TEXT, FNAME TEXT, CHNGIND TEXT);
+		INSERT INTO EKKO VALUES ('100', '4500000001', 'F', '20240102', 'BUYER'), ('200', '4500000002', 'F', '20240102', 'OTHER');
+		INSERT INTO EKBE VALUES ('100', '4500000001', '1', '20240110', '081500', 'CLERK'), ('100', '4500000001', '2', '20240112', '140000', 'AP');
+		INSERT INTO CDHDR VALUES ('100', 'EINKBELEG', '4500000001', '0001', 'BUYER', '20240103', '093000');
+		INSERT INTO CDPOS VALUES ('100', 'EINKBELEG', '4500000001', '0001', 'NETPR', 'U');
+	`); err != nil {
+		t.Fatal(err)
+	}
+	seed.Close()
+
+	driver := mustLookup(t, "sqlite")
+	dsn, err := driver.BuildDSN(config.DBConfig{Driver: "sqlite", Path: path}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, _ := Lookup("sap-p2p")
+	query, err := tmpl.Query(driver, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := db.RenderQuery("sqlite", query, map[string]string{"client": "100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "source_extract.csv")
+	if _, err := driver.Extract(context.Background(), dsn, rendered.SQL+" ORDER BY 3", out, rendered.Args...); err != nil {
+		t.Fatalf("extract: %v\n%s", err, rendered.SQL)
+	}
+	data, _ := os.ReadFile(out)
+	want := strings.Join([]string{
+		"case_id,activity,timestamp,resource",
+		"4500000001,Create Purchase Order,2024-01-02 00:00:00,BUYER",
+		"4500000001,Change NETPR,2024-01-03 09:30:00,BUYER",
+		"4500000001,Record Goods Receipt,2024-01-10 08:15:00,CLERK",
+		"4500000001,Record Invoice Receipt,2024-01-12 14:00:00,AP",
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Fatalf("extract =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func mustLookup(t *testing.T, name string) db.Driver {
+	t.Helper()
+	driver, err := db.Lookup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return driver
+}
@@ -0,0 +1,302 @@
+// Package sqlguard rejects SQL that could modify a source system before it is
+// sent to a database connector.
+package sqlguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DialectPostgres  = "postgres"
+	DialectMySQL     = "mysql"
+	DialectTSQL      = "tsql"
+	DialectSnowflake = "snowflake"
+	DialectBigQuery  = "bigquery"
+	DialectSQLite    = "sqlite"
+	DialectDuckDB    = "duckdb"
+	DialectOracle    = "oracle"
+	DialectHANA      = "hana"
+)
+
+// Policy error codes reported for blocked queries.
+const (
+	CodeParseError         = "SQL_PARSE_ERROR"
+	CodeEmptyQuery         = "SQL_EMPTY_QUERY"
+	CodeMultipleStatements = "SQL_MULTIPLE_STATEMENTS"
+	CodeNotSelect          = "SQL_NOT_SELECT"
+	CodeWriteKeyword       = "SQL_WRITE_KEYWORD"
+	CodeLockingClause      = "SQL_LOCKING_CLAUSE"
+	CodeDangerousFunction  = "SQL_DANGEROUS_FUNCTION"
+	CodeExecutableComment  = "SQL_EXECUTABLE_COMMENT"
+	CodeUnsupportedDialect = "SQL_UNSUPPORTED_DIALECT"
+)
+
+// Violation is returned when a query is blocked by the read-only policy.
+type Violation struct {
+	Code    string
+	Offset  int
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("query blocked by read-only policy [%s]: %s (at offset %d)", v.Code, v.Message, v.Offset)
+}
+
+func violation(code string, offset int, format string, args ...any) *Violation {
+	return &Violation{Code: code, Offset: offset, Message: fmt.Sprintf(format, args...)}
+}
+
+// statementStarts lists the keywords a read-only query may begin with.
+var statementStarts = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"VALUES": true,
+}
+
+// writeKeywords are rejected anywhere outside literals and identifiers; they
+// also catch data-modifying CTEs and SELECT ... INTO.
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"UPSERT":   true,
+	"TRUNCATE": true,
+	"CREATE":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"COPY":     true,
+	"CALL":     true,
+	"EXEC":     true,
+	"EXECUTE":  true,
+	"INTO":     true,
+}
+
+// dangerousFunctions lists per-dialect functions with side effects: sleeping,
+// locking, file or network access, session control, or running nested SQL.
+var dangerousFunctions = map[string]map[string]bool{
+	DialectPostgres: set(
+		"PG_SLEEP", "PG_SLEEP_FOR", "PG_SLEEP_UNTIL",
+		"PG_READ_FILE", "PG_READ_BINARY_FILE", "PG_LS_DIR", "PG_STAT_FILE",
+		"LO_IMPORT", "LO_EXPORT", "LO_UNLINK",
+		"DBLINK", "DBLINK_EXEC", "DBLINK_CONNECT",
+		"PG_TERMINATE_BACKEND", "PG_CANCEL_BACKEND", "PG_RELOAD_CONF", "PG_ROTATE_LOGFILE",
+		"SET_CONFIG", "NEXTVAL", "SETVAL",
+		"PG_ADVISORY_LOCK", "PG_ADVISORY_XACT_LOCK", "PG_TRY_ADVISORY_LOCK",
+		"QUERY_TO_XML", "QUERY_TO_XML_AND_XMLSCHEMA", "CURSOR_TO_XML",
+	),
+	DialectMySQL: set(
+		"SLEEP", "BENCHMARK", "LOAD_FILE", "GET_LOCK", "RELEASE_LOCK", "RELEASE_ALL_LOCKS",
+		"MASTER_POS_WAIT", "SOURCE_POS_WAIT", "WAIT_FOR_EXECUTED_GTID_SET",
+	),
+	DialectTSQL: set(
+		"OPENROWSET", "OPENDATASOURCE", "OPENQUERY", "XP_CMDSHELL", "XP_DIRTREE", "XP_FILEEXIST",
+	),
+	DialectSnowflake: set(
+		"SYSTEM$WAIT", "SYSTEM$CANCEL_QUERY", "SYSTEM$CANCEL_ALL_QUERIES",
+		"SYSTEM$ABORT_SESSION", "SYSTEM$ABORT_TRANSACTION", "SYSTEM$SET_RETURN_VALUE",
+	),
+	DialectBigQuery: set(
+		"EXTERNAL_QUERY",
+	),
+	DialectSQLite: set(
+		"LOAD_EXTENSION", "READFILE", "WRITEFILE", "EDIT", "FTS3_TOKENIZER",
+	),
+	// DuckDB table functions can read any local file or URL, or reach another
+	// database, which would let a query escape the connector's database file.
+	DialectDuckDB: set(
+		"READ_CSV", "READ_CSV_AUTO", "READ_PARQUET", "PARQUET_SCAN", "READ_JSON", "READ_JSON_AUTO",
+		"READ_NDJSON", "READ_NDJSON_AUTO", "READ_JSON_OBJECTS", "READ_JSON_OBJECTS_AUTO",
+		"READ_NDJSON_OBJECTS", "READ_TEXT", "READ_BLOB", "READ_XLSX", "READ_AVRO", "GLOB",
+		"SNIFF_CSV", "PARQUET_METADATA", "PARQUET_SCHEMA", "PARQUET_FILE_METADATA",
+		"PARQUET_KV_METADATA", "PARQUET_BLOOM_PROBE", "ST_READ", "ST_READ_META", "ST_READOSM",
+		"ST_READSHP", "QUERY", "QUERY_TABLE", "GETENV", "SQLITE_SCAN", "SQLITE_ATTACH",
+		"POSTGRES_SCAN", "POSTGRES_ATTACH", "POSTGRES_QUERY", "POSTGRES_EXECUTE", "MYSQL_SCAN",
+		"MYSQL_QUERY", "MYSQL_EXECUTE", "ODBC_SCAN", "DELTA_SCAN", "ICEBERG_SCAN",
+		"ICEBERG_METADATA", "ICEBERG_SNAPSHOTS", "DUCKDB_SECRETS", "WHICH_SECRET",
+		"LOAD_AWS_CREDENTIALS",
+	),
+	DialectOracle: set(
+		"SLEEP", "HTTPURITYPE",
+	),
+	// HANA side effects are procedures, which CALL already blocks.
+	DialectHANA: set(),
+}
+
+// dangerousPackages are Oracle-style PL/SQL packages whose members reach the
+// network or file system, sleep, lock, or run dynamic SQL. Any reference is
+// blocked, qualified or not.
+var dangerousPackages = map[string]map[string]bool{
+	DialectOracle: set(
+		"UTL_HTTP", "UTL_TCP", "UTL_SMTP", "UTL_MAIL", "UTL_FILE", "UTL_INADDR",
+		"DBMS_LOCK", "DBMS_PIPE", "DBMS_ALERT", "DBMS_SESSION", "DBMS_SQL", "DBMS_XMLGEN",
+		"DBMS_XMLQUERY", "DBMS_SCHEDULER", "DBMS_JOB", "DBMS_JAVA", "DBMS_LDAP", "DBMS_AQ",
+	),
+}
+
+// dangerousKeywords are dialect statements that are unsafe in any position.
+var dangerousKeywords = map[string]map[string]bool{
+	DialectTSQL:   set("WAITFOR", "UPDLOCK", "XLOCK", "TABLOCKX", "HOLDLOCK"),
+	DialectSQLite: set("ATTACH", "DETACH", "PRAGMA", "VACUUM", "REINDEX", "ANALYZE"),
+	DialectDuckDB: set("ATTACH", "DETACH", "PRAGMA", "INSTALL", "LOAD", "EXPORT", "IMPORT", "CHECKPOINT", "VACUUM"),
+}
+
+func set(items ...string) map[string]bool {
+	out := make(map[string]bool, len(items))
+	for _, item := range items {
+		out[item] = true
+	}
+	return out
+}
+
+// Check returns a *Violation if query is not a single read-only statement in
+// the given dialect.
+func Check(dialect string, query string) error {
+	if _, ok := dangerousFunctions[dialect]; !ok {
+		return violation(CodeUnsupportedDialect, 0, "no read-only rules for dialect %q", dialect)
+	}
+	tokens, err := tokenize(dialect, query)
+	if err != nil {
+		return err
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenSemicolon {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return violation(CodeEmptyQuery, 0, "query is empty")
+	}
+	for _, tok := range tokens {
+		if tok.kind == tokenSemicolon {
+			return violation(CodeMultipleStatements, tok.offset, "only a single statement is allowed")
+		}
+	}
+
+	first := tokens[0]
+	for i := 0; i < len(tokens) && tokens[i].kind == tokenPunct && tokens[i].text == "("; i++ {
+		if i+1 < len(tokens) {
+			first = tokens[i+1]
+		}
+	}
+	if first.kind != tokenWord || !statementStarts[first.text] {
+		return violation(CodeNotSelect, first.offset, "statement must start with SELECT, WITH, or VALUES, found %q", first.text)
+	}
+
+	for i, tok := range tokens {
+		if tok.kind != tokenWord {
+			continue
+		}
+		// Functions are checked even when schema-qualified (pg_catalog.pg_sleep).
+		if dangerousFunctions[dialect][tok.text] && isCall(tokens, i) {
+			return violation(CodeDangerousFunction, tok.offset, "function %s is not allowed for %s", strings.ToLower(tok.text), dialect)
+		}
+		if dangerousPackages[dialect][tok.text] {
+			return violation(CodeDangerousFunction, tok.offset, "package %s is not allowed for %s", strings.ToLower(tok.text), dialect)
+		}
+		if qualified(tokens, i) {
+			continue
+		}
+		if tok.text == "FOR" && i+1 < len(tokens) && isLockMode(tokens[i+1:]) {
+			return violation(CodeLockingClause, tok.offset, "locking clause FOR %s is not allowed", tokens[i+1].text)
+		}
+		if tok.text == "LOCK" && i+2 < len(tokens) && tokens[i+1].text == "IN" && tokens[i+2].text == "SHARE" {
+			return violation(CodeLockingClause, tok.offset, "locking clause LOCK IN SHARE MODE is not allowed")
+		}
+		if writeKeywords[tok.text] {
+			return violation(CodeWriteKeyword, tok.offset, "%s is not allowed in a read-only query", tok.text)
+		}
+		if dangerousKeywords[dialect][tok.text] {
+			return violation(CodeDangerousFunction, tok.offset, "%s is not allowed in a read-only query", tok.text)
+		}
+	}
+	if dialect == DialectDuckDB {
+		if tok, ok := fileSource(tokens); ok {
+			return violation(CodeDangerousFunction, tok.offset, "table source %s reads a file or URL, which is not allowed for %s", tok.text, dialect)
+		}
+	}
+	return nil
+}
+
+// fromClauseEnds are the keywords that close a FROM clause.
+var fromClauseEnds = set("WHERE", "GROUP", "HAVING", "QUALIFY", "WINDOW", "ORDER", "LIMIT", "OFFSET", "UNION", "EXCEPT", "INTERSECT", "SELECT")
+
+// fileSource finds a string literal used as a table source (FROM '/etc/passwd',
+// JOIN 'https://...'), which DuckDB reads as a file or URL. A double-quoted
+// name that looks like a path is caught too, as DuckDB scans unknown table
+// names with a file extension. Only parentheses that open a query count, so
+// EXTRACT(YEAR FROM '2024-01-01') is left alone.
+func fileSource(tokens []token) (token, bool) {
+	// levels tracks, per open parenthesis, whether it holds a query and
+	// whether a FROM clause is open in it.
+	type level struct{ query, from bool }
+	levels := []level{{query: true}}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		top := &levels[len(levels)-1]
+		switch {
+		case tok.kind == tokenPunct && tok.text == "(":
+			next := level{}
+			if i+1 < len(tokens) && tokens[i+1].kind == tokenWord {
+				next.query = statementStarts[tokens[i+1].text] || tokens[i+1].text == "FROM"
+			}
+			levels = append(levels, next)
+			continue
+		case tok.kind == tokenPunct && tok.text == ")":
+			if len(levels) > 1 {
+				levels = levels[:len(levels)-1]
+			}
+			continue
+		case tok.kind == tokenWord && (tok.text == "FROM" || tok.text == "JOIN") && top.query:
+			top.from = true
+		case tok.kind == tokenWord && fromClauseEnds[tok.text]:
+			top.from = false
+			continue
+		case tok.kind == tokenPunct && tok.text == "," && top.from:
+		default:
+			continue
+		}
+		j := i + 1
+		if j+1 < len(tokens) && tokens[j].kind == tokenWord && tokens[j].text == "E" && tokens[j+1].kind == tokenQuoted && tokens[j+1].offset == tokens[j].offset+1 {
+			j++
+		}
+		if j < len(tokens) && tokens[j].kind == tokenQuoted && pathLike(tokens[j].text) {
+			return tokens[j], true
+		}
+	}
+	return token{}, false
+}
+
+// pathLike reports whether a quoted table source names a file or URL rather
+// than a table: any string literal, or a quoted identifier with a path
+// separator, scheme, or file extension in it.
+func pathLike(quoted string) bool {
+	if !strings.HasPrefix(quoted, "\"") {
+		return true
+	}
+	return strings.ContainsAny(quoted, "./\\:")
+}
+
+// qualified reports whether the word is part of a dotted name (t.update),
+// where it is a column or table rather than a keyword.
+func qualified(tokens []token, i int) bool {
+	if i > 0 && tokens[i-1].kind == tokenPunct && tokens[i-1].text == "." {
+		return true
+	}
+	return i+1 < len(tokens) && tokens[i+1].kind == tokenPunct && tokens[i+1].text == "."
+}
+
+func isCall(tokens []token, i int) bool {
+	return i+1 < len(tokens) && tokens[i+1].kind == tokenPunct && tokens[i+1].text == "("
+}
+
+func isLockMode(rest []token) bool {
+	switch rest[0].text {
+	case "UPDATE", "SHARE":
+		return true
+	case "NO", "KEY":
+		return len(rest) > 1 && (rest[1].text == "KEY" || rest[1].text == "SHARE" || rest[1].text == "UPDATE")
+	}
+	return false
+}
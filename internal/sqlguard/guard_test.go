@@ -0,0 +1,78 @@
+package sqlguard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		dialect string
+		query   string
+		code    string
+	}{
+		{DialectPostgres, "SELECT * FROM orders WHERE status = 'DELETE' AND note = 'a;b';", ""},
+		{DialectPostgres, "WITH x AS (SELECT 1 AS update_count) SELECT t.update FROM x t", ""},
+		{DialectPostgres, "SELECT $body$ DROP TABLE x; $body$ AS txt, created_at FROM t WHERE id > $1", ""},
+		{DialectTSQL, "SELECT [delete] FROM dbo.orders WITH (NOLOCK) WHERE id > @p1", ""},
+		{DialectMySQL, "SELECT `insert` FROM t -- ; DROP TABLE t", ""},
+		{DialectBigQuery, "SELECT * FROM `proj.ds.t` WHERE x IN (?, ?)", ""},
+		{DialectPostgres, "SELECT 1; DROP TABLE orders", CodeMultipleStatements},
+		{DialectPostgres, "DELETE FROM orders", CodeNotSelect},
+		{DialectPostgres, "WITH gone AS (DELETE FROM orders RETURNING *) SELECT * FROM gone", CodeWriteKeyword},
+		{DialectTSQL, "SELECT * INTO backup FROM orders", CodeWriteKeyword},
+		{DialectPostgres, "SELECT * FROM orders FOR UPDATE", CodeLockingClause},
+		{DialectPostgres, "SELECT pg_catalog.pg_sleep(10)", CodeDangerousFunction},
+		{DialectMySQL, "SELECT SLEEP(5)", CodeDangerousFunction},
+		{DialectMySQL, "SELECT 1 /*!50000 , (SELECT 1) */", CodeExecutableComment},
+		{DialectMySQL, "SELECT id --1 INTO OUTFILE '/tmp/pwn'\nFROM t", CodeWriteKeyword},
+		{DialectMySQL, "SELECT id --1 + SLEEP(5)\nFROM t", CodeDangerousFunction},
+		{DialectMySQL, "SELECT id -- ; DROP TABLE t\nFROM t --", ""},
+		{DialectTSQL, "SELECT 1 WAITFOR DELAY '00:00:05'", CodeDangerousFunction},
+		{DialectSnowflake, "SELECT SYSTEM$WAIT(10)", CodeDangerousFunction},
+		{DialectSnowflake, "SELECT 1 // '\n, SYSTEM$WAIT(10) --'", CodeDangerousFunction},
+		{DialectSnowflake, "SELECT a // it's a note; DROP TABLE t\nFROM t", ""},
+		{DialectBigQuery, "SELECT * FROM EXTERNAL_QUERY('conn', 'DELETE FROM t')", CodeDangerousFunction},
+		{DialectSQLite, "SELECT [order], `from` FROM events WHERE id > ?", ""},
+		{DialectSQLite, "SELECT load_extension('/tmp/evil.so')", CodeDangerousFunction},
+		{DialectSQLite, "ATTACH DATABASE 'other.db' AS o", CodeNotSelect},
+		{DialectDuckDB, "SELECT * FROM read_csv('/etc/passwd')", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT 1 FROM t; INSTALL httpfs", CodeMultipleStatements},
+		{DialectDuckDB, "SELECT * FROM '/etc/passwd'", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT * FROM 'https://example.com/x.csv'", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT * FROM read_xlsx('/x.xlsx')", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT * FROM st_read('/x.shp')", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT * FROM events e JOIN '/tmp/x.parquet' p ON e.id = p.id", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT * FROM events, (SELECT 1) s, E'/etc/passwd'", CodeDangerousFunction},
+		{DialectDuckDB, "WITH f AS (FROM $$/etc/passwd$$) SELECT * FROM f", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT * FROM \"data/x.csv\"", CodeDangerousFunction},
+		{DialectDuckDB, "SELECT 'a' AS k, extract(year FROM '2024-01-01'::DATE) FROM \"events\" e, main.t WHERE e.s IN ('x', 'y')", ""},
+		{DialectOracle, "SELECT q'[it's; DROP TABLE t]' AS note, \"ORDER\" FROM orders WHERE id > :1 FETCH FIRST 10 ROWS ONLY", ""},
+		{DialectOracle, "SELECT SYS.DBMS_LOCK.SLEEP(10) FROM dual", CodeDangerousFunction},
+		{DialectOracle, "SELECT utl_http.request('http://example.com') FROM dual", CodeDangerousFunction},
+		{DialectHANA, "SELECT \"VBELN\" FROM \"SAPHANADB\".\"VBAK\" WHERE \"ERDAT\" > ? LIMIT 5", ""},
+		{DialectHANA, "SELECT * FROM vbak FOR UPDATE", CodeLockingClause},
+		{DialectPostgres, "SELECT E'\\'' ; DELETE FROM t; --'", CodeMultipleStatements},
+		{DialectDuckDB, "SELECT e'\\'' ; DELETE FROM t; --'", CodeMultipleStatements},
+		{DialectPostgres, "SELECT E'it\\'s; DROP TABLE t' AS note, 'a\\' AS path FROM t", ""},
+		{DialectPostgres, "SELECT 'unterminated", CodeParseError},
+		{DialectPostgres, "  ;  ", CodeEmptyQuery},
+	}
+	for _, tc := range cases {
+		err := Check(tc.dialect, tc.query)
+		if tc.code == "" {
+			if err != nil {
+				t.Errorf("%s %q: unexpected error: %v", tc.dialect, tc.query, err)
+			}
+			continue
+		}
+		var v *Violation
+		if !errors.As(err, &v) {
+			t.Errorf("%s %q: expected violation %s, got %v", tc.dialect, tc.query, tc.code, err)
+			continue
+		}
+		if v.Code != tc.code {
+			t.Errorf("%s %q: expected %s, got %s", tc.dialect, tc.query, tc.code, v.Code)
+		}
+	}
+}
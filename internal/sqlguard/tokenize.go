@@ -0,0 +1,318 @@
+package sqlguard
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuoted
+	tokenNumber
+	tokenParam
+	tokenPunct
+	tokenSemicolon
+	// tokenComment is only produced when scanning for Quoted.
+	tokenComment
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// tokenize splits SQL into words, quoted literals/identifiers, numbers,
+// parameters, and punctuation. Comments and whitespace are dropped. Quoting
+// and comment rules follow the dialect.
+func tokenize(dialect string, sql string) ([]token, error) {
+	return scan(dialect, sql, false)
+}
+
+// scan tokenizes sql, keeping comments as tokens when comments is set.
+func scan(dialect string, sql string, comments bool) ([]token, error) {
+	var tokens []token
+	runes := []rune(sql)
+	comment := func(start int, end int) {
+		if comments {
+			tokens = append(tokens, token{kind: tokenComment, text: string(runes[start:end]), offset: start})
+		}
+	}
+	n := len(runes)
+	i := 0
+	for i < n {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < n && runes[i+1] == '-' && (dialect != DialectMySQL || i+2 == n || runes[i+2] <= ' '):
+			// MySQL only starts a comment when whitespace or a control
+			// character follows --; "id --1" is id - -1.
+			end := skipLine(runes, i)
+			comment(i, end)
+			i = end
+		case r == '/' && i+1 < n && runes[i+1] == '/' && dialect == DialectSnowflake:
+			end := skipLine(runes, i)
+			comment(i, end)
+			i = end
+		case r == '#' && (dialect == DialectMySQL || dialect == DialectBigQuery):
+			end := skipLine(runes, i)
+			comment(i, end)
+			i = end
+		case r == '/' && i+1 < n && runes[i+1] == '*':
+			if dialect == DialectMySQL && i+2 < n && runes[i+2] == '!' {
+				return nil, violation(CodeExecutableComment, i, "MySQL executable comments (/*! ... */) are not allowed")
+			}
+			end, err := skipBlockComment(runes, i, dialect == DialectPostgres || dialect == DialectSnowflake || dialect == DialectDuckDB)
+			if err != nil {
+				return nil, err
+			}
+			comment(i, end)
+			i = end
+		case r == '\'':
+			end, err := skipQuoted(runes, i, '\'', backslashEscapes(dialect) || escapeString(dialect, tokens, i))
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: string(runes[i:end]), offset: i})
+			i = end
+		case r == '"':
+			end, err := skipQuoted(runes, i, '"', backslashEscapes(dialect) && dialect != DialectPostgres)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: string(runes[i:end]), offset: i})
+			i = end
+		case r == '`' && (dialect == DialectMySQL || dialect == DialectBigQuery || dialect == DialectSQLite):
+			end, err := skipQuoted(runes, i, '`', false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: string(runes[i:end]), offset: i})
+			i = end
+		case r == '[' && (dialect == DialectTSQL || dialect == DialectSQLite):
+			end, err := skipQuoted(runes, i, ']', false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: string(runes[i:end]), offset: i})
+			i = end
+		case r == '$' && i+1 < n && unicode.IsDigit(runes[i+1]):
+			start := i
+			i++
+			for i < n && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenParam, text: string(runes[start:i]), offset: start})
+		case r == '$' && (dialect == DialectPostgres || dialect == DialectSnowflake || dialect == DialectDuckDB):
+			tag, ok := dollarTag(runes, i)
+			if !ok {
+				tokens = append(tokens, token{kind: tokenPunct, text: "$", offset: i})
+				i++
+				continue
+			}
+			end := closeDollarQuote(runes, i+len(tag), tag)
+			if end < 0 {
+				return nil, violation(CodeParseError, i, "unterminated dollar-quoted string")
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: string(runes[i:end]), offset: i})
+			i = end
+		case dialect == DialectOracle && isQQuoteStart(runes, i):
+			end, err := skipQQuote(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: string(runes[i:end]), offset: i})
+			i = end
+		case isWordStart(r):
+			start := i
+			for i < n && isWordPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: strings.ToUpper(string(runes[start:i])), offset: start})
+		case unicode.IsDigit(r):
+			start := i
+			for i < n && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), offset: start})
+		case r == ';':
+			tokens = append(tokens, token{kind: tokenSemicolon, text: ";", offset: i})
+			i++
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(r), offset: i})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// Span is a half-open byte range of a query.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Quoted returns the spans of quoted literals, quoted identifiers, and
+// comments in sql, in order, following the dialect's quoting and comment
+// rules. Text outside them is SQL code.
+func Quoted(dialect string, sql string) ([]Span, error) {
+	tokens, err := scan(dialect, sql, true)
+	if err != nil {
+		return nil, err
+	}
+	// Token offsets count runes; spans count bytes.
+	bytes := make([]int, 0, len(sql)+1)
+	for offset := range sql {
+		bytes = append(bytes, offset)
+	}
+	bytes = append(bytes, len(sql))
+	var spans []Span
+	for _, tok := range tokens {
+		if tok.kind == tokenQuoted || tok.kind == tokenComment {
+			spans = append(spans, Span{Start: bytes[tok.offset], End: bytes[tok.offset+len([]rune(tok.text))]})
+		}
+	}
+	return spans, nil
+}
+
+func backslashEscapes(dialect string) bool {
+	return dialect == DialectMySQL || dialect == DialectBigQuery || dialect == DialectSnowflake
+}
+
+// escapeString reports whether the quote at i opens a Postgres-style E'...'
+// string, which takes backslash escapes: the quote directly follows a word
+// token E.
+func escapeString(dialect string, tokens []token, i int) bool {
+	if dialect != DialectPostgres && dialect != DialectDuckDB || len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenWord && last.text == "E" && last.offset == i-1
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func skipLine(runes []rune, i int) int {
+	for i < len(runes) && runes[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+func skipBlockComment(runes []rune, i int, nested bool) (int, error) {
+	start := i
+	depth := 0
+	for i < len(runes) {
+		if runes[i] == '/' && i+1 < len(runes) && runes[i+1] == '*' {
+			if depth == 0 || nested {
+				depth++
+			}
+			i += 2
+			continue
+		}
+		if runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/' {
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, nil
+			}
+			continue
+		}
+		i++
+	}
+	return 0, violation(CodeParseError, start, "unterminated block comment")
+}
+
+// skipQuoted returns the index just past a quoted run starting at i. A doubled
+// closing quote is an escaped quote; backslash escapes apply when enabled.
+func skipQuoted(runes []rune, i int, closing rune, backslash bool) (int, error) {
+	start := i
+	i++
+	for i < len(runes) {
+		switch {
+		case backslash && runes[i] == '\\':
+			i += 2
+		case runes[i] == closing:
+			if i+1 < len(runes) && runes[i+1] == closing {
+				i += 2
+				continue
+			}
+			return i + 1, nil
+		default:
+			i++
+		}
+	}
+	return 0, violation(CodeParseError, start, "unterminated quoted string or identifier")
+}
+
+// isQQuoteStart matches an Oracle alternative-quoting opener: q'x or nq'x.
+func isQQuoteStart(runes []rune, i int) bool {
+	if i > 0 && isWordPart(runes[i-1]) {
+		return false
+	}
+	if runes[i] == 'n' || runes[i] == 'N' {
+		i++
+	}
+	return i+2 < len(runes) && (runes[i] == 'q' || runes[i] == 'Q') && runes[i+1] == '\''
+}
+
+// skipQQuote returns the index just past q'<x>...<y>', where y closes the
+// bracket x or equals it; quotes inside need no escaping.
+func skipQQuote(runes []rune, i int) (int, error) {
+	start := i
+	for runes[i] != '\'' {
+		i++
+	}
+	open := runes[i+1]
+	closing := open
+	switch open {
+	case '[':
+		closing = ']'
+	case '{':
+		closing = '}'
+	case '(':
+		closing = ')'
+	case '<':
+		closing = '>'
+	}
+	for j := i + 2; j+1 < len(runes); j++ {
+		if runes[j] == closing && runes[j+1] == '\'' {
+			return j + 2, nil
+		}
+	}
+	return 0, violation(CodeParseError, start, "unterminated quoted string or identifier")
+}
+
+// dollarTag matches a Postgres-style $tag$ or $$ opener at i.
+func dollarTag(runes []rune, i int) ([]rune, bool) {
+	j := i + 1
+	for j < len(runes) && runes[j] != '$' {
+		if !(runes[j] == '_' || unicode.IsLetter(runes[j]) || (j > i+1 && unicode.IsDigit(runes[j]))) {
+			return nil, false
+		}
+		j++
+	}
+	if j >= len(runes) {
+		return nil, false
+	}
+	return runes[i : j+1], true
+}
+
+// closeDollarQuote returns the index just past the closing tag, or -1.
+func closeDollarQuote(runes []rune, from int, tag []rune) int {
+	for k := from; k+len(tag) <= len(runes); k++ {
+		if string(runes[k:k+len(tag)]) == string(tag) {
+			return k + len(tag)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,58 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const SchemaVersion = 1
+
+// State is project-level bookkeeping that must survive across runs.
+type State struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Watermarks    map[string]Watermark `json:"watermarks,omitempty"`
+}
+
+// Path returns the state file location for a project.
+func Path(projectPath string) string {
+	return filepath.Join(projectPath, ".pm-assist", "state.json")
+}
+
+// Load reads the project state, returning an empty state if none exists.
+func Load(projectPath string) (*State, error) {
+	st := &State{SchemaVersion: SchemaVersion, Watermarks: map[string]Watermark{}}
+	data, err := os.ReadFile(Path(projectPath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return st, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, err
+	}
+	if st.Watermarks == nil {
+		st.Watermarks = map[string]Watermark{}
+	}
+	return st, nil
+}
+
+// Save writes the project state atomically.
+func (s *State) Save(projectPath string) error {
+	path := Path(projectPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	s.SchemaVersion = SchemaVersion
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
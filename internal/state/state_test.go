@@ -0,0 +1,47 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaxWatermarkAndRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "extract.csv")
+	content := "id,updated_at\n" +
+		"9,2024-03-01 09:00:00 +0000 UTC\n" +
+		"12,2024-03-02 08:30:00 +0100 CET\n" +
+		"10,\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	value, ok, err := MaxWatermark(path, "updated_at", KindTimestamp)
+	if err != nil || !ok {
+		t.Fatalf("timestamp watermark: %v %v", ok, err)
+	}
+	if value != "2024-03-02T07:30:00Z" {
+		t.Fatalf("unexpected timestamp watermark: %s", value)
+	}
+	value, _, err = MaxWatermark(path, "id", KindID)
+	if err != nil || value != "12" {
+		t.Fatalf("unexpected id watermark: %s %v", value, err)
+	}
+
+	st, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load empty: %v", err)
+	}
+	st.Watermarks["warehouse"] = Watermark{Column: "id", Kind: KindID, Value: value}
+	if err := st.Save(dir); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	arg, err := loaded.Watermarks["warehouse"].Arg()
+	if err != nil || arg != int64(12) {
+		t.Fatalf("unexpected arg: %v %v", arg, err)
+	}
+}
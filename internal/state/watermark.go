@@ -0,0 +1,143 @@
+package state
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/eventlog"
+)
+
+const (
+	KindTimestamp = "timestamp"
+	KindID        = "id"
+)
+
+// Watermark is the high-water mark of the last incremental extract for a connector.
+type Watermark struct {
+	Column    string `json:"column"`
+	Kind      string `json:"kind"`
+	Value     string `json:"value,omitempty"`
+	RunID     string `json:"run_id,omitempty"`
+	LogPath   string `json:"log_path,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Arg returns the watermark value typed for use as a query parameter.
+func (w Watermark) Arg() (any, error) {
+	switch w.Kind {
+	case KindTimestamp:
+		parsed, err := parseWatermarkTime(w.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp watermark %q: %w", w.Value, err)
+		}
+		return parsed, nil
+	case KindID:
+		if n, err := strconv.ParseInt(w.Value, 10, 64); err == nil {
+			return n, nil
+		}
+		return w.Value, nil
+	default:
+		return nil, fmt.Errorf("unsupported watermark kind: %s", w.Kind)
+	}
+}
+
+// MaxWatermark scans a CSV extract and returns the largest value of column,
+// compared as timestamps or IDs depending on kind. ok is false when the
+// column holds no values.
+func MaxWatermark(path string, column string, kind string) (value string, ok bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	idx := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return "", false, fmt.Errorf("watermark column not found in extract: %s", column)
+	}
+
+	var (
+		maxTime time.Time
+		maxInt  int64
+		maxText string
+		numeric = true
+	)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if idx >= len(record) || strings.TrimSpace(record[idx]) == "" {
+			continue
+		}
+		raw := strings.TrimSpace(record[idx])
+		switch kind {
+		case KindTimestamp:
+			parsed, err := parseWatermarkTime(raw)
+			if err != nil {
+				return "", false, fmt.Errorf("watermark column %s: %w", column, err)
+			}
+			if !ok || parsed.After(maxTime) {
+				maxTime = parsed
+			}
+		case KindID:
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil && numeric {
+				if !ok || n > maxInt {
+					maxInt = n
+				}
+			} else {
+				numeric = false
+			}
+			if !ok || raw > maxText {
+				maxText = raw
+			}
+		default:
+			return "", false, fmt.Errorf("unsupported watermark kind: %s", kind)
+		}
+		ok = true
+	}
+	if !ok {
+		return "", false, nil
+	}
+	switch {
+	case kind == KindTimestamp:
+		return maxTime.UTC().Format(time.RFC3339Nano), true, nil
+	case numeric:
+		return strconv.FormatInt(maxInt, 10), true, nil
+	default:
+		return maxText, true, nil
+	}
+}
+
+// parseWatermarkTime accepts the stage log layouts plus the fmt.Sprint form
+// of time.Time that database drivers produce in raw extracts.
+func parseWatermarkTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", value); err == nil {
+		return parsed, nil
+	}
+	return eventlog.ParseTimestamp(value)
+}
@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Value formats.
+const (
+	FormatJSON = "json"
+	FormatAvro = "avro"
+)
+
+// Formats returns the supported value formats.
+func Formats() []string {
+	return []string{FormatJSON, FormatAvro}
+}
+
+// Decoder turns a message value into a decoded JSON document.
+type Decoder interface {
+	Decode(ctx context.Context, value []byte) (any, error)
+}
+
+// NewDecoder returns the decoder for format; Avro needs a schema registry URL.
+func NewDecoder(format string, registryURL string) (Decoder, error) {
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return jsonDecoder{}, nil
+	case FormatAvro:
+		if registryURL == "" {
+			return nil, errors.New("avro values require a schema registry URL")
+		}
+		return &avroDecoder{
+			registry: strings.TrimSuffix(registryURL, "/"),
+			http:     &http.Client{Timeout: 30 * time.Second},
+			codecs:   map[uint32]*goavro.Codec{},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported stream format: %s", format)
+	}
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(_ context.Context, value []byte) (any, error) {
+	return decodeJSON(value)
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// avroDecoder reads the Confluent wire format: a zero magic byte, a 4-byte
+// big-endian schema ID, then the Avro binary body. Schemas are fetched from
+// the registry once per ID.
+type avroDecoder struct {
+	registry string
+	http     *http.Client
+	mu       sync.Mutex
+	codecs   map[uint32]*goavro.Codec
+}
+
+func (d *avroDecoder) Decode(ctx context.Context, value []byte) (any, error) {
+	if len(value) < 5 || value[0] != 0 {
+		return nil, errors.New("value is not in the schema registry wire format")
+	}
+	codec, err := d.codec(ctx, binary.BigEndian.Uint32(value[1:5]))
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := codec.NativeFromBinary(value[5:])
+	if err != nil {
+		return nil, err
+	}
+	// The standard-JSON codec writes unions as plain values rather than
+	// {"type": value}, so field paths do not need the branch name.
+	text, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(text)
+}
+
+func (d *avroDecoder) codec(ctx context.Context, id uint32) (*goavro.Codec, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if codec, ok := d.codecs[id]; ok {
+		return codec, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", d.registry, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("schema registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, fmt.Errorf("schema registry: schema %d: %s %s", id, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var payload struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("schema registry: schema %d: %w", id, err)
+	}
+	if payload.SchemaType != "" && payload.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("schema %d is %s, not Avro", id, payload.SchemaType)
+	}
+	codec, err := goavro.NewCodecForStandardJSONFull(payload.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	d.codecs[id] = codec
+	return codec, nil
+}
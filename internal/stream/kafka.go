@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms.
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// readTimeout bounds waiting for the next batch; reads stop at the range end,
+// so a stall means the broker stopped answering.
+const readTimeout = 30 * time.Second
+
+// kafkaSource reads partitions directly from their leaders without a consumer
+// group, so it never commits offsets.
+type kafkaSource struct {
+	dialer  *kafka.Dialer
+	brokers []string
+	topic   string
+}
+
+// NewKafkaSource returns a source for the configured topic. The password is
+// the SASL secret, required when a mechanism is set.
+func NewKafkaSource(cfg config.StreamConfig, password string) (Source, error) {
+	if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+		return nil, errors.New("stream connector requires brokers and a topic")
+	}
+	dialer := &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, ClientID: "pm-assist"}
+	if cfg.TLS {
+		dialer.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.SASLMechanism != "" {
+		if cfg.User == "" || password == "" {
+			return nil, errors.New("SASL authentication requires a user and the password in the credential env var")
+		}
+		var (
+			mechanism sasl.Mechanism
+			err       error
+		)
+		switch strings.ToLower(cfg.SASLMechanism) {
+		case SASLPlain:
+			mechanism = plain.Mechanism{Username: cfg.User, Password: password}
+		case SASLScramSHA256:
+			mechanism, err = scram.Mechanism(scram.SHA256, cfg.User, password)
+		case SASLScramSHA512:
+			mechanism, err = scram.Mechanism(scram.SHA512, cfg.User, password)
+		default:
+			return nil, fmt.Errorf("unsupported SASL mechanism: %s", cfg.SASLMechanism)
+		}
+		if err != nil {
+			return nil, err
+		}
+		dialer.SASLMechanism = mechanism
+	}
+	return &kafkaSource{dialer: dialer, brokers: cfg.Brokers, topic: cfg.Topic}, nil
+}
+
+func (k *kafkaSource) Partitions(ctx context.Context) ([]int, error) {
+	var lastErr error
+	for _, broker := range k.brokers {
+		conn, err := k.dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		partitions, err := conn.ReadPartitions(k.topic)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(partitions))
+		for i, partition := range partitions {
+			ids[i] = partition.ID
+		}
+		sort.Ints(ids)
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("topic %s not found", k.topic)
+		}
+		return ids, nil
+	}
+	return nil, fmt.Errorf("no broker reachable: %w", lastErr)
+}
+
+// leader dials the partition leader through the first reachable broker.
+func (k *kafkaSource) leader(ctx context.Context, partition int) (*kafka.Conn, error) {
+	var lastErr error
+	for _, broker := range k.brokers {
+		conn, err := k.dialer.DialLeader(ctx, "tcp", broker, k.topic, partition)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (k *kafkaSource) Offsets(ctx context.Context, partition int) (int64, int64, error) {
+	conn, err := k.leader(ctx, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	return conn.ReadOffsets()
+}
+
+func (k *kafkaSource) OffsetAt(ctx context.Context, partition int, t time.Time) (int64, error) {
+	conn, err := k.leader(ctx, partition)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	offset, err := conn.ReadOffset(t)
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		// No message at or after t yet.
+		return conn.ReadLastOffset()
+	}
+	return offset, nil
+}
+
+func (k *kafkaSource) Read(ctx context.Context, partition int, start int64, end int64, fn func(Message) error) error {
+	conn, err := k.leader(ctx, partition)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Seek(start, kafka.SeekAbsolute); err != nil {
+		return err
+	}
+	next := start
+	for next < end {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		batch := conn.ReadBatch(1, 10<<20)
+		for {
+			msg, err := batch.ReadMessage()
+			if err != nil {
+				break
+			}
+			if msg.Offset >= end {
+				next = end
+				break
+			}
+			if err := fn(Message{Partition: partition, Offset: msg.Offset, Time: msg.Time, Key: msg.Key, Value: msg.Value}); err != nil {
+				batch.Close()
+				return err
+			}
+			next = msg.Offset + 1
+		}
+		// Offsets can skip (compaction, transaction markers), so progress is
+		// taken from the batch as well as the messages read.
+		next = max(next, batch.Offset())
+		if err := batch.Close(); err != nil && next < end {
+			return fmt.Errorf("read from offset %d: %w", next, err)
+		}
+	}
+	return nil
+}
+
+func (k *kafkaSource) Close() error {
+	return nil
+}
@@ -0,0 +1,209 @@
+// Package stream materialises a range of Kafka topic messages into a CSV
+// extract. Messages are decoded from JSON or Avro and projected into columns
+// with the same JSONPath fields as API connectors.
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pm-assist/pm-assist/internal/api"
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// Offset keywords.
+const (
+	OffsetEarliest = "earliest"
+	OffsetLatest   = "latest"
+)
+
+// Metadata columns written before the projected fields.
+var metadataColumns = []string{"kafka_partition", "kafka_offset", "kafka_timestamp", "kafka_key"}
+
+// Message is one consumed record.
+type Message struct {
+	Partition int
+	Offset    int64
+	Time      time.Time
+	Key       []byte
+	Value     []byte
+}
+
+// Source reads messages from one topic.
+type Source interface {
+	Partitions(ctx context.Context) ([]int, error)
+	// Offsets returns the first available offset and the high watermark (the
+	// offset the next message will get).
+	Offsets(ctx context.Context, partition int) (first int64, last int64, err error)
+	// OffsetAt returns the first offset with a timestamp at or after t, or
+	// the high watermark when there is none.
+	OffsetAt(ctx context.Context, partition int, t time.Time) (int64, error)
+	// Read calls fn for each message in [start, end).
+	Read(ctx context.Context, partition int, start int64, end int64, fn func(Message) error) error
+	Close() error
+}
+
+// Range selects the messages to read from each partition.
+type Range struct {
+	StartOffset string
+	EndOffset   string
+	StartTime   time.Time
+	EndTime     time.Time
+	MaxMessages int64
+}
+
+// RangeFromConfig parses the configured offsets and times.
+func RangeFromConfig(cfg config.StreamConfig) (Range, error) {
+	r := Range{StartOffset: cfg.StartOffset, EndOffset: cfg.EndOffset, MaxMessages: cfg.MaxMessages}
+	for _, bound := range []struct {
+		name   string
+		value  string
+		target *time.Time
+	}{
+		{"start_time", cfg.StartTime, &r.StartTime},
+		{"end_time", cfg.EndTime, &r.EndTime},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return r, fmt.Errorf("invalid %s %q: use RFC 3339, e.g. 2024-01-31T00:00:00Z", bound.name, bound.value)
+		}
+		*bound.target = parsed
+	}
+	if _, err := parseOffset(r.StartOffset, 0, 0); err != nil {
+		return r, err
+	}
+	if _, err := parseOffset(r.EndOffset, 0, 0); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// parseOffset resolves an offset keyword or number against a partition's
+// first offset and high watermark.
+func parseOffset(value string, first int64, last int64) (int64, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", OffsetEarliest:
+		return first, nil
+	case OffsetLatest:
+		return last, nil
+	}
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset %q: use earliest, latest, or a number", value)
+	}
+	return offset, nil
+}
+
+// PartitionRange records what was read from one partition; End is exclusive.
+type PartitionRange struct {
+	Partition int
+	Start     int64
+	End       int64
+	Messages  int64
+}
+
+// Result summarises a materialised range.
+type Result struct {
+	Topic      string
+	Partitions []PartitionRange
+	Rows       int64
+	Columns    []string
+}
+
+// Materialize reads the range from each partition (all when partitions is
+// empty) and writes one CSV row per message: partition, offset, timestamp,
+// and key, followed by the projected value fields. Tombstones (messages
+// without a value) are counted but not written.
+func Materialize(ctx context.Context, source Source, topic string, partitions []int, r Range, decoder Decoder, project *api.Projection, outputPath string) (Result, error) {
+	result := Result{Topic: topic}
+	if len(partitions) == 0 {
+		var err error
+		if partitions, err = source.Partitions(ctx); err != nil {
+			return result, err
+		}
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+	writer := project.NewWriter(file, filepath.Dir(outputPath), metadataColumns)
+	defer writer.Discard()
+
+	for _, partition := range partitions {
+		start, end, err := resolveRange(ctx, source, partition, r)
+		if err != nil {
+			return result, fmt.Errorf("partition %d: %w", partition, err)
+		}
+		read := PartitionRange{Partition: partition, Start: start, End: end}
+		if start < end {
+			err = source.Read(ctx, partition, start, end, func(msg Message) error {
+				read.Messages++
+				if msg.Value == nil {
+					return nil
+				}
+				value, err := decoder.Decode(ctx, msg.Value)
+				if err != nil {
+					return fmt.Errorf("decode offset %d: %w", msg.Offset, err)
+				}
+				meta := []string{strconv.Itoa(msg.Partition), strconv.FormatInt(msg.Offset, 10), msg.Time.UTC().Format(time.RFC3339Nano), string(msg.Key)}
+				if err := writer.Write(meta, value); err != nil {
+					return err
+				}
+				result.Rows++
+				return nil
+			})
+			if err != nil {
+				return result, fmt.Errorf("partition %d: %w", partition, err)
+			}
+		}
+		result.Partitions = append(result.Partitions, read)
+	}
+	if result.Columns, err = writer.Close(); err != nil {
+		return result, err
+	}
+	return result, file.Close()
+}
+
+// resolveRange turns the range into [start, end) offsets for one partition,
+// clamped to what the partition still holds.
+func resolveRange(ctx context.Context, source Source, partition int, r Range) (int64, int64, error) {
+	first, last, err := source.Offsets(ctx, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	start, err := parseOffset(r.StartOffset, first, last)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !r.StartTime.IsZero() {
+		if start, err = source.OffsetAt(ctx, partition, r.StartTime); err != nil {
+			return 0, 0, err
+		}
+	}
+	end := last
+	if r.EndOffset != "" {
+		if end, err = parseOffset(r.EndOffset, last, last); err != nil {
+			return 0, 0, err
+		}
+	}
+	if !r.EndTime.IsZero() {
+		if end, err = source.OffsetAt(ctx, partition, r.EndTime); err != nil {
+			return 0, 0, err
+		}
+	}
+	start = max(start, first)
+	end = min(end, last)
+	if r.MaxMessages > 0 {
+		end = min(end, start+r.MaxMessages)
+	}
+	return start, max(end, start), nil
+}
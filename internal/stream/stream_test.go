@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/pm-assist/pm-assist/internal/api"
+	"github.com/pm-assist/pm-assist/internal/config"
+)
+
+// fakeSource holds messages per partition; offsets start at base and the
+// message at offset base+i has timestamp day i.
+type fakeSource struct {
+	base     int64
+	messages map[int][][]byte
+}
+
+var day0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (f *fakeSource) Partitions(context.Context) ([]int, error) {
+	return []int{0, 1}, nil
+}
+
+func (f *fakeSource) Offsets(_ context.Context, partition int) (int64, int64, error) {
+	return f.base, f.base + int64(len(f.messages[partition])), nil
+}
+
+func (f *fakeSource) OffsetAt(_ context.Context, partition int, t time.Time) (int64, error) {
+	for i := range f.messages[partition] {
+		if !day0.AddDate(0, 0, i).Before(t) {
+			return f.base + int64(i), nil
+		}
+	}
+	return f.base + int64(len(f.messages[partition])), nil
+}
+
+func (f *fakeSource) Read(_ context.Context, partition int, start int64, end int64, fn func(Message) error) error {
+	for offset := start; offset < end; offset++ {
+		i := offset - f.base
+		msg := Message{Partition: partition, Offset: offset, Time: day0.AddDate(0, 0, int(i)), Key: []byte("k"), Value: f.messages[partition][i]}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSource) Close() error { return nil }
+
+func TestMaterializeJSONRange(t *testing.T) {
+	source := &fakeSource{base: 100, messages: map[int][][]byte{
+		0: {[]byte(`{"order":"A","step":"Create"}`), []byte(`{"order":"A","step":"Ship","meta":{"user":"u1"}}`), nil, []byte(`{"order":"A","step":"Bill"}`)},
+		1: {[]byte(`{"order":"B","step":"Create"}`)},
+	}}
+	project, err := api.NewProjection([]config.APIField{{Name: "case_id", Path: "$.order"}, {Name: "activity", Path: "step"}, {Name: "user", Path: "$.meta.user"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := RangeFromConfig(config.StreamConfig{StartOffset: "101", EndTime: "2024-01-04T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder, _ := NewDecoder(FormatJSON, "")
+	out := filepath.Join(t.TempDir(), "source_extract.csv")
+	result, err := Materialize(context.Background(), source, "orders", nil, r, decoder, project, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Partition 0 reads offsets 101-102 (the tombstone is counted, not
+	// written); partition 1 starts past its only message.
+	want := []PartitionRange{{Partition: 0, Start: 101, End: 103, Messages: 2}, {Partition: 1, Start: 101, End: 101}}
+	if result.Rows != 1 || len(result.Partitions) != 2 || result.Partitions[0] != want[0] || result.Partitions[1] != want[1] {
+		t.Fatalf("result = %+v", result)
+	}
+	data, _ := os.ReadFile(out)
+	expected := "kafka_partition,kafka_offset,kafka_timestamp,kafka_key,case_id,activity,user\n" +
+		"0,101,2024-01-02T00:00:00Z,k,A,Ship,u1\n"
+	if string(data) != expected {
+		t.Fatalf("extract =\n%s\nwant\n%s", data, expected)
+	}
+	if _, err := RangeFromConfig(config.StreamConfig{StartOffset: "first"}); err == nil {
+		t.Fatal("expected an invalid offset to fail")
+	}
+}
+
+func TestMaterializeInfersColumnsFromEveryMessage(t *testing.T) {
+	source := &fakeSource{messages: map[int][][]byte{
+		0: {[]byte(`{"order":"A","step":"Create"}`)},
+		1: {[]byte(`{"order":"B","step":"Ship","carrier":"dhl"}`)},
+	}}
+	decoder, _ := NewDecoder(FormatJSON, "")
+	project, _ := api.NewProjection(nil)
+	out := filepath.Join(t.TempDir(), "source_extract.csv")
+	result, err := Materialize(context.Background(), source, "orders", nil, Range{}, decoder, project, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	expected := "kafka_partition,kafka_offset,kafka_timestamp,kafka_key,carrier,order,step\n" +
+		"0,0,2024-01-01T00:00:00Z,k,,A,Create\n" +
+		"1,0,2024-01-01T00:00:00Z,k,dhl,B,Ship\n"
+	if result.Rows != 2 || len(result.Columns) != 7 || string(data) != expected {
+		t.Fatalf("columns = %v, extract =\n%s\nwant\n%s", result.Columns, data, expected)
+	}
+}
+
+func TestAvroDecoderUsesSchemaRegistry(t *testing.T) {
+	schema := `{"type":"record","name":"Event","fields":[
+		{"name":"case_id","type":"string"},
+		{"name":"activity","type":"string"},
+		{"name":"resource","type":["null","string"],"default":null}
+	]}`
+	lookups := 0
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		lookups++
+		json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+	}))
+	defer registry.Close()
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(native map[string]any) []byte {
+		body, err := codec.BinaryFromNative(nil, native)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := []byte{0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(header[1:], 7)
+		return append(header, body...)
+	}
+	source := &fakeSource{messages: map[int][][]byte{
+		0: {encode(map[string]any{"case_id": "C1", "activity": "Open", "resource": goavro.Union("string", "alice")})},
+		1: {encode(map[string]any{"case_id": "C2", "activity": "Close", "resource": nil})},
+	}}
+	decoder, err := NewDecoder(FormatAvro, registry.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, _ := api.NewProjection(nil)
+	out := filepath.Join(t.TempDir(), "source_extract.csv")
+	if _, err := Materialize(context.Background(), source, "events", nil, Range{}, decoder, project, out); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "kafka_key,activity,case_id,resource") ||
+		!strings.HasSuffix(lines[1], ",Open,C1,alice") || !strings.HasSuffix(lines[2], ",Close,C2,") || lookups != 1 {
+		t.Fatalf("extract (%d schema lookups):\n%s", lookups, data)
+	}
+	if _, err := decoder.Decode(context.Background(), []byte(`{"plain":"json"}`)); err == nil {
+		t.Fatal("expected a value without the wire format header to fail")
+	}
+}
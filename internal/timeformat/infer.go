@@ -0,0 +1,154 @@
+package timeformat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// candidates lists the layouts Infer tries. On equal scores the earlier
+// layout wins, so padded layouts come before unpadded ones and slash dates
+// read month first, as US exports do; dotted and dashed dates read day
+// first.
+var candidates = func() []string {
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05Z07:00",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"2006/01/02 15:04:05",
+		"2006/01/02 15:04",
+		"2006/01/02",
+		"20060102150405",
+		"20060102",
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon Jan 2 15:04:05 2006",
+	}
+	times := []string{" 15:04:05", " 15:04", " 3:04:05 PM", " 3:04 PM", ""}
+	dates := []string{
+		"01/02/2006", "02/01/2006", "1/2/2006", "2/1/2006",
+		"02.01.2006", "01.02.2006", "2.1.2006", "1.2.2006",
+		"02-01-2006", "01-02-2006",
+		"01/02/06", "02/01/06", "02.01.06",
+		"02 Jan 2006", "2 Jan 2006", "02-Jan-2006", "02-Jan-06", "Jan 2, 2006", "02. Jan 2006",
+		"2 January 2006", "January 2, 2006", "2. January 2006",
+	}
+	for _, date := range dates {
+		for _, clock := range times {
+			layouts = append(layouts, date+clock)
+		}
+	}
+	return append(layouts, EpochSeconds, EpochMillis, ISOWeek)
+}()
+
+// Candidate is a layout with the number of sample values it parses.
+type Candidate struct {
+	Layout  string `json:"layout"`
+	Matched int    `json:"matched"`
+}
+
+// Inference is the result of Infer.
+type Inference struct {
+	// Layout is the best-scoring layout, empty when nothing parsed.
+	Layout string `json:"layout"`
+	// Confidence is the share of non-empty sample values Layout parses.
+	Confidence float64 `json:"confidence"`
+	// Samples counts the non-empty values scored.
+	Samples int `json:"samples"`
+	// Ambiguous is set when other layouts parse as many values but read
+	// some of them as different times, such as 03/04/2024 read day first
+	// and month first. Alternatives lists those layouts.
+	Ambiguous    bool     `json:"ambiguous"`
+	Alternatives []string `json:"alternatives,omitempty"`
+	// Candidates are the layouts that parsed any value, best first.
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+// Infer scores every candidate layout against the sample values and picks
+// the one parsing the most of them.
+func Infer(values []string) Inference {
+	var sample []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			sample = append(sample, value)
+		}
+	}
+	result := Inference{Samples: len(sample)}
+	if len(sample) == 0 {
+		return result
+	}
+	parsed := map[string][]time.Time{}
+	for _, layout := range candidates {
+		times := make([]time.Time, len(sample))
+		matched := 0
+		for i, value := range sample {
+			if t, err := Parse(value, layout); err == nil {
+				times[i] = t
+				matched++
+			}
+		}
+		if matched > 0 {
+			result.Candidates = append(result.Candidates, Candidate{Layout: layout, Matched: matched})
+			parsed[layout] = times
+		}
+	}
+	if len(result.Candidates) == 0 {
+		return result
+	}
+	// Stable, so candidate order breaks ties.
+	slices.SortStableFunc(result.Candidates, func(a, b Candidate) int { return b.Matched - a.Matched })
+	best := result.Candidates[0]
+	result.Layout = best.Layout
+	result.Confidence = float64(best.Matched) / float64(len(sample))
+	for _, other := range result.Candidates[1:] {
+		if other.Matched < best.Matched {
+			break
+		}
+		// List one layout per distinct reading.
+		distinct := !sameTimes(parsed[best.Layout], parsed[other.Layout])
+		for _, alternative := range result.Alternatives {
+			distinct = distinct && !sameTimes(parsed[alternative], parsed[other.Layout])
+		}
+		if distinct {
+			result.Ambiguous = true
+			result.Alternatives = append(result.Alternatives, other.Layout)
+		}
+	}
+	return result
+}
+
+// sameTimes reports whether two layouts read every value both parse as the
+// same time.
+func sameTimes(a []time.Time, b []time.Time) bool {
+	for i := range a {
+		if !a[i].IsZero() && !b[i].IsZero() && !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse parses value with the inferred layout, then with the other
+// candidates in rank order, so a column mixing layouts still parses.
+func (inf Inference) Parse(value string) (time.Time, error) {
+	for _, candidate := range inf.Candidates {
+		if parsed, err := Parse(value, candidate.Layout); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp format")
+}
+
+// Describe summarises the inference for CLI output.
+func (inf Inference) Describe() string {
+	if inf.Layout == "" {
+		return fmt.Sprintf("no known layout in %d sampled values", inf.Samples)
+	}
+	return fmt.Sprintf("%s (%.0f%% of %d sampled values)", inf.Layout, inf.Confidence*100, inf.Samples)
+}
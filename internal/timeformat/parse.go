@@ -0,0 +1,190 @@
+// Package timeformat infers and parses the timestamp layouts found in event
+// log exports. Layouts are Go reference layouts plus three pseudo-layouts
+// Go cannot express: Unix epoch seconds, epoch milliseconds, and ISO weeks.
+package timeformat
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Pseudo-layouts for formats time.Parse does not support.
+const (
+	// EpochSeconds is a Unix timestamp in seconds, optionally fractional.
+	EpochSeconds = "epoch_seconds"
+	// EpochMillis is a Unix timestamp in milliseconds.
+	EpochMillis = "epoch_millis"
+	// ISOWeek is an ISO 8601 week date such as 2025-W03-2 or 2025W03; a
+	// missing weekday means Monday.
+	ISOWeek = "iso_week"
+)
+
+// Epoch values outside 1990–2100 are taken for plain numbers.
+var (
+	epochMin = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	epochMax = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+var (
+	epochPattern   = regexp.MustCompile(`^\d+(\.\d+)?$`)
+	isoWeekPattern = regexp.MustCompile(`^(\d{4})-?W(\d{2})(?:-?([1-7]))?$`)
+)
+
+// Parse parses value in layout, which is a Go layout or a pseudo-layout.
+// Month names in layouts containing Jan or January may be written in
+// German, French, Spanish, Italian, Dutch, or Portuguese.
+func Parse(value string, layout string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch layout {
+	case EpochSeconds, EpochMillis:
+		return parseEpoch(value, layout)
+	case ISOWeek:
+		return parseISOWeek(value)
+	}
+	parsed, err := time.Parse(layout, value)
+	if err != nil && strings.Contains(layout, "Jan") {
+		if translated, ok := translateMonths(value, strings.Contains(layout, "January")); ok {
+			return time.Parse(layout, translated)
+		}
+	}
+	return parsed, err
+}
+
+// HasZone reports whether values in layout carry a time zone or offset.
+// Epoch values are absolute; values in other layouts without one parse as
+// UTC.
+func HasZone(layout string) bool {
+	switch layout {
+	case EpochSeconds, EpochMillis:
+		return true
+	case ISOWeek:
+		return false
+	}
+	return strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST")
+}
+
+func parseEpoch(value string, layout string) (time.Time, error) {
+	if !epochPattern.MatchString(value) {
+		return time.Time{}, fmt.Errorf("not an epoch timestamp: %q", value)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if layout == EpochMillis {
+		if strings.Contains(value, ".") {
+			return time.Time{}, fmt.Errorf("fractional epoch milliseconds: %q", value)
+		}
+		number /= 1000
+	}
+	seconds, fraction := math.Modf(number)
+	parsed := time.Unix(int64(seconds), int64(math.Round(fraction*1e9))).UTC()
+	if parsed.Before(epochMin) || !parsed.Before(epochMax) {
+		return time.Time{}, fmt.Errorf("epoch timestamp out of range: %q", value)
+	}
+	return parsed, nil
+}
+
+func parseISOWeek(value string) (time.Time, error) {
+	match := isoWeekPattern.FindStringSubmatch(value)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("not an ISO week date: %q", value)
+	}
+	year, _ := strconv.Atoi(match[1])
+	week, _ := strconv.Atoi(match[2])
+	day := 1
+	if match[3] != "" {
+		day, _ = strconv.Atoi(match[3])
+	}
+	// December 28th is always in the last week of its year.
+	if _, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek(); week < 1 || week > weeks {
+		return time.Time{}, fmt.Errorf("week %d out of range in %q", week, value)
+	}
+	// January 4th is always in week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7)
+	return monday.AddDate(0, 0, (week-1)*7+day-1), nil
+}
+
+// monthNames maps lower-case month names and abbreviations in other
+// languages to their month.
+var monthNames = func() map[string]time.Month {
+	long := map[time.Month][]string{
+		time.January:   {"januar", "jänner", "janvier", "enero", "gennaio", "januari", "janeiro"},
+		time.February:  {"februar", "février", "fevrier", "febrero", "febbraio", "februari", "fevereiro"},
+		time.March:     {"märz", "maerz", "mars", "marzo", "maart", "março", "marco"},
+		time.April:     {"avril", "abril", "aprile"},
+		time.May:       {"mai", "mayo", "maggio", "mei", "maio"},
+		time.June:      {"juni", "juin", "junio", "giugno", "junho"},
+		time.July:      {"juli", "juillet", "julio", "luglio", "julho"},
+		time.August:    {"août", "aout", "agosto", "augustus"},
+		time.September: {"septembre", "septiembre", "settembre", "setembro"},
+		time.October:   {"oktober", "octobre", "octubre", "ottobre", "outubro"},
+		time.November:  {"novembre", "noviembre", "novembro"},
+		time.December:  {"dezember", "décembre", "decembre", "diciembre", "dicembre", "dezembro"},
+	}
+	short := map[time.Month][]string{
+		time.January:   {"jan", "jän", "janv", "ene", "gen"},
+		time.February:  {"feb", "févr", "fevr", "fév", "fev"},
+		time.March:     {"mär", "mrz", "mrt", "mars", "mar"},
+		time.April:     {"avr", "abr"},
+		time.May:       {"mai", "may", "mag", "mei"},
+		time.June:      {"juin", "jun", "giu"},
+		time.July:      {"juil", "jul", "lug"},
+		time.August:    {"août", "aout", "ago", "aug"},
+		time.September: {"sept", "sep", "set"},
+		time.October:   {"okt", "oct", "ott", "out"},
+		time.November:  {"nov"},
+		time.December:  {"dez", "déc", "dec", "dic"},
+	}
+	names := map[string]time.Month{}
+	for _, table := range []map[time.Month][]string{short, long} {
+		for month, words := range table {
+			for _, word := range words {
+				names[word] = month
+			}
+		}
+	}
+	return names
+}()
+
+// translateMonths replaces month names in other languages with English
+// ones, long or abbreviated, dropping the period after an abbreviation.
+func translateMonths(value string, long bool) (string, bool) {
+	var out strings.Builder
+	changed := false
+	runes := []rune(value)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) {
+			out.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && unicode.IsLetter(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		month, ok := monthNames[strings.ToLower(word)]
+		english := month.String()
+		if !long {
+			english = english[:3]
+		}
+		if ok && !strings.EqualFold(word, english) {
+			out.WriteString(english)
+			changed = true
+			if j < len(runes) && runes[j] == '.' {
+				j++
+			}
+		} else {
+			out.WriteString(word)
+		}
+		i = j
+	}
+	return out.String(), changed
+}
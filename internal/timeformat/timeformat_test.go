@@ -0,0 +1,55 @@
+package timeformat
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInfer(t *testing.T) {
+	cases := []struct {
+		name      string
+		values    []string
+		layout    string
+		ambiguous []string
+		first     time.Time
+	}{
+		{"day first from a day above 12", []string{"03/04/2024 10:00", "25/04/2024 11:30", ""}, "02/01/2006 15:04", nil, time.Date(2024, 4, 3, 10, 0, 0, 0, time.UTC)},
+		{"day and month ambiguous", []string{"03/04/2024", "05/06/2024"}, "01/02/2006", []string{"02/01/2006"}, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"iso with offset", []string{"2024-01-05T10:00:00.250+01:00"}, time.RFC3339, nil, time.Date(2024, 1, 5, 9, 0, 0, 250e6, time.UTC)},
+		{"epoch seconds", []string{"1704067200", "1704070800.5"}, EpochSeconds, nil, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"epoch millis", []string{"1704067200000"}, EpochMillis, nil, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"iso week", []string{"2025-W01-3", "2025W02"}, ISOWeek, nil, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"german month names", []string{"5. März 2024 14:00", "12. Mai 2024 09:15"}, "2. January 2006 15:04", nil, time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC)},
+		{"french abbreviations", []string{"05 févr. 2024", "17 déc. 2024"}, "02 Jan 2006", nil, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		got := Infer(tc.values)
+		if got.Layout != tc.layout || got.Ambiguous != (tc.ambiguous != nil) || !slices.Equal(got.Alternatives, tc.ambiguous) {
+			t.Errorf("%s: got %s, ambiguous %v %q", tc.name, got.Describe(), got.Ambiguous, got.Alternatives)
+			continue
+		}
+		if got.Confidence != 1 {
+			t.Errorf("%s: confidence %.2f", tc.name, got.Confidence)
+		}
+		if parsed, err := Parse(tc.values[0], got.Layout); err != nil || !parsed.Equal(tc.first) {
+			t.Errorf("%s: parsed %v, %v", tc.name, parsed, err)
+		}
+	}
+
+	mixed := Infer([]string{"2024-01-05", "2024-01-06", "not a date", "06.01.2024"})
+	if mixed.Layout != "2006-01-02" || mixed.Confidence != 0.5 {
+		t.Fatalf("mixed: %s", mixed.Describe())
+	}
+	if _, err := mixed.Parse("07.01.2024"); err != nil {
+		t.Fatalf("expected a lower-ranked layout to parse: %v", err)
+	}
+	if _, err := Parse("2025-W53", ISOWeek); err == nil {
+		t.Fatal("expected week 53 of 2025 to be rejected")
+	}
+	for layout, want := range map[string]bool{time.RFC3339: true, time.RFC1123: true, "2006-01-02 15:04:05 -0700": true, EpochMillis: true, "2006-01-02 15:04:05": false, "02-01-2006": false, ISOWeek: false} {
+		if HasZone(layout) != want {
+			t.Errorf("HasZone(%q) = %v", layout, !want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/filepicker"
@@ -12,6 +13,7 @@ import (
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
 )
 
 type TextPrompt struct {
@@ -135,6 +137,32 @@ func RunWithSpinner(message string, fn func() error) error {
 	return err
 }
 
+// RunWithProgress runs fn behind a spinner whose message fn may update from
+// any goroutine. Without a terminal, updates print as [INFO] lines instead.
+func RunWithProgress(message string, fn func(update func(string)) error) error {
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		fmt.Printf("[INFO] %s\n", message)
+		return fn(func(status string) {
+			fmt.Printf("[INFO] %s\n", status)
+		})
+	}
+	done := make(chan error, 1)
+	p := tea.NewProgram(newSpinnerModel(message, done))
+	go func() {
+		done <- fn(func(status string) {
+			p.Send(statusMsg(status))
+		})
+	}()
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	if m, ok := final.(spinnerModel); ok {
+		return m.err
+	}
+	return nil
+}
+
 func RenderProgress(value float64) string {
 	p := progress.New(progress.WithGradient("#00A0FF", "#00D7AF"))
 	return p.ViewAs(value)
@@ -343,6 +371,9 @@ func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case doneMsg:
 		m.err = msg.err
 		return m, tea.Quit
+	case statusMsg:
+		m.message = string(msg)
+		return m, nil
 	}
 	var cmd tea.Cmd
 	m.spinner, cmd = m.spinner.Update(msg)
@@ -356,6 +387,8 @@ func (m spinnerModel) View() string {
 	return fmt.Sprintf("%s %s", m.spinner.View(), m.message)
 }
 
+type statusMsg string
+
 type doneMsg struct {
 	err error
 }